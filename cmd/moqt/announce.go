@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const (
+	lsUsage    = "ls [flags] <url> [prefix]"
+	watchUsage = "watch [flags] <url> [prefix]"
+)
+
+// announceFlags are the flags shared by the ls and watch commands.
+type announceFlags struct {
+	dialFlags
+
+	match  string
+	asJSON bool
+}
+
+func (f *announceFlags) register(fs *flag.FlagSet) {
+	f.dialFlags.register(fs)
+	fs.StringVar(&f.match, "match", "", "only show broadcast paths matching this regular expression")
+	fs.BoolVar(&f.asJSON, "json", false, "print one JSON object per line")
+}
+
+// announceSession is a session requesting the announcements under a prefix.
+type announceSession struct {
+	client *moqt.Client
+	sess   *moqt.Session
+	reader *moqt.AnnouncementReader
+	match  *regexp.Regexp
+}
+
+// acceptAnnounce dials the URL in the arguments and requests the announcements under the prefix.
+func (f *announceFlags) acceptAnnounce(ctx context.Context, fs *flag.FlagSet) (*announceSession, error) {
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return nil, errUsage
+	}
+
+	prefix := "/"
+	if fs.NArg() == 2 {
+		prefix = fs.Arg(1)
+	}
+
+	as := &announceSession{
+		client: f.client(f.logger()),
+	}
+
+	if f.match != "" {
+		var err error
+		as.match, err = regexp.Compile(f.match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -match: %w", err)
+		}
+	}
+
+	var err error
+	as.sess, err = f.dial(ctx, as.client, fs.Arg(0), nil)
+	if err != nil {
+		as.close()
+		return nil, err
+	}
+
+	as.reader, err = as.sess.AcceptAnnounce(prefix)
+	if err != nil {
+		as.close()
+		return nil, err
+	}
+
+	return as, nil
+}
+
+func (as *announceSession) matches(path moqt.BroadcastPath) bool {
+	return as.match == nil || as.match.MatchString(string(path))
+}
+
+func (as *announceSession) close() {
+	if as.reader != nil {
+		_ = as.reader.Close()
+	}
+	if as.sess != nil {
+		_ = as.sess.CloseWithError(moqt.NoError, "no error")
+	}
+	_ = as.client.Close()
+}
+
+func runLs(ctx context.Context, args []string) error {
+	fs := newFlagSet("ls", lsUsage)
+
+	var f announceFlags
+	f.register(fs)
+	wait := fs.Duration("wait", 0, "keep collecting announcements for this long before printing")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	as, err := f.acceptAnnounce(ctx, fs)
+	if err != nil {
+		return err
+	}
+	defer as.close()
+
+	// The announcements active when the request was accepted are already pending,
+	// so a done context only drains them.
+	collectCtx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+
+	var anns []*moqt.Announcement
+	for {
+		ann, err := as.reader.ReceiveAnnouncement(collectCtx)
+		if err != nil {
+			if collectCtx.Err() != nil || closedByRemote(err) {
+				break
+			}
+			return err
+		}
+		anns = append(anns, ann)
+	}
+
+	var paths []string
+	for _, ann := range anns {
+		if !ann.IsActive() || !as.matches(ann.BroadcastPath()) {
+			continue
+		}
+		paths = append(paths, string(ann.BroadcastPath()))
+	}
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		if f.asJSON {
+			err = json.NewEncoder(os.Stdout).Encode(lsEntry{Path: path})
+		} else {
+			_, err = fmt.Println(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type lsEntry struct {
+	Path string `json:"path"`
+}
+
+func runWatch(ctx context.Context, args []string) error {
+	fs := newFlagSet("watch", watchUsage)
+
+	var f announceFlags
+	f.register(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	as, err := f.acceptAnnounce(ctx, fs)
+	if err != nil {
+		return err
+	}
+	defer as.close()
+
+	p := &eventPrinter{w: os.Stdout, asJSON: f.asJSON}
+	// Closing the reader ends every announcement; do not report those
+	defer p.stop()
+
+	for {
+		ann, err := as.reader.ReceiveAnnouncement(ctx)
+		if err != nil {
+			if ctx.Err() != nil || closedByRemote(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !as.matches(ann.BroadcastPath()) {
+			continue
+		}
+		path := string(ann.BroadcastPath())
+
+		p.print("ACTIVE", path)
+
+		ann.AfterFunc(func() {
+			p.print("ENDED", path)
+		})
+	}
+}
+
+// eventPrinter serializes announcement events written from several goroutines.
+type eventPrinter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	asJSON  bool
+	stopped bool
+}
+
+type watchEvent struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+	Path   string    `json:"path"`
+}
+
+func (p *eventPrinter) print(status, path string) {
+	e := watchEvent{
+		Time:   time.Now(),
+		Status: status,
+		Path:   path,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		return
+	}
+
+	if p.asJSON {
+		_ = json.NewEncoder(p.w).Encode(e)
+		return
+	}
+
+	fmt.Fprintf(p.w, "%s %-6s %s\n", e.Time.Format(time.RFC3339Nano), e.Status, e.Path)
+}
+
+func (p *eventPrinter) stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopped = true
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/webtransport"
+)
+
+// dialFlags are the connection flags shared by client-side commands.
+type dialFlags struct {
+	insecure  bool
+	transport string
+	timeout   time.Duration
+	verbose   bool
+	qlogDir   string
+}
+
+func (f *dialFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.insecure, "insecure", false, "skip TLS certificate verification")
+	fs.StringVar(&f.transport, "transport", "auto", "transport to use: auto, webtransport, quic, tcp or websocket (auto picks by URL scheme)")
+	fs.DurationVar(&f.timeout, "timeout", 5*time.Second, "session setup timeout")
+	fs.BoolVar(&f.verbose, "v", false, "log session events to stderr")
+	fs.StringVar(&f.qlogDir, "qlog", "", "write a qlog trace of every connection to this directory")
+}
+
+// logger returns the logger for session events, or nil unless -v is given.
+func (f *dialFlags) logger() *slog.Logger {
+	if !f.verbose {
+		return nil
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func (f *dialFlags) client(logger *slog.Logger) *moqt.Client {
+	config := &moqt.Config{
+		SetupTimeout: f.timeout,
+	}
+	if f.qlogDir != "" {
+		config.Qlog = moqt.QlogDir(f.qlogDir)
+	}
+
+	return &moqt.Client{
+		TLSConfig: &tls.Config{
+			InsecureSkipVerify: f.insecure,
+		},
+		// WebTransport requires QUIC datagram support
+		QUICConfig: &quic.Config{
+			EnableDatagrams: true,
+		},
+		Config: config,
+		Logger: logger,
+	}
+}
+
+// dial establishes a session to rawURL with the selected transport.
+func (f *dialFlags) dial(ctx context.Context, client *moqt.Client, rawURL string, mux *moqt.TrackMux) (*moqt.Session, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := f.transport
+	if transport == "auto" {
+		switch u.Scheme {
+		case "https":
+			transport = "webtransport"
+		case "moqt":
+			transport = "quic"
+		case "ws", "wss":
+			transport = "websocket"
+		default:
+			return nil, fmt.Errorf("%w: %q", moqt.ErrInvalidScheme, u.Scheme)
+		}
+	}
+
+	// The ALPN must match the transport so that the server dispatches
+	// the connection correctly.
+	switch transport {
+	case "webtransport":
+		client.TLSConfig.NextProtos = []string{webtransport.NextProtoH3}
+		return client.DialWebTransport(ctx, u.Host, u.Path, mux)
+	case "quic":
+		client.TLSConfig.NextProtos = []string{moqt.NextProtoMOQ}
+		return client.DialQUIC(ctx, u.Host, u.Path, mux)
+	case "tcp":
+		client.TLSConfig.NextProtos = []string{moqt.NextProtoMOQ}
+		return client.DialTCP(ctx, u.Host, u.Path, mux)
+	case "websocket":
+		return client.DialWebSocket(ctx, rawURL, mux)
+	default:
+		return nil, fmt.Errorf("unknown transport %q", f.transport)
+	}
+}
+
+// closedByRemote reports whether err is the peer closing the session without an error,
+// which ends a command normally. A server shutting down gracefully closes
+// its sessions with GoAwayTimeoutErrorCode once its grace period ends, and
+// a WebTransport server closes the underlying HTTP/3 connection with H3_NO_ERROR.
+func closedByRemote(err error) bool {
+	var appErr *quic.ApplicationError
+	var sessErr *moqt.SessionError
+	if errors.As(err, &sessErr) {
+		appErr = sessErr.ApplicationError
+	} else if !errors.As(err, &appErr) {
+		return false
+	}
+
+	if appErr == nil || !appErr.Remote {
+		return false
+	}
+
+	switch appErr.ErrorCode {
+	case quic.ApplicationErrorCode(moqt.NoError), quic.ApplicationErrorCode(moqt.GoAwayTimeoutErrorCode), h3NoError:
+		return true
+	default:
+		return false
+	}
+}
+
+// h3NoError is the HTTP/3 error code for a graceful connection close.
+const h3NoError quic.ApplicationErrorCode = 0x100
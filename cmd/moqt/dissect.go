@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/okdaichi/gomoqt/moqt/dissect"
+)
+
+const dissectUsage = "dissect [flags] [file]"
+
+func runDissect(ctx context.Context, args []string) error {
+	fs := newFlagSet("dissect", dissectUsage)
+
+	streams := make([]string, len(dissect.Streams))
+	for i, s := range dissect.Streams {
+		streams[i] = string(s)
+	}
+	stream := fs.String("stream", string(dissect.BiStream), "what the bytes are: "+strings.Join(streams, ", "))
+	isHex := fs.Bool("hex", false, "the input is a hex dump; whitespace is ignored")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return errUsage
+	}
+	if !slices.Contains(dissect.Streams, dissect.Stream(*stream)) {
+		return fmt.Errorf("unknown stream %q, want one of %s", *stream, strings.Join(streams, ", "))
+	}
+
+	in := os.Stdin
+	if fs.NArg() == 1 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	if *isHex {
+		b, err = decodeHexDump(b)
+		if err != nil {
+			return err
+		}
+	}
+
+	msgs, err := dissect.Dissect(b, dissect.Stream(*stream))
+	if perr := dissect.Fprint(os.Stdout, msgs); perr != nil {
+		return perr
+	}
+
+	return err
+}
+
+// decodeHexDump decodes hex digits, ignoring whitespace and 0x prefixes.
+func decodeHexDump(b []byte) ([]byte, error) {
+	fields := strings.FieldsFunc(string(b), unicode.IsSpace)
+	for i, f := range fields {
+		fields[i] = strings.TrimPrefix(strings.TrimPrefix(f, "0x"), "0X")
+	}
+	return hex.DecodeString(strings.Join(fields, ""))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// frameEncoder writes frame payloads in one of the output formats of the sub command.
+type frameEncoder interface {
+	encode(w io.Writer, seq moqt.GroupSequence, index int, payload []byte) error
+	ext() string
+}
+
+func newFrameEncoder(format string) (frameEncoder, error) {
+	switch format {
+	case "raw":
+		return rawEncoder{}, nil
+	case "length":
+		return lengthEncoder{}, nil
+	case "hex":
+		return hexEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// rawEncoder writes payloads back to back.
+type rawEncoder struct{}
+
+func (rawEncoder) encode(w io.Writer, _ moqt.GroupSequence, _ int, payload []byte) error {
+	_, err := w.Write(payload)
+	return err
+}
+
+func (rawEncoder) ext() string { return ".bin" }
+
+// lengthEncoder prefixes every payload with its length as a QUIC variable-length integer,
+// the same framing used on group streams.
+type lengthEncoder struct{}
+
+func (lengthEncoder) encode(w io.Writer, _ moqt.GroupSequence, _ int, payload []byte) error {
+	b := quicvarint.Append(make([]byte, 0, 8+len(payload)), uint64(len(payload)))
+	b = append(b, payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+func (lengthEncoder) ext() string { return ".lp" }
+
+// hexEncoder writes a header line followed by a hex dump of every payload.
+type hexEncoder struct{}
+
+func (hexEncoder) encode(w io.Writer, seq moqt.GroupSequence, index int, payload []byte) error {
+	_, err := fmt.Fprintf(w, "group %d frame %d (%d bytes)\n", seq, index, len(payload))
+	if err != nil {
+		return err
+	}
+
+	d := hex.Dumper(w)
+	_, err = d.Write(payload)
+	if err != nil {
+		return err
+	}
+
+	return d.Close()
+}
+
+func (hexEncoder) ext() string { return ".txt" }
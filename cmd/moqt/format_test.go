@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFrameEncoder(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		ext     string
+		wantErr bool
+	}{
+		"raw":     {format: "raw", ext: ".bin"},
+		"length":  {format: "length", ext: ".lp"},
+		"hex":     {format: "hex", ext: ".txt"},
+		"unknown": {format: "json", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			enc, err := newFrameEncoder(tt.format)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.ext, enc.ext())
+		})
+	}
+}
+
+func TestFrameEncoder_Encode(t *testing.T) {
+	tests := map[string]struct {
+		format  string
+		seq     moqt.GroupSequence
+		index   int
+		payload []byte
+		want    string
+	}{
+		"raw": {
+			format:  "raw",
+			payload: []byte("abc"),
+			want:    "abc",
+		},
+		"raw empty": {
+			format:  "raw",
+			payload: nil,
+			want:    "",
+		},
+		"length": {
+			format:  "length",
+			payload: []byte("abc"),
+			want:    "\x03abc",
+		},
+		"length empty": {
+			format:  "length",
+			payload: nil,
+			want:    "\x00",
+		},
+		"length two-byte prefix": {
+			format:  "length",
+			payload: bytes.Repeat([]byte{'a'}, 64),
+			want:    "\x40\x40" + string(bytes.Repeat([]byte{'a'}, 64)),
+		},
+		"hex": {
+			format:  "hex",
+			seq:     3,
+			index:   1,
+			payload: []byte("abc"),
+			want:    "group 3 frame 1 (3 bytes)\n00000000  61 62 63                                          |abc|\n",
+		},
+		"hex empty": {
+			format: "hex",
+			seq:    0,
+			index:  0,
+			want:   "group 0 frame 0 (0 bytes)\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			enc, err := newFrameEncoder(tt.format)
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			require.NoError(t, enc.encode(&buf, tt.seq, tt.index, tt.payload))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestLengthEncoder_SplitLength(t *testing.T) {
+	payloads := []string{"a", "", "hello, world", string(bytes.Repeat([]byte{'x'}, 1000))}
+
+	var buf bytes.Buffer
+	for i, p := range payloads {
+		require.NoError(t, lengthEncoder{}.encode(&buf, 0, i, []byte(p)))
+	}
+
+	// The sub command's length format is read back by the pub command
+	got, err := scanFrames(buf.Bytes(), splitLength)
+	require.NoError(t, err)
+	assert.Equal(t, payloads, got)
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const loadUsage = "load [flags] <url>\n       moqt load -local [flags]"
+
+// loadTrackName is the track every simulated publisher serves.
+const loadTrackName moqt.TrackName = "load"
+
+// timestampSize is the size of the send timestamp at the start of every frame.
+const timestampSize = 8
+
+type loadConfig struct {
+	publishers    int
+	subscribers   int
+	frameSize     int
+	rate          float64
+	groupFrames   int
+	duration      time.Duration
+	setupDeadline time.Duration
+}
+
+func runLoad(ctx context.Context, args []string) error {
+	fs := newFlagSet("load", loadUsage)
+
+	var df dialFlags
+	df.register(fs)
+
+	var cfg loadConfig
+	fs.IntVar(&cfg.publishers, "publishers", 1, "number of simulated publishers, each with its own session")
+	fs.IntVar(&cfg.subscribers, "subscribers", 10, "number of simulated subscribers, each with its own session; subscriber i subscribes to publisher i mod publishers")
+	fs.IntVar(&cfg.frameSize, "frame-size", 1200, "frame size in bytes (at least 8, the size of the embedded send timestamp)")
+	fs.Float64Var(&cfg.rate, "rate", 30, "frames per second per publisher")
+	fs.IntVar(&cfg.groupFrames, "group-frames", 30, "frames per group")
+	fs.DurationVar(&cfg.duration, "duration", 10*time.Second, "how long to publish after setup")
+	fs.DurationVar(&cfg.setupDeadline, "setup-deadline", 10*time.Second, "how long subscribers retry subscribing while publishers are being announced")
+	local := fs.Bool("local", false, "run against an in-process server on localhost")
+	prefix := fs.String("prefix", "/load", "broadcast path prefix of the simulated publishers")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.publishers < 1:
+		return fmt.Errorf("-publishers must be at least 1")
+	case cfg.subscribers < 0:
+		return fmt.Errorf("-subscribers must not be negative")
+	case cfg.frameSize < timestampSize:
+		return fmt.Errorf("-frame-size must be at least %d", timestampSize)
+	case cfg.rate <= 0:
+		return fmt.Errorf("-rate must be positive")
+	case cfg.groupFrames < 1:
+		return fmt.Errorf("-group-frames must be at least 1")
+	}
+
+	logger := df.logger()
+
+	var rawURL string
+	if *local {
+		if fs.NArg() != 0 {
+			return errUsage
+		}
+
+		// The in-process server only listens on QUIC
+		switch df.transport {
+		case "auto", "quic", "webtransport":
+		default:
+			return fmt.Errorf("-local does not support -transport %s; use quic or webtransport", df.transport)
+		}
+
+		cert, err := selfSignedCertificate()
+		if err != nil {
+			return err
+		}
+
+		ls, err := startServer("localhost:0", cert, relayHandler(moqt.NewTrackMux(), logger), logger)
+		if err != nil {
+			return err
+		}
+		defer ls.shutdown(time.Second)
+
+		// A self-signed certificate is only trusted with -insecure
+		df.insecure = true
+
+		port := ls.Addr().(*net.UDPAddr).Port
+		scheme := "moqt"
+		if df.transport == "webtransport" {
+			scheme = "https"
+		}
+		rawURL = scheme + "://localhost:" + strconv.Itoa(port) + "/load"
+
+		fmt.Fprintf(os.Stderr, "started in-process server at %s\n", rawURL)
+	} else {
+		if fs.NArg() != 1 {
+			return errUsage
+		}
+		rawURL = fs.Arg(0)
+	}
+
+	r := newLoadRun(cfg, &df, logger, rawURL, *prefix)
+	report := r.run(ctx)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	report.print(os.Stdout)
+
+	return nil
+}
+
+func newLoadRun(cfg loadConfig, df *dialFlags, logger *slog.Logger, rawURL, prefix string) *loadRun {
+	return &loadRun{
+		cfg:    cfg,
+		df:     df,
+		logger: logger,
+		rawURL: rawURL,
+		prefix: prefix,
+	}
+}
+
+// loadRun drives the simulated publishers and subscribers of one load test.
+type loadRun struct {
+	cfg    loadConfig
+	df     *dialFlags
+	logger *slog.Logger
+	rawURL string
+	prefix string
+
+	mu sync.Mutex
+
+	pubSetup  []time.Duration
+	subSetup  []time.Duration
+	latencies []time.Duration
+
+	sentFrames     int64
+	sentBytes      int64
+	receivedFrames int64
+	receivedBytes  int64
+
+	failures map[string]int
+}
+
+func (r *loadRun) path(i int) moqt.BroadcastPath {
+	return moqt.BroadcastPath(fmt.Sprintf("%s/pub-%d", r.prefix, i))
+}
+
+func (r *loadRun) fail(kind string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failures == nil {
+		r.failures = make(map[string]int)
+	}
+	r.failures[kind]++
+}
+
+func (r *loadRun) run(ctx context.Context) *loadReport {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var sessions []*moqt.Session
+	var sessMu sync.Mutex
+	addSession := func(sess *moqt.Session) {
+		sessMu.Lock()
+		sessions = append(sessions, sess)
+		sessMu.Unlock()
+	}
+
+	client := r.df.client(r.logger)
+	defer client.Close()
+
+	// Publishers first, so that their broadcasts are announced when subscribers arrive
+	for i := range r.cfg.publishers {
+		wg.Go(func() {
+			sess := r.startPublisher(ctx, client, i)
+			if sess != nil {
+				addSession(sess)
+			}
+		})
+	}
+	wg.Wait()
+
+	start := time.Now()
+	runCtx, stop := context.WithTimeout(ctx, r.cfg.duration)
+	defer stop()
+
+	for j := range r.cfg.subscribers {
+		wg.Go(func() {
+			r.runSubscriber(runCtx, client, j, addSession)
+		})
+	}
+
+	<-runCtx.Done()
+	elapsed := time.Since(start)
+
+	sessMu.Lock()
+	for _, sess := range sessions {
+		_ = sess.CloseWithError(moqt.NoError, "no error")
+	}
+	sessMu.Unlock()
+
+	wg.Wait()
+
+	return r.report(elapsed)
+}
+
+func (r *loadRun) startPublisher(ctx context.Context, client *moqt.Client, i int) *moqt.Session {
+	mux := moqt.NewTrackMux()
+	mux.Publish(ctx, r.path(i), moqt.TrackHandlerFunc(func(tw *moqt.TrackWriter) {
+		if tw.TrackName != loadTrackName {
+			moqt.NotFound(tw)
+			return
+		}
+		r.serveLoad(tw)
+	}))
+
+	start := time.Now()
+	sess, err := r.df.dial(ctx, client, r.rawURL, mux)
+	if err != nil {
+		r.fail("publisher setup")
+		return nil
+	}
+
+	r.mu.Lock()
+	r.pubSetup = append(r.pubSetup, time.Since(start))
+	r.mu.Unlock()
+
+	return sess
+}
+
+// serveLoad writes timestamped frames at the configured rate and group cadence
+// until the subscription ends.
+func (r *loadRun) serveLoad(tw *moqt.TrackWriter) {
+	ctx := tw.Context()
+	defer tw.Close()
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / r.cfg.rate))
+	defer ticker.Stop()
+
+	frame := moqt.NewFrame(r.cfg.frameSize)
+	payload := make([]byte, r.cfg.frameSize)
+
+	var gw *moqt.GroupWriter
+	var count int
+
+	for {
+		select {
+		case <-ctx.Done():
+			if gw != nil {
+				_ = gw.Close()
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if gw == nil || count == r.cfg.groupFrames {
+			if gw != nil {
+				_ = gw.Close()
+			}
+
+			var err error
+			gw, err = tw.OpenGroup()
+			if err != nil {
+				if ctx.Err() == nil {
+					r.fail("open group")
+				}
+				return
+			}
+			count = 0
+		}
+
+		binary.BigEndian.PutUint64(payload, uint64(time.Now().UnixNano()))
+		frame.Reset()
+		_, _ = frame.Write(payload)
+
+		err := gw.WriteFrame(frame)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.fail("write frame")
+			}
+			gw = nil
+			continue
+		}
+		count++
+
+		r.mu.Lock()
+		r.sentFrames++
+		r.sentBytes += int64(len(payload))
+		r.mu.Unlock()
+	}
+}
+
+func (r *loadRun) runSubscriber(ctx context.Context, client *moqt.Client, j int, addSession func(*moqt.Session)) {
+	start := time.Now()
+
+	sess, err := r.df.dial(ctx, client, r.rawURL, nil)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.fail("subscriber setup")
+		}
+		return
+	}
+	addSession(sess)
+
+	path := r.path(j % r.cfg.publishers)
+
+	// The broadcast may not have reached a relay yet; retry until the deadline
+	var tr *moqt.TrackReader
+	deadline := time.Now().Add(r.cfg.setupDeadline)
+	for {
+		tr, err = sess.Subscribe(path, loadTrackName, nil)
+		if err == nil {
+			break
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			r.fail("subscribe")
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	defer tr.Close()
+
+	r.mu.Lock()
+	r.subSetup = append(r.subSetup, time.Since(start))
+	r.mu.Unlock()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		gr, err := tr.AcceptGroup(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.fail("accept group")
+			}
+			return
+		}
+
+		wg.Go(func() {
+			r.readLoad(ctx, gr)
+		})
+	}
+}
+
+func (r *loadRun) readLoad(ctx context.Context, gr *moqt.GroupReader) {
+	frame := moqt.NewFrame(r.cfg.frameSize)
+
+	for {
+		err := gr.ReadFrame(frame)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				r.fail("read frame")
+			}
+			return
+		}
+
+		now := time.Now()
+
+		body := frame.Body()
+		if len(body) < timestampSize {
+			r.fail("short frame")
+			continue
+		}
+		sent := time.Unix(0, int64(binary.BigEndian.Uint64(body)))
+
+		r.mu.Lock()
+		r.latencies = append(r.latencies, now.Sub(sent))
+		r.receivedFrames++
+		r.receivedBytes += int64(len(body))
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// relayHandler returns a SetupHandler that serves every session with mux and
+// re-announces the broadcasts of every session on mux, so that sessions can
+// subscribe to each other. It backs "moqt load -local".
+func relayHandler(mux *moqt.TrackMux, logger *slog.Logger) moqt.SetupHandler {
+	return moqt.SetupHandlerFunc(func(w moqt.SetupResponseWriter, r *moqt.SetupRequest) {
+		sess, err := moqt.Accept(w, r, mux)
+		if err != nil {
+			if logger != nil {
+				logger.Error("failed to accept session", "error", err)
+			}
+			return
+		}
+
+		ar, err := sess.AcceptAnnounce("/")
+		if err != nil {
+			return
+		}
+
+		go func() {
+			for ann := range ar.Announcements(sess.Context()) {
+				mux.Announce(ann, &forwarder{sess: sess})
+			}
+		}()
+	})
+}
+
+// forwarder serves a subscription by subscribing to the same track on the
+// publishing session and copying its groups.
+// Every subscription is forwarded separately; there is no fan-out.
+type forwarder struct {
+	sess *moqt.Session
+}
+
+func (f *forwarder) ServeTrack(tw *moqt.TrackWriter) {
+	ctx := tw.Context()
+
+	src, err := f.sess.Subscribe(tw.BroadcastPath, tw.TrackName, tw.TrackConfig())
+	if err != nil {
+		tw.CloseWithError(moqt.TrackNotFoundErrorCode)
+		return
+	}
+	defer src.Close()
+
+	for {
+		gr, err := src.AcceptGroup(ctx)
+		if err != nil {
+			tw.Close()
+			return
+		}
+
+		gw, err := tw.OpenGroupAt(gr.GroupSequence())
+		if err != nil {
+			gr.CancelRead(moqt.SubscribeCanceledErrorCode)
+			tw.Close()
+			return
+		}
+
+		go copyGroup(gw, gr)
+	}
+}
+
+func copyGroup(gw *moqt.GroupWriter, gr *moqt.GroupReader) {
+	frame := moqt.NewFrame(0)
+	for {
+		err := gr.ReadFrame(frame)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				_ = gw.Close()
+			} else {
+				gw.CancelWrite(moqt.PublishAbortedErrorCode)
+			}
+			return
+		}
+
+		err = gw.WriteFrame(frame)
+		if err != nil {
+			gr.CancelRead(moqt.SubscribeCanceledErrorCode)
+			return
+		}
+	}
+}
+
+var _ moqt.TrackHandler = (*forwarder)(nil)
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// loadReport summarizes a load test.
+type loadReport struct {
+	Publishers  int           `json:"publishers"`
+	Subscribers int           `json:"subscribers"`
+	Duration    time.Duration `json:"duration_ns"`
+
+	PublisherSetup  percentiles `json:"publisher_setup"`
+	SubscriberSetup percentiles `json:"subscriber_setup"`
+	Latency         percentiles `json:"latency"`
+
+	SentFrames     int64   `json:"sent_frames"`
+	SentBytes      int64   `json:"sent_bytes"`
+	ReceivedFrames int64   `json:"received_frames"`
+	ReceivedBytes  int64   `json:"received_bytes"`
+	SendBitrate    float64 `json:"send_bitrate_bps"`
+	ReceiveBitrate float64 `json:"receive_bitrate_bps"`
+
+	Failures map[string]int `json:"failures"`
+}
+
+// percentiles summarizes a set of durations.
+type percentiles struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+func newPercentiles(samples []time.Duration) percentiles {
+	if len(samples) == 0 {
+		return percentiles{}
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	at := func(q float64) time.Duration {
+		i := int(q * float64(len(sorted)-1))
+		return sorted[i]
+	}
+
+	return percentiles{
+		Count: len(sorted),
+		Min:   sorted[0],
+		P50:   at(0.50),
+		P90:   at(0.90),
+		P99:   at(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+func (p percentiles) String() string {
+	if p.Count == 0 {
+		return "no samples"
+	}
+
+	r := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+
+	return fmt.Sprintf("min %s, p50 %s, p90 %s, p99 %s, max %s (%d samples)",
+		r(p.Min), r(p.P50), r(p.P90), r(p.P99), r(p.Max), p.Count)
+}
+
+func (r *loadRun) report(elapsed time.Duration) *loadReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &loadReport{
+		Publishers:      r.cfg.publishers,
+		Subscribers:     r.cfg.subscribers,
+		Duration:        elapsed,
+		PublisherSetup:  newPercentiles(r.pubSetup),
+		SubscriberSetup: newPercentiles(r.subSetup),
+		Latency:         newPercentiles(r.latencies),
+		SentFrames:      r.sentFrames,
+		SentBytes:       r.sentBytes,
+		ReceivedFrames:  r.receivedFrames,
+		ReceivedBytes:   r.receivedBytes,
+		Failures:        make(map[string]int, len(r.failures)),
+	}
+
+	for kind, n := range r.failures {
+		report.Failures[kind] = n
+	}
+
+	if secs := elapsed.Seconds(); secs > 0 {
+		report.SendBitrate = float64(r.sentBytes) * 8 / secs
+		report.ReceiveBitrate = float64(r.receivedBytes) * 8 / secs
+	}
+
+	return report
+}
+
+func (rep *loadReport) print(w io.Writer) {
+	fmt.Fprintf(w, "duration:          %s\n", rep.Duration.Round(time.Millisecond))
+	fmt.Fprintf(w, "publishers:        %d (%d set up)\n", rep.Publishers, rep.PublisherSetup.Count)
+	fmt.Fprintf(w, "subscribers:       %d (%d set up)\n", rep.Subscribers, rep.SubscriberSetup.Count)
+	fmt.Fprintf(w, "publisher setup:   %s\n", rep.PublisherSetup)
+	fmt.Fprintf(w, "subscriber setup:  %s\n", rep.SubscriberSetup)
+	fmt.Fprintf(w, "latency:           %s\n", rep.Latency)
+	fmt.Fprintf(w, "sent:              %d frames, %d bytes (%.1f kbit/s)\n", rep.SentFrames, rep.SentBytes, rep.SendBitrate/1000)
+	fmt.Fprintf(w, "received:          %d frames, %d bytes (%.1f kbit/s)\n", rep.ReceivedFrames, rep.ReceivedBytes, rep.ReceiveBitrate/1000)
+
+	if len(rep.Failures) == 0 {
+		fmt.Fprintln(w, "failures:          none")
+		return
+	}
+
+	kinds := make([]string, 0, len(rep.Failures))
+	for kind := range rep.Failures {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	fmt.Fprintln(w, "failures:")
+	for _, kind := range kinds {
+		fmt.Fprintf(w, "  %-16s %d\n", kind+":", rep.Failures[kind])
+	}
+}
@@ -0,0 +1,128 @@
+// Command moqt is a command-line tool for debugging MOQ sessions.
+//
+// Usage:
+//
+//	moqt <command> [flags] <args>
+//
+// Run "moqt help" for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+)
+
+type command struct {
+	usage string
+	short string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = map[string]*command{
+	"dissect": {
+		usage: dissectUsage,
+		short: "decode the raw bytes of a captured stream",
+		run:   runDissect,
+	},
+	"load": {
+		usage: loadUsage,
+		short: "generate load with simulated publishers and subscribers",
+		run:   runLoad,
+	},
+	"ls": {
+		usage: lsUsage,
+		short: "list the active broadcasts under a prefix",
+		run:   runLs,
+	},
+	"pub": {
+		usage: pubUsage,
+		short: "publish stdin or files as a track",
+		run:   runPub,
+	},
+	"sub": {
+		usage: subUsage,
+		short: "subscribe to a track and dump its frames",
+		run:   runSub,
+	},
+	"watch": {
+		usage: watchUsage,
+		short: "stream announcement events under a prefix",
+		run:   runWatch,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "moqt: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	// The library logs through the default logger; keep it quiet.
+	// -v logs session events through the loggers of the client and server.
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := cmd.run(ctx, os.Args[2:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: moqt %s\n", cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "moqt %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+var errUsage = errors.New("invalid arguments")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: moqt <command> [flags] <args>")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].short)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, `Run "moqt <command> -h" for the flags of a command.`)
+}
+
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("moqt "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: moqt %s\n\nFlags:\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const pubUsage = "pub [flags] <url> <broadcast-path> [file...]\n       moqt pub -listen <addr> [flags] <broadcast-path> [file...]"
+
+func runPub(ctx context.Context, args []string) error {
+	fs := newFlagSet("pub", pubUsage)
+
+	var df dialFlags
+	df.register(fs)
+	var sf serveFlags
+	sf.register(fs)
+
+	track := fs.String("track", "", "only serve this track name (default serves every track name)")
+	split := fs.String("split", "delim", "how to cut the input into frames: delim, size or length (varint length prefix)")
+	delim := fs.String("delim", `\n`, "frame delimiter for -split delim; Go escape sequences are interpreted")
+	size := fs.Int("size", 1200, "frame size in bytes for -split size")
+	groupFrames := fs.Int("group-frames", 0, "start a new group every N frames")
+	groupInterval := fs.Duration("group-interval", 0, "start a new group when this much time has passed since the group started (e.g. 500ms)")
+	rate := fs.Float64("rate", 0, "maximum frames per second (0 means unlimited)")
+	loop := fs.Bool("loop", false, "repeat the input files forever")
+	wait := fs.Bool("wait", true, "wait for the first subscriber before reading the input")
+	linger := fs.Duration("linger", 2*time.Second, "how long to wait for subscribers to receive the queued data after the input ends")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rest := fs.Args()
+	var rawURL string
+	if !sf.enabled() {
+		if len(rest) < 2 {
+			return errUsage
+		}
+		rawURL, rest = rest[0], rest[1:]
+	} else if len(rest) < 1 {
+		return errUsage
+	}
+	path := moqt.BroadcastPath(rest[0])
+	files := rest[1:]
+
+	if *loop && (len(files) == 0 || (len(files) == 1 && files[0] == "-")) {
+		return fmt.Errorf("-loop requires input files")
+	}
+	if *groupFrames < 0 || *groupInterval < 0 || *rate < 0 {
+		return fmt.Errorf("-group-frames, -group-interval and -rate must not be negative")
+	}
+
+	splitFunc, err := newSplitFunc(*split, *delim, *size)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	b := newBroadcaster(moqt.TrackName(*track))
+	logger := df.logger()
+
+	mux := moqt.NewTrackMux()
+	mux.Publish(ctx, path, b)
+
+	// finish lingers after the input ends so that the queued groups are delivered
+	var finish func()
+
+	if sf.enabled() {
+		serveCtx, stopServe := context.WithCancel(context.Background())
+		serveDone := make(chan struct{})
+		go func() {
+			defer close(serveDone)
+			defer cancel()
+			err := sf.serve(serveCtx, mux, *linger, logger)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "moqt pub: %v\n", err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			stopServe()
+		}()
+
+		finish = func() {
+			stopServe()
+			<-serveDone
+		}
+	} else {
+		client := df.client(logger)
+		defer client.Close()
+
+		sess, err := df.dial(ctx, client, rawURL, mux)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			cancel()
+			_ = sess.CloseWithError(moqt.NoError, "no error")
+		}()
+
+		go func() {
+			<-sess.Context().Done()
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "moqt pub: session closed: %v\n", moqt.Cause(sess.Context()))
+			}
+			cancel()
+		}()
+
+		finish = func() {
+			t := time.NewTimer(*linger)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-sess.Context().Done():
+			}
+		}
+	}
+
+	if *wait {
+		fmt.Fprintf(os.Stderr, "waiting for a subscriber to %s\n", path)
+		if err := b.waitSubscriber(ctx); err != nil {
+			return nil
+		}
+	}
+
+	p := &pubLoop{
+		b:             b,
+		split:         splitFunc,
+		groupFrames:   *groupFrames,
+		groupInterval: *groupInterval,
+	}
+	if *rate > 0 {
+		p.frameInterval = time.Duration(float64(time.Second) / *rate)
+	}
+
+	for {
+		err := p.publishInput(ctx, files)
+		if err != nil {
+			b.close()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !*loop {
+			break
+		}
+	}
+
+	b.close()
+	finish()
+
+	fmt.Fprintf(os.Stderr, "published %d groups, %d frames\n", p.groups, p.frames)
+
+	return nil
+}
+
+// pubLoop cuts the input into frames and groups and hands them to a broadcaster.
+type pubLoop struct {
+	b     *broadcaster
+	split func(data []byte, atEOF bool) (int, []byte, error)
+
+	groupFrames   int
+	groupInterval time.Duration
+	frameInterval time.Duration
+
+	groupStart time.Time
+	groupCount int
+	inGroup    bool
+	lastFrame  time.Time
+	groups     int
+	frames     int
+}
+
+// publishInput publishes every file in order, or stdin when files is empty or "-".
+func (p *pubLoop) publishInput(ctx context.Context, files []string) error {
+	if len(files) == 0 {
+		return p.publish(ctx, os.Stdin)
+	}
+
+	for _, name := range files {
+		if name == "-" {
+			if err := p.publish(ctx, os.Stdin); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		err = p.publish(ctx, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *pubLoop) publish(ctx context.Context, r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxFrameSize+8)
+	sc.Split(p.split)
+
+	frame := moqt.NewFrame(0)
+
+	for sc.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p.pace(ctx)
+
+		if p.needGroup() {
+			p.b.startGroup()
+			p.groupStart = time.Now()
+			p.groupCount = 0
+			p.inGroup = true
+			p.groups++
+		}
+
+		frame.Reset()
+		_, _ = frame.Write(sc.Bytes())
+		p.b.writeFrame(frame)
+
+		p.groupCount++
+		p.frames++
+	}
+
+	return sc.Err()
+}
+
+// needGroup reports whether the next frame starts a new group.
+// Without -group-frames and -group-interval every frame is its own group.
+func (p *pubLoop) needGroup() bool {
+	if !p.inGroup {
+		return true
+	}
+	if p.groupFrames == 0 && p.groupInterval == 0 {
+		return true
+	}
+	if p.groupFrames > 0 && p.groupCount >= p.groupFrames {
+		return true
+	}
+	if p.groupInterval > 0 && time.Since(p.groupStart) >= p.groupInterval {
+		return true
+	}
+	return false
+}
+
+func (p *pubLoop) pace(ctx context.Context) {
+	if p.frameInterval == 0 {
+		return
+	}
+
+	if !p.lastFrame.IsZero() {
+		wait := time.Until(p.lastFrame.Add(p.frameInterval))
+		if wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+			}
+		}
+	}
+
+	p.lastFrame = time.Now()
+}
+
+// maxQueuedFrames is the number of frames queued for a subscriber before
+// the rest of its current group is dropped.
+const maxQueuedFrames = 256
+
+func newBroadcaster(track moqt.TrackName) *broadcaster {
+	return &broadcaster{
+		track:  track,
+		subs:   make(map[*moqt.TrackWriter]*subQueue),
+		joined: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// broadcaster fans the published groups out to every subscriber.
+// A subscriber that joins in the middle of a group starts receiving at the next group.
+//
+// Groups and frames are queued for every subscriber and written by the
+// handler serving it, so a slow subscriber does not hold up the input.
+// A subscriber that falls maxQueuedFrames behind loses the rest of its
+// current group, which is canceled, and resumes at the next group.
+type broadcaster struct {
+	track moqt.TrackName
+
+	mu     sync.Mutex
+	seq    moqt.GroupSequence
+	subs   map[*moqt.TrackWriter]*subQueue
+	joined chan struct{}
+	closed bool
+	done   chan struct{}
+}
+
+var _ moqt.TrackHandler = (*broadcaster)(nil)
+
+// queued is a group start, a frame or a group cancellation queued for a subscriber.
+type queued struct {
+	start  bool
+	cancel bool
+	seq    moqt.GroupSequence
+	frame  []byte
+}
+
+// subQueue holds what is still to be written to one subscriber.
+// Its fields are guarded by broadcaster.mu.
+type subQueue struct {
+	items []queued
+	// lagging drops frames after an overflow until the next group starts.
+	lagging bool
+	wake    chan struct{}
+}
+
+// push queues q and wakes the handler. The caller must hold b.mu.
+func (s *subQueue) push(q queued) {
+	if q.start {
+		s.lagging = false
+		if len(s.items) >= maxQueuedFrames {
+			// Skip the backlog and start over at the new group
+			s.items = append(s.items[:0], queued{cancel: true})
+		}
+	} else {
+		if s.lagging {
+			return
+		}
+		if len(s.items) >= maxQueuedFrames {
+			s.lagging = true
+			q = queued{cancel: true}
+		}
+	}
+
+	s.items = append(s.items, q)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (b *broadcaster) ServeTrack(tw *moqt.TrackWriter) {
+	if b.track != "" && tw.TrackName != b.track {
+		moqt.NotFound(tw)
+		return
+	}
+
+	// Capture the context before Close clears the subscribe stream
+	ctx := tw.Context()
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		tw.Close()
+		return
+	}
+	q := &subQueue{wake: make(chan struct{}, 1)}
+	b.subs[tw] = q
+	select {
+	case <-b.joined:
+	default:
+		close(b.joined)
+	}
+	b.mu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "subscriber joined: %s\n", tw.TrackName)
+
+	var gw *moqt.GroupWriter
+	frame := moqt.NewFrame(0)
+	endGroup := func(cancel bool) {
+		if gw == nil {
+			return
+		}
+		if cancel {
+			gw.CancelWrite(moqt.InternalGroupErrorCode)
+		} else {
+			_ = gw.Close()
+		}
+		gw = nil
+	}
+
+	for done := false; !done; {
+		select {
+		case <-q.wake:
+		case <-b.done:
+			// Write what is queued before ending the subscription
+			done = true
+		case <-ctx.Done():
+			done = true
+		}
+
+		b.mu.Lock()
+		items := q.items
+		q.items = nil
+		b.mu.Unlock()
+
+		for _, it := range items {
+			if ctx.Err() != nil {
+				break
+			}
+
+			switch {
+			case it.start:
+				endGroup(false)
+				gw, _ = tw.OpenGroupAt(it.seq)
+			case it.cancel:
+				endGroup(true)
+			case gw != nil:
+				frame.Reset()
+				_, _ = frame.Write(it.frame)
+				if err := gw.WriteFrame(frame); err != nil {
+					// Drop the group; the subscriber receives the next one
+					endGroup(true)
+				}
+			}
+		}
+	}
+
+	b.mu.Lock()
+	delete(b.subs, tw)
+	b.mu.Unlock()
+
+	endGroup(false)
+	tw.Close()
+
+	fmt.Fprintf(os.Stderr, "subscriber left: %s\n", tw.TrackName)
+}
+
+func (b *broadcaster) waitSubscriber(ctx context.Context) error {
+	select {
+	case <-b.joined:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startGroup ends the current group of every subscriber and starts the next one.
+func (b *broadcaster) startGroup() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, q := range b.subs {
+		q.push(queued{start: true, seq: b.seq})
+	}
+
+	b.seq++
+}
+
+// writeFrame queues a copy of the frame payload for every subscriber.
+func (b *broadcaster) writeFrame(frame *moqt.Frame) {
+	body := bytes.Clone(frame.Body())
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, q := range b.subs {
+		q.push(queued{frame: body})
+	}
+}
+
+// close ends the subscriptions once their queued groups are written.
+// It does not wait for slow subscribers; the linger after the input
+// ends bounds how long they are given.
+func (b *broadcaster) close() {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	close(b.done)
+	b.mu.Unlock()
+}
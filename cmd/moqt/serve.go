@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicgo"
+	"github.com/okdaichi/gomoqt/webtransport"
+)
+
+// serveFlags are the flags of commands that can run as a server instead of dialing.
+type serveFlags struct {
+	listen   string
+	certFile string
+	keyFile  string
+}
+
+func (f *serveFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.listen, "listen", "", "run as a server on this address (e.g. :9000) instead of dialing a URL")
+	fs.StringVar(&f.certFile, "cert", "", "TLS certificate file for -listen (a self-signed certificate is generated if empty)")
+	fs.StringVar(&f.keyFile, "key", "", "TLS key file for -listen")
+}
+
+func (f *serveFlags) enabled() bool {
+	return f.listen != ""
+}
+
+// serve accepts sessions over WebTransport and QUIC on f.listen until ctx is canceled.
+// Every accepted session is served by mux. On cancellation, sessions are given
+// up to grace to close before they are terminated.
+func (f *serveFlags) serve(ctx context.Context, mux *moqt.TrackMux, grace time.Duration, logger *slog.Logger) error {
+	cert, err := f.certificate()
+	if err != nil {
+		return err
+	}
+
+	ls, err := startServer(f.listen, cert, acceptHandler(mux, logger), logger)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "listening on %s\n", ls.Addr())
+
+	select {
+	case err := <-ls.errCh:
+		return err
+	case <-ctx.Done():
+		return ls.shutdown(grace)
+	}
+}
+
+func (f *serveFlags) certificate() (tls.Certificate, error) {
+	if f.certFile != "" || f.keyFile != "" {
+		return tls.LoadX509KeyPair(f.certFile, f.keyFile)
+	}
+
+	fmt.Fprintln(os.Stderr, "no -cert given, using a self-signed certificate")
+
+	return selfSignedCertificate()
+}
+
+// acceptHandler returns a SetupHandler accepting every session with mux.
+func acceptHandler(mux *moqt.TrackMux, logger *slog.Logger) moqt.SetupHandler {
+	return moqt.SetupHandlerFunc(func(w moqt.SetupResponseWriter, r *moqt.SetupRequest) {
+		_, err := moqt.Accept(w, r, mux)
+		if err != nil && logger != nil {
+			logger.Error("failed to accept session", "error", err)
+		}
+	})
+}
+
+// localServer is a Server listening for WebTransport and QUIC on a single UDP address.
+type localServer struct {
+	server *moqt.Server
+	ln     quic.Listener
+	errCh  chan error
+}
+
+var registerWebTransportOnce sync.Once
+
+// startServer listens on addr and serves sessions with handler in the background.
+// An addr with port 0 picks a free port; see Addr.
+func startServer(addr string, cert tls.Certificate, handler moqt.SetupHandler, logger *slog.Logger) (*localServer, error) {
+	tlsConfig := &tls.Config{
+		NextProtos:   []string{moqt.NextProtoMOQ, webtransport.NextProtoH3},
+		Certificates: []tls.Certificate{cert},
+	}
+	quicConfig := &quic.Config{
+		EnableDatagrams: true,
+	}
+
+	server := &moqt.Server{
+		Addr:         addr,
+		TLSConfig:    tlsConfig,
+		QUICConfig:   quicConfig,
+		SetupHandler: handler,
+		CheckHTTPOrigin: func(r *http.Request) bool {
+			return true
+		},
+		Logger: logger,
+	}
+
+	ln, err := quicgo.ListenAddrEarly(addr, tlsConfig, quicConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// WebTransport sessions are upgraded through the default HTTP mux
+	registerWebTransportOnce.Do(func() {
+		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			err := server.HandleWebTransport(w, r)
+			if err != nil && logger != nil {
+				logger.Error("failed to serve WebTransport", "error", err)
+			}
+		})
+	})
+
+	ls := &localServer{
+		server: server,
+		ln:     ln,
+		errCh:  make(chan error, 1),
+	}
+
+	go func() {
+		err := server.ServeQUICListener(ln)
+		if errors.Is(err, moqt.ErrServerClosed) {
+			err = nil
+		}
+		ls.errCh <- err
+	}()
+
+	return ls, nil
+}
+
+// Addr returns the address the server listens on.
+func (ls *localServer) Addr() net.Addr {
+	return ls.ln.Addr()
+}
+
+// shutdown stops accepting sessions and gives the active ones up to grace to close.
+func (ls *localServer) shutdown(grace time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	err := ls.server.Shutdown(ctx)
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+
+	return nil
+}
+
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
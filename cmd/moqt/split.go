@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// maxFrameSize bounds the size of a single frame read from the input.
+const maxFrameSize = 16 << 20
+
+// newSplitFunc returns a bufio.SplitFunc cutting the input into frames.
+//
+//   - "delim" cuts at every occurrence of delim, which is stripped from the frames.
+//   - "size" cuts frames of size bytes; the last frame may be shorter.
+//   - "length" reads frames prefixed by their length as a QUIC variable-length integer,
+//     the format written by "moqt sub -format length".
+func newSplitFunc(mode, delim string, size int) (bufio.SplitFunc, error) {
+	switch mode {
+	case "delim":
+		sep, err := unquoteDelim(delim)
+		if err != nil {
+			return nil, err
+		}
+		return splitDelim(sep), nil
+	case "size":
+		if size <= 0 || size > maxFrameSize {
+			return nil, fmt.Errorf("size must be between 1 and %d", maxFrameSize)
+		}
+		return splitSize(size), nil
+	case "length":
+		return splitLength, nil
+	default:
+		return nil, fmt.Errorf("unknown split mode %q", mode)
+	}
+}
+
+// unquoteDelim interprets Go escape sequences such as "\n" or "\x00" in delim.
+func unquoteDelim(delim string) ([]byte, error) {
+	sep, err := strconv.Unquote(`"` + delim + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid delimiter %q: %w", delim, err)
+	}
+	if sep == "" {
+		return nil, fmt.Errorf("delimiter must not be empty")
+	}
+	return []byte(sep), nil
+}
+
+func splitDelim(sep []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, sep); i >= 0 {
+			return i + len(sep), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func splitSize(size int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if len(data) >= size {
+			return size, data[:size], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func splitLength(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	length, n, err := quicvarint.Parse(data)
+	if err != nil {
+		if atEOF {
+			return 0, nil, fmt.Errorf("truncated length prefix")
+		}
+		return 0, nil, nil
+	}
+	if length > maxFrameSize {
+		return 0, nil, fmt.Errorf("frame of %d bytes exceeds the limit of %d bytes", length, maxFrameSize)
+	}
+
+	end := n + int(length)
+	if len(data) < end {
+		if atEOF {
+			return 0, nil, fmt.Errorf("truncated frame: want %d bytes, have %d", length, len(data)-n)
+		}
+		return 0, nil, nil
+	}
+
+	return end, data[n:end], nil
+}
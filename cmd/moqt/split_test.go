@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/quic-go/quic-go/quicvarint"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scanFrames cuts input into frames with split.
+func scanFrames(input []byte, split bufio.SplitFunc) ([]string, error) {
+	s := bufio.NewScanner(bytes.NewReader(input))
+	s.Split(split)
+
+	var frames []string
+	for s.Scan() {
+		frames = append(frames, s.Text())
+	}
+	return frames, s.Err()
+}
+
+func TestUnquoteDelim(t *testing.T) {
+	tests := map[string]struct {
+		delim   string
+		want    []byte
+		wantErr bool
+	}{
+		"plain":             {delim: "--", want: []byte("--")},
+		"newline":           {delim: `\n`, want: []byte("\n")},
+		"crlf":              {delim: `\r\n`, want: []byte("\r\n")},
+		"hex escape":        {delim: `\x00`, want: []byte{0}},
+		"escaped quote":     {delim: `\"`, want: []byte(`"`)},
+		"escaped backslash": {delim: `\\`, want: []byte(`\`)},
+		"empty":             {delim: "", wantErr: true},
+		"invalid escape":    {delim: `\q`, wantErr: true},
+		"unescaped quote":   {delim: `"`, wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := unquoteDelim(tt.delim)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitDelim(t *testing.T) {
+	tests := map[string]struct {
+		sep   string
+		input string
+		want  []string
+	}{
+		"newline": {
+			sep:   "\n",
+			input: "a\nbb\nccc\n",
+			want:  []string{"a", "bb", "ccc"},
+		},
+		"trailing frame without delimiter": {
+			sep:   "\n",
+			input: "a\nbb",
+			want:  []string{"a", "bb"},
+		},
+		"empty frames": {
+			sep:   "\n",
+			input: "\n\na\n",
+			want:  []string{"", "", "a"},
+		},
+		"multibyte delimiter": {
+			sep:   "\r\n",
+			input: "a\nb\r\nc",
+			want:  []string{"a\nb", "c"},
+		},
+		"nul delimiter": {
+			sep:   "\x00",
+			input: "a\x00b\x00",
+			want:  []string{"a", "b"},
+		},
+		"no delimiter": {
+			sep:   "\n",
+			input: "abc",
+			want:  []string{"abc"},
+		},
+		"empty input": {
+			sep:   "\n",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := scanFrames([]byte(tt.input), splitDelim([]byte(tt.sep)))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitSize(t *testing.T) {
+	tests := map[string]struct {
+		size  int
+		input string
+		want  []string
+	}{
+		"exact": {
+			size:  2,
+			input: "aabbcc",
+			want:  []string{"aa", "bb", "cc"},
+		},
+		"short last frame": {
+			size:  4,
+			input: "aaaabb",
+			want:  []string{"aaaa", "bb"},
+		},
+		"larger than input": {
+			size:  8,
+			input: "abc",
+			want:  []string{"abc"},
+		},
+		"empty input": {
+			size:  2,
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := scanFrames([]byte(tt.input), splitSize(tt.size))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitLength(t *testing.T) {
+	frame := func(payload string) []byte {
+		return append(quicvarint.Append(nil, uint64(len(payload))), payload...)
+	}
+
+	tests := map[string]struct {
+		input   []byte
+		want    []string
+		wantErr string
+	}{
+		"frames": {
+			input: append(append(frame("a"), frame("")...), frame(strings.Repeat("b", 100))...),
+			want:  []string{"a", "", strings.Repeat("b", 100)},
+		},
+		"empty input": {
+			input: nil,
+			want:  nil,
+		},
+		"truncated length prefix": {
+			// A 2-byte varint cut after its first byte
+			input:   append(frame("a"), 0x40),
+			want:    []string{"a"},
+			wantErr: "truncated length prefix",
+		},
+		"truncated frame": {
+			input:   frame("abc")[:3],
+			wantErr: "truncated frame",
+		},
+		"frame too large": {
+			input:   quicvarint.Append(nil, maxFrameSize+1),
+			wantErr: "exceeds the limit",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := scanFrames(tt.input, splitLength)
+			assert.Equal(t, tt.want, got)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestNewSplitFunc(t *testing.T) {
+	tests := map[string]struct {
+		mode    string
+		delim   string
+		size    int
+		wantErr bool
+	}{
+		"delim":           {mode: "delim", delim: `\n`},
+		"invalid delim":   {mode: "delim", delim: `\q`, wantErr: true},
+		"size":            {mode: "size", size: 1024},
+		"size 0":          {mode: "size", size: 0, wantErr: true},
+		"negative size":   {mode: "size", size: -1, wantErr: true},
+		"size over limit": {mode: "size", size: maxFrameSize + 1, wantErr: true},
+		"length":          {mode: "length"},
+		"unknown mode":    {mode: "line", wantErr: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			split, err := newSplitFunc(tt.mode, tt.delim, tt.size)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, split)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, split)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const subUsage = "sub [flags] <url> <broadcast-path> [track-name]"
+
+func runSub(ctx context.Context, args []string) error {
+	fs := newFlagSet("sub", subUsage)
+
+	var df dialFlags
+	df.register(fs)
+
+	priority := fs.Uint("priority", 0, "track priority (0-255)")
+	format := fs.String("format", "raw", "output format: raw, length (varint length prefix) or hex")
+	outDir := fs.String("o", "", "write each group to a file in this directory instead of stdout")
+	groups := fs.Int("groups", 0, "exit after receiving this many groups (0 means unlimited)")
+	quiet := fs.Bool("q", false, "do not print statistics to stderr")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 || fs.NArg() > 3 {
+		return errUsage
+	}
+	if *priority > 255 {
+		return fmt.Errorf("priority must be between 0 and 255")
+	}
+
+	enc, err := newFrameEncoder(*format)
+	if err != nil {
+		return err
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			return err
+		}
+	}
+
+	rawURL := fs.Arg(0)
+	path := moqt.BroadcastPath(fs.Arg(1))
+	var name moqt.TrackName
+	if fs.NArg() == 3 {
+		name = moqt.TrackName(fs.Arg(2))
+	}
+
+	client := df.client(df.logger())
+	defer client.Close()
+
+	sess, err := df.dial(ctx, client, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	defer sess.CloseWithError(moqt.NoError, "no error")
+
+	tr, err := sess.Subscribe(path, name, &moqt.TrackConfig{
+		TrackPriority: moqt.TrackPriority(*priority),
+	})
+	if err != nil {
+		return err
+	}
+	defer tr.Close()
+
+	var stats *subStats
+	if !*quiet {
+		stats = newSubStats(os.Stderr)
+		defer stats.summary()
+	}
+
+	frame := moqt.NewFrame(0)
+
+	for n := 0; *groups == 0 || n < *groups; n++ {
+		gr, err := tr.AcceptGroup(ctx)
+		if err != nil {
+			if ctx.Err() != nil || closedByRemote(err) {
+				return nil
+			}
+			return err
+		}
+
+		err = readGroup(gr, frame, enc, *outDir, stats)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readGroup reads every frame of gr and writes it to stdout or to a file in outDir.
+func readGroup(gr *moqt.GroupReader, frame *moqt.Frame, enc frameEncoder, outDir string, stats *subStats) error {
+	seq := gr.GroupSequence()
+
+	var w io.Writer = os.Stdout
+	if outDir != "" {
+		f, err := os.Create(filepath.Join(outDir, fmt.Sprintf("group-%d%s", seq, enc.ext())))
+		if err != nil {
+			gr.CancelRead(moqt.InternalGroupErrorCode)
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	stats.startGroup(seq)
+
+	var index int
+	for {
+		err := gr.ReadFrame(frame)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			// A canceled group is reported but does not stop the subscription
+			stats.endGroup(err)
+			return nil
+		}
+
+		err = enc.encode(w, seq, index, frame.Body())
+		if err != nil {
+			gr.CancelRead(moqt.InternalGroupErrorCode)
+			return err
+		}
+
+		stats.frame(frame.Len())
+		index++
+	}
+
+	stats.endGroup(nil)
+
+	return nil
+}
+
+// subStats tracks group and frame timing of a subscription.
+// A nil *subStats discards everything.
+type subStats struct {
+	w io.Writer
+
+	start     time.Time
+	lastGroup time.Time
+
+	groups int
+	frames int
+	bytes  int
+
+	// Current group
+	seq         moqt.GroupSequence
+	groupStart  time.Time
+	lastFrame   time.Time
+	groupFrames int
+	groupBytes  int
+	maxGap      time.Duration
+}
+
+func newSubStats(w io.Writer) *subStats {
+	return &subStats{
+		w:     w,
+		start: time.Now(),
+	}
+}
+
+func (s *subStats) startGroup(seq moqt.GroupSequence) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+
+	var interval time.Duration
+	if !s.lastGroup.IsZero() {
+		interval = now.Sub(s.lastGroup)
+	}
+
+	s.seq = seq
+	s.groupStart = now
+	s.lastGroup = now
+	s.lastFrame = now
+	s.groupFrames = 0
+	s.groupBytes = 0
+	s.maxGap = 0
+
+	fmt.Fprintf(s.w, "group %d: started (+%s since previous group)\n", seq, interval.Round(time.Microsecond))
+}
+
+func (s *subStats) frame(n int) {
+	if s == nil {
+		return
+	}
+
+	now := time.Now()
+	s.maxGap = max(s.maxGap, now.Sub(s.lastFrame))
+	s.lastFrame = now
+
+	s.groupFrames++
+	s.groupBytes += n
+	s.frames++
+	s.bytes += n
+}
+
+func (s *subStats) endGroup(err error) {
+	if s == nil {
+		return
+	}
+
+	s.groups++
+
+	status := "done"
+	if err != nil {
+		status = err.Error()
+	}
+
+	fmt.Fprintf(s.w, "group %d: %s, %d frames, %d bytes in %s (max frame gap %s)\n",
+		s.seq, status, s.groupFrames, s.groupBytes,
+		time.Since(s.groupStart).Round(time.Microsecond), s.maxGap.Round(time.Microsecond))
+}
+
+func (s *subStats) summary() {
+	if s == nil {
+		return
+	}
+
+	elapsed := time.Since(s.start)
+
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(s.bytes) * 8 / elapsed.Seconds() / 1000
+	}
+
+	fmt.Fprintf(s.w, "total: %d groups, %d frames, %d bytes in %s (%.1f kbit/s)\n",
+		s.groups, s.frames, s.bytes, elapsed.Round(time.Millisecond), rate)
+}
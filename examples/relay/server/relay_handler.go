@@ -150,22 +150,27 @@ func (r *trackRelayer) relay(ctx context.Context) {
 		}
 		r.mu.RUnlock()
 
-		frame := moqt.NewFrame(0)
 		for {
+			// Frames are read into pooled buffers and shared with every
+			// destination instead of being copied for each of them
+			frame := moqt.GetFrame(0)
 			err := gr.ReadFrame(frame)
 			if err != nil {
+				moqt.PutFrame(frame)
 				break
 			}
+			shared := frame.Share()
 
 			for _, gw := range groups {
-				if err := gw.WriteFrame(frame); err != nil {
+				if err := gw.WriteSharedFrame(shared); err != nil {
 					continue
 				}
 			}
 
 			if r.lastGroupCache.seq == currentSeq {
-				r.lastGroupCache.addFrame(frame)
+				r.lastGroupCache.addFrame(shared.Retain())
 			}
+			shared.Release()
 		}
 	}
 }
@@ -173,7 +178,7 @@ func (r *trackRelayer) relay(ctx context.Context) {
 func newGroupCache(seq moqt.GroupSequence) *groupCache {
 	return &groupCache{
 		seq:    seq,
-		frames: make([]*moqt.Frame, 0),
+		frames: make([]*moqt.SharedFrame, 0),
 		dests:  make(map[int][]*moqt.GroupWriter),
 	}
 }
@@ -181,12 +186,13 @@ func newGroupCache(seq moqt.GroupSequence) *groupCache {
 type groupCache struct {
 	mu     sync.RWMutex
 	seq    moqt.GroupSequence
-	frames []*moqt.Frame
+	frames []*moqt.SharedFrame
 
 	dests map[int][]*moqt.GroupWriter
 }
 
-func (gc *groupCache) addFrame(frame *moqt.Frame) {
+// addFrame takes over a reference to frame until the cache moves to the next group.
+func (gc *groupCache) addFrame(frame *moqt.SharedFrame) {
 	gc.mu.Lock()
 	defer gc.mu.Unlock()
 
@@ -196,7 +202,7 @@ func (gc *groupCache) addFrame(frame *moqt.Frame) {
 
 	var err error
 	for _, gw := range gc.dests[frameCount-1] {
-		err = gw.WriteFrame(frame)
+		err = gw.WriteSharedFrame(frame)
 		if err != nil {
 			continue
 		}
@@ -222,14 +228,17 @@ func (gc *groupCache) flush(gw *moqt.GroupWriter) {
 	gc.dests[frameCount] = append(gc.dests[frameCount], gw)
 
 	// Create a snapshot of current frames to avoid race conditions
-	// This is efficient - just copies the slice header, not the data
-	frames := make([]*moqt.Frame, len(gc.frames))
-	copy(frames, gc.frames)
+	// The frames are retained so that they outlive a move to the next group
+	frames := make([]*moqt.SharedFrame, len(gc.frames))
+	for i, frame := range gc.frames {
+		frames[i] = frame.Retain()
+	}
 
 	gc.mu.Unlock()
 
 	for _, frame := range frames {
-		gw.WriteFrame(frame)
+		gw.WriteSharedFrame(frame)
+		frame.Release()
 	}
 }
 
@@ -253,5 +262,8 @@ func (gc *groupCache) update(seq moqt.GroupSequence) {
 			}
 		}
 	}
+	for _, frame := range gc.frames {
+		frame.Release()
+	}
 	gc.frames = gc.frames[:0]
 }
@@ -0,0 +1,21 @@
+// Package hls serves MOQ tracks to HLS players, with optional LL-HLS.
+//
+// The Handler subscribes to a track on its first request and packages each
+// group as a media segment made of its concatenated frames. With LL-HLS, each
+// frame is also served as a partial segment, and playlists support blocking
+// reloads and preload hints. The frames are served as they are, so tracks
+// should carry media a player can play, such as fMP4 or MPEG-TS fragments.
+/*
+	mux := moqt.NewTrackMux()
+	// ... publish broadcasts on mux
+
+	h := &hls.Handler{
+	    Subscriber: &hls.MuxSubscriber{Mux: mux},
+	    PartTarget: 200 * time.Millisecond,
+	}
+	defer h.Close()
+
+	// http://localhost:8080/live/video/index.m3u8 plays track "video" of "/live".
+	http.ListenAndServe(":8080", h)
+*/
+package hls
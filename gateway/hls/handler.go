@@ -0,0 +1,396 @@
+package hls
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const (
+	defaultTargetDuration = 2 * time.Second
+	defaultSegmentCount   = 6
+	defaultIdleTimeout    = 30 * time.Second
+	defaultExtension      = ".m4s"
+
+	// retainedSegments is the number of segments kept after they leave the playlist.
+	retainedSegments = 2
+)
+
+// Handler serves the tracks of MOQ broadcasts as HLS media playlists.
+//
+// Requests are routed by path, relative to where the handler is mounted:
+//
+//	/<broadcast path>/<track name>/index.m3u8        media playlist
+//	/<broadcast path>/<track name>/<msn><ext>        segment
+//	/<broadcast path>/<track name>/<msn>.<part><ext> LL-HLS partial segment
+//	/<broadcast path>/<track name>/init<ext>         initialization section
+//
+// A track is subscribed to on its first request and unsubscribed from when
+// it has not been requested for IdleTimeout. Each group of the track is
+// packaged as a segment made of the concatenated frames, and each frame as
+// a partial segment. Durations are measured from the arrival times of the frames.
+type Handler struct {
+	// Subscriber subscribes to the tracks. Use a *moqt.Session to serve the
+	// tracks of a peer and a *MuxSubscriber for those of a local TrackMux.
+	Subscriber Subscriber
+
+	// TrackConfig is used for the subscriptions.
+	TrackConfig *moqt.TrackConfig
+
+	// TargetDuration is the initial target duration, two seconds if zero.
+	// It grows when a longer segment is received.
+	TargetDuration time.Duration
+
+	// PartTarget enables LL-HLS with the given part target duration.
+	// Frames must not be longer than the part target.
+	PartTarget time.Duration
+
+	// Segments is the number of complete segments in a playlist, six if zero.
+	Segments int
+
+	// Extension is the file extension of segments and partial segments, ".m4s" if empty.
+	Extension string
+
+	// InitTrack returns the name of the track carrying the initialization
+	// section of a track, or an empty name if it has none. The frames of the
+	// latest group of that track are served as the initialization section.
+	InitTrack func(moqt.TrackName) moqt.TrackName
+
+	// IdleTimeout is how long a track stays subscribed without requests, 30 seconds if zero.
+	IdleTimeout time.Duration
+
+	Logger *slog.Logger
+
+	mu     sync.Mutex
+	tracks map[trackKey]*track
+	closed bool
+}
+
+type trackKey struct {
+	path moqt.BroadcastPath
+	name moqt.TrackName
+}
+
+func (h *Handler) targetDuration() time.Duration {
+	if h.TargetDuration > 0 {
+		return h.TargetDuration
+	}
+	return defaultTargetDuration
+}
+
+func (h *Handler) segmentCount() int {
+	if h.Segments > 0 {
+		return h.Segments
+	}
+	return defaultSegmentCount
+}
+
+func (h *Handler) extension() string {
+	if h.Extension != "" {
+		return h.Extension
+	}
+	return defaultExtension
+}
+
+func (h *Handler) idleTimeout() time.Duration {
+	if h.IdleTimeout > 0 {
+		return h.IdleTimeout
+	}
+	return defaultIdleTimeout
+}
+
+func (h *Handler) initTrack(name moqt.TrackName) moqt.TrackName {
+	if h.InitTrack == nil {
+		return ""
+	}
+	return h.InitTrack(name)
+}
+
+func (h *Handler) logger() *slog.Logger {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
+// blockTimeout bounds blocking playlist reloads and preload hint requests.
+func (h *Handler) blockTimeout() time.Duration {
+	return 3 * h.targetDuration()
+}
+
+// ServeHTTP serves playlists, segments and partial segments.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	dir, file := path.Split(path.Clean("/" + r.URL.Path))
+	broadcastPath, trackName := path.Split(strings.TrimSuffix(dir, "/"))
+	broadcastPath = strings.TrimSuffix(broadcastPath, "/")
+	if broadcastPath == "" || trackName == "" || file == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	t, err := h.track(moqt.BroadcastPath(broadcastPath), moqt.TrackName(trackName))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	t.touch()
+
+	ext := h.extension()
+	switch {
+	case file == playlistName:
+		h.servePlaylist(w, r, t)
+	case file == initName+ext && h.initTrack(t.name) != "":
+		h.serveInit(w, r, t)
+	case strings.HasSuffix(file, ext):
+		h.serveMedia(w, r, t, strings.TrimSuffix(file, ext))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+var errHandlerClosed = errors.New("hls: handler closed")
+
+// track returns the track, subscribing to it if it is not subscribed yet.
+func (h *Handler) track(path moqt.BroadcastPath, name moqt.TrackName) (*track, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, errHandlerClosed
+	}
+
+	key := trackKey{path: path, name: name}
+	if t, ok := h.tracks[key]; ok {
+		return t, nil
+	}
+
+	if h.tracks == nil {
+		h.tracks = make(map[trackKey]*track)
+	}
+
+	t := newTrack(h, path, name)
+	h.tracks[key] = t
+
+	go t.run()
+	go h.expire(key, t)
+
+	return t, nil
+}
+
+// expire unsubscribes from the track once it is idle or has ended.
+func (h *Handler) expire(key trackKey, t *track) {
+	timeout := h.idleTimeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+		case <-timer.C:
+			if idle := time.Since(t.idleSince()); idle < timeout {
+				timer.Reset(timeout - idle)
+				continue
+			}
+		}
+		break
+	}
+
+	h.mu.Lock()
+	if h.tracks[key] == t {
+		delete(h.tracks, key)
+	}
+	h.mu.Unlock()
+
+	t.close()
+}
+
+func (h *Handler) servePlaylist(w http.ResponseWriter, r *http.Request, t *track) {
+	query := r.URL.Query()
+
+	ready := t.listable
+
+	if msnParam := query.Get("_HLS_msn"); msnParam != "" && h.PartTarget > 0 {
+		msn, err := strconv.ParseUint(msnParam, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid _HLS_msn", http.StatusBadRequest)
+			return
+		}
+
+		index := -1
+		if partParam := query.Get("_HLS_part"); partParam != "" {
+			index, err = strconv.Atoi(partParam)
+			if err != nil || index < 0 {
+				http.Error(w, "invalid _HLS_part", http.StatusBadRequest)
+				return
+			}
+		}
+
+		t.mu.Lock()
+		next := t.nextMSN
+		t.mu.Unlock()
+		// Requests too far in the future are rejected
+		if msn > next+2 {
+			http.Error(w, "_HLS_msn is too far in the future", http.StatusBadRequest)
+			return
+		}
+
+		ready = func() bool {
+			if index < 0 {
+				return t.hasSegment(msn) || t.nextMSN > msn+1
+			}
+			return t.hasPart(msn, index) || t.hasSegment(msn) || t.nextMSN > msn+1
+		}
+	}
+
+	if !t.waitFor(r.Context(), h.blockTimeout(), ready) {
+		t.mu.Lock()
+		err := t.err
+		t.mu.Unlock()
+		h.trackError(w, err)
+		return
+	}
+
+	t.mu.Lock()
+	b := t.playlist()
+	t.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write(b)
+}
+
+func (h *Handler) serveInit(w http.ResponseWriter, r *http.Request, t *track) {
+	if !t.waitFor(r.Context(), h.blockTimeout(), func() bool { return t.hasInit }) {
+		http.Error(w, "initialization section not available", http.StatusNotFound)
+		return
+	}
+
+	t.mu.Lock()
+	b := t.init
+	t.mu.Unlock()
+
+	h.writeMedia(w, b)
+}
+
+func (h *Handler) serveMedia(w http.ResponseWriter, r *http.Request, t *track, name string) {
+	msnStr, partStr, isPart := strings.Cut(name, ".")
+
+	msn, err := strconv.ParseUint(msnStr, 10, 64)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if !isPart {
+		// A segment in progress is served once it completes
+		t.mu.Lock()
+		inProgress := msn+1 == t.nextMSN && !t.hasSegment(msn)
+		t.mu.Unlock()
+
+		if inProgress {
+			t.waitFor(r.Context(), h.blockTimeout(), func() bool { return t.hasSegment(msn) })
+		}
+
+		t.mu.Lock()
+		var b []byte
+		if t.hasSegment(msn) {
+			b = t.findSegment(msn).data()
+		}
+		t.mu.Unlock()
+
+		if b == nil {
+			http.NotFound(w, r)
+			return
+		}
+		h.writeMedia(w, b)
+		return
+	}
+
+	index, err := strconv.Atoi(partStr)
+	if err != nil || index < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	// The part of a preload hint is served as soon as it is available
+	t.mu.Lock()
+	nextMSN, nextIndex := t.nextPart()
+	hinted := msn == nextMSN && index == nextIndex
+	t.mu.Unlock()
+
+	if hinted {
+		t.waitFor(r.Context(), h.blockTimeout(), func() bool { return t.hasPart(msn, index) })
+	}
+
+	t.mu.Lock()
+	var b []byte
+	if t.hasPart(msn, index) {
+		b = t.findSegment(msn).parts[index].data
+	}
+	t.mu.Unlock()
+
+	if b == nil {
+		http.NotFound(w, r)
+		return
+	}
+	h.writeMedia(w, b)
+}
+
+func (h *Handler) writeMedia(w http.ResponseWriter, b []byte) {
+	w.Header().Set("Content-Type", contentType(h.extension()))
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	_, _ = w.Write(b)
+}
+
+func (h *Handler) trackError(w http.ResponseWriter, err error) {
+	if err == nil {
+		http.Error(w, "track not available yet", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusNotFound)
+}
+
+// Close unsubscribes from all the tracks. Requests are answered with 503 afterwards.
+func (h *Handler) Close() error {
+	h.mu.Lock()
+	h.closed = true
+	tracks := h.tracks
+	h.tracks = nil
+	h.mu.Unlock()
+
+	for _, t := range tracks {
+		t.close()
+	}
+
+	return nil
+}
+
+func contentType(ext string) string {
+	switch ext {
+	case ".ts":
+		return "video/mp2t"
+	case ".m4s", ".mp4", ".cmfv":
+		return "video/mp4"
+	case ".m4a", ".cmfa":
+		return "audio/mp4"
+	case ".aac":
+		return "audio/aac"
+	case ".vtt":
+		return "text/vtt"
+	default:
+		return "application/octet-stream"
+	}
+}
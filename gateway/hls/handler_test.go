@@ -0,0 +1,338 @@
+package hls
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPublisher publishes a track on a TrackMux and writes groups to its subscribers on demand.
+type testPublisher struct {
+	mu      sync.Mutex
+	writers []*moqt.TrackWriter
+	added   chan struct{}
+}
+
+func publish(t *testing.T, mux *moqt.TrackMux, path moqt.BroadcastPath, name moqt.TrackName) *testPublisher {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	p := &testPublisher{added: make(chan struct{}, 16)}
+
+	mux.PublishFunc(ctx, path, func(tw *moqt.TrackWriter) {
+		if tw.TrackName != name {
+			moqt.NotFound(tw)
+			return
+		}
+
+		subCtx := tw.Context()
+
+		p.mu.Lock()
+		p.writers = append(p.writers, tw)
+		p.mu.Unlock()
+		p.added <- struct{}{}
+
+		select {
+		case <-subCtx.Done():
+		case <-ctx.Done():
+		}
+	})
+
+	return p
+}
+
+func (p *testPublisher) waitSubscriber(t *testing.T) *moqt.TrackWriter {
+	t.Helper()
+
+	select {
+	case <-p.added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no subscriber")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.writers[len(p.writers)-1]
+}
+
+func writeGroup(t *testing.T, tw *moqt.TrackWriter, frames ...string) {
+	t.Helper()
+
+	gw, err := tw.OpenGroup()
+	require.NoError(t, err)
+
+	for _, f := range frames {
+		frame := moqt.NewFrame(len(f))
+		_, _ = frame.Write([]byte(f))
+		require.NoError(t, gw.WriteFrame(frame))
+		// Frames need distinct arrival times
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	require.NoError(t, gw.Close())
+}
+
+func newTestServer(t *testing.T, h *Handler) *httptest.Server {
+	srv := httptest.NewServer(h)
+	t.Cleanup(func() {
+		srv.Close()
+		_ = h.Close()
+		if sub, ok := h.Subscriber.(*MuxSubscriber); ok {
+			_ = sub.Close()
+		}
+	})
+
+	return srv
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   string
+}
+
+func get(t *testing.T, url string) response {
+	t.Helper()
+
+	rsp, err := http.Get(url)
+	require.NoError(t, err)
+	defer rsp.Body.Close()
+
+	b, err := io.ReadAll(rsp.Body)
+	require.NoError(t, err)
+
+	return response{status: rsp.StatusCode, header: rsp.Header, body: string(b)}
+}
+
+// getAsync requests url in the background.
+func getAsync(t *testing.T, url string) <-chan response {
+	ch := make(chan response, 1)
+	go func() {
+		rsp, err := http.Get(url)
+		if err != nil {
+			ch <- response{}
+			return
+		}
+		defer rsp.Body.Close()
+
+		b, _ := io.ReadAll(rsp.Body)
+		ch <- response{status: rsp.StatusCode, header: rsp.Header, body: string(b)}
+	}()
+	return ch
+}
+
+func receive(t *testing.T, ch <-chan response) response {
+	t.Helper()
+
+	select {
+	case rsp := <-ch:
+		return rsp
+	case <-time.After(5 * time.Second):
+		t.Fatal("request did not complete")
+		return response{}
+	}
+}
+
+func TestHandler_Segments(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	pub := publish(t, mux, "/live/stream", "video")
+
+	srv := newTestServer(t, &Handler{
+		Subscriber: &MuxSubscriber{Mux: mux},
+		Extension:  ".ts",
+	})
+
+	// The first playlist request subscribes and waits for a complete segment
+	playlist := getAsync(t, srv.URL+"/live/stream/video/index.m3u8")
+
+	tw := pub.waitSubscriber(t)
+	writeGroup(t, tw, "a", "b")
+	writeGroup(t, tw, "c")
+
+	rsp := receive(t, playlist)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "application/vnd.apple.mpegurl", rsp.header.Get("Content-Type"))
+	assert.Contains(t, rsp.body, "#EXT-X-MEDIA-SEQUENCE:0\n")
+	assert.Contains(t, rsp.body, "\n0.ts\n")
+	assert.NotContains(t, rsp.body, "1.ts")
+	assert.NotContains(t, rsp.body, "#EXT-X-PART")
+
+	rsp = get(t, srv.URL+"/live/stream/video/0.ts")
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "video/mp2t", rsp.header.Get("Content-Type"))
+	assert.Equal(t, "ab", rsp.body)
+
+	// The segment in progress is served once it completes
+	segment := getAsync(t, srv.URL+"/live/stream/video/1.ts")
+	writeGroup(t, tw, "d")
+
+	rsp = receive(t, segment)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "c", rsp.body)
+
+	assert.Equal(t, http.StatusNotFound, get(t, srv.URL+"/live/stream/video/9.ts").status)
+}
+
+func TestHandler_LowLatency(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	pub := publish(t, mux, "/live", "video")
+
+	srv := newTestServer(t, &Handler{
+		Subscriber: &MuxSubscriber{Mux: mux},
+		PartTarget: 200 * time.Millisecond,
+	})
+
+	playlist := getAsync(t, srv.URL+"/live/video/index.m3u8")
+
+	tw := pub.waitSubscriber(t)
+	writeGroup(t, tw, "a", "b")
+
+	rsp := receive(t, playlist)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Contains(t, rsp.body, "#EXT-X-PART-INF:PART-TARGET=0.200\n")
+	assert.Contains(t, rsp.body, `URI="0.0.m4s",INDEPENDENT=YES`)
+	assert.Contains(t, rsp.body, `#EXT-X-PRELOAD-HINT:TYPE=PART,URI="0.1.m4s"`)
+
+	rsp = get(t, srv.URL+"/live/video/0.0.m4s")
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "a", rsp.body)
+
+	// Requests for the hinted part and a blocking reload wait for the next frame
+	hinted := getAsync(t, srv.URL+"/live/video/0.1.m4s")
+	reload := getAsync(t, srv.URL+"/live/video/index.m3u8?_HLS_msn=1&_HLS_part=0")
+
+	writeGroup(t, tw, "c", "d")
+
+	rsp = receive(t, hinted)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "b", rsp.body)
+
+	rsp = receive(t, reload)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Contains(t, rsp.body, "#EXTINF:")
+	assert.Contains(t, rsp.body, "\n0.m4s\n")
+	assert.Contains(t, rsp.body, `URI="1.0.m4s",INDEPENDENT=YES`)
+
+	rsp = get(t, srv.URL+"/live/video/0.m4s")
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "ab", rsp.body)
+
+	rsp = get(t, srv.URL+"/live/video/index.m3u8?_HLS_msn=10")
+	assert.Equal(t, http.StatusBadRequest, rsp.status)
+}
+
+func TestHandler_InitTrack(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var media *moqt.TrackWriter
+	mediaReady := make(chan struct{})
+	mux.PublishFunc(ctx, "/live", func(tw *moqt.TrackWriter) {
+		subCtx := tw.Context()
+		switch tw.TrackName {
+		case "video.init":
+			gw, err := tw.OpenGroup()
+			if err != nil {
+				return
+			}
+			frame := moqt.NewFrame(0)
+			_, _ = frame.Write([]byte("ftypmoov"))
+			_ = gw.WriteFrame(frame)
+			_ = gw.Close()
+		case "video":
+			media = tw
+			close(mediaReady)
+		default:
+			moqt.NotFound(tw)
+			return
+		}
+		<-subCtx.Done()
+	})
+
+	srv := newTestServer(t, &Handler{
+		Subscriber: &MuxSubscriber{Mux: mux},
+		InitTrack: func(name moqt.TrackName) moqt.TrackName {
+			return name + ".init"
+		},
+	})
+
+	playlist := getAsync(t, srv.URL+"/live/video/index.m3u8")
+
+	<-mediaReady
+	writeGroup(t, media, "moof")
+	writeGroup(t, media, "moof")
+
+	rsp := receive(t, playlist)
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Contains(t, rsp.body, "#EXT-X-VERSION:6\n")
+	assert.Contains(t, rsp.body, `#EXT-X-MAP:URI="init.m4s"`)
+
+	rsp = get(t, srv.URL+"/live/video/init.m4s")
+	require.Equal(t, http.StatusOK, rsp.status)
+	assert.Equal(t, "video/mp4", rsp.header.Get("Content-Type"))
+	assert.Equal(t, "ftypmoov", rsp.body)
+}
+
+func TestHandler_TrackNotFound(t *testing.T) {
+	mux := moqt.NewTrackMux()
+
+	srv := newTestServer(t, &Handler{
+		Subscriber:     &MuxSubscriber{Mux: mux},
+		TargetDuration: time.Second,
+	})
+
+	rsp := get(t, srv.URL+"/missing/video/index.m3u8")
+	assert.Equal(t, http.StatusNotFound, rsp.status)
+}
+
+func TestHandler_IdleTimeout(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	pub := publish(t, mux, "/live", "video")
+
+	h := &Handler{
+		Subscriber:  &MuxSubscriber{Mux: mux},
+		IdleTimeout: 50 * time.Millisecond,
+	}
+	srv := newTestServer(t, h)
+
+	playlist := getAsync(t, srv.URL+"/live/video/index.m3u8")
+	tw := pub.waitSubscriber(t)
+	writeGroup(t, tw, "a")
+	writeGroup(t, tw, "b")
+	require.Equal(t, http.StatusOK, receive(t, playlist).status)
+
+	// Without requests, the track is unsubscribed from
+	assert.Eventually(t, func() bool {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return len(h.tracks) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A new request subscribes again
+	_ = getAsync(t, srv.URL+"/live/video/index.m3u8")
+	pub.waitSubscriber(t)
+}
+
+func TestHandler_BadRequests(t *testing.T) {
+	srv := newTestServer(t, &Handler{
+		Subscriber: &MuxSubscriber{Mux: moqt.NewTrackMux()},
+	})
+
+	rsp, err := http.Post(srv.URL+"/live/video/index.m3u8", "text/plain", strings.NewReader(""))
+	require.NoError(t, err)
+	rsp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, rsp.StatusCode)
+
+	assert.Equal(t, http.StatusNotFound, get(t, srv.URL+"/index.m3u8").status)
+	assert.Equal(t, http.StatusNotFound, get(t, srv.URL+"/video/index.m3u8").status)
+}
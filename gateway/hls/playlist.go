@@ -0,0 +1,123 @@
+package hls
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	playlistName = "index.m3u8"
+	initName     = "init"
+)
+
+// partSegments is the number of complete segments listed with their parts
+// in LL-HLS playlists, in addition to the segment in progress.
+const partSegments = 2
+
+func segmentURI(msn uint64, ext string) string {
+	return fmt.Sprintf("%d%s", msn, ext)
+}
+
+func partURI(msn uint64, index int, ext string) string {
+	return fmt.Sprintf("%d.%d%s", msn, index, ext)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// playlist renders the media playlist of the track. The caller must hold t.mu.
+func (t *track) playlist() []byte {
+	h := t.h
+	ext := h.extension()
+	lowLatency := h.PartTarget > 0
+
+	// The segments to list: the last complete ones and the one in progress
+	var listed []*segment
+	var complete int
+	for i := len(t.segments) - 1; i >= 0; i-- {
+		seg := t.segments[i]
+		if seg.complete {
+			if complete == h.segmentCount() {
+				break
+			}
+			complete++
+		} else if !lowLatency || len(seg.parts) == 0 {
+			// Without parts, a segment is listed once it is complete
+			continue
+		}
+		listed = append(listed, seg)
+	}
+	for i, j := 0, len(listed)-1; i < j; i, j = i+1, j-1 {
+		listed[i], listed[j] = listed[j], listed[i]
+	}
+
+	var b strings.Builder
+
+	b.WriteString("#EXTM3U\n")
+	switch {
+	case lowLatency:
+		b.WriteString("#EXT-X-VERSION:9\n")
+	case h.InitTrack != nil:
+		b.WriteString("#EXT-X-VERSION:6\n")
+	default:
+		b.WriteString("#EXT-X-VERSION:3\n")
+	}
+	fmt.Fprintf(&b, "#EXT-X-TARGETDURATION:%d\n", int(math.Ceil(t.targetDuration.Seconds())))
+
+	if lowLatency {
+		fmt.Fprintf(&b, "#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=%s\n", seconds(3*h.PartTarget))
+		fmt.Fprintf(&b, "#EXT-X-PART-INF:PART-TARGET=%s\n", seconds(h.PartTarget))
+	}
+
+	var firstMSN uint64
+	if len(listed) > 0 {
+		firstMSN = listed[0].msn
+	} else {
+		firstMSN = t.nextMSN
+	}
+	fmt.Fprintf(&b, "#EXT-X-MEDIA-SEQUENCE:%d\n", firstMSN)
+
+	if h.initTrack(t.name) != "" {
+		fmt.Fprintf(&b, "#EXT-X-MAP:URI=\"%s%s\"\n", initName, ext)
+	}
+
+	for i, seg := range listed {
+		if lowLatency && i >= len(listed)-partSegments-1 {
+			for j, p := range seg.parts {
+				fmt.Fprintf(&b, "#EXT-X-PART:DURATION=%s,URI=\"%s\"", seconds(p.duration), partURI(seg.msn, j, ext))
+				if j == 0 {
+					b.WriteString(",INDEPENDENT=YES")
+				}
+				b.WriteByte('\n')
+			}
+		}
+
+		if seg.complete {
+			fmt.Fprintf(&b, "#EXTINF:%s,\n%s\n", seconds(seg.duration()), segmentURI(seg.msn, ext))
+		}
+	}
+
+	if t.err != nil {
+		b.WriteString("#EXT-X-ENDLIST\n")
+	} else if lowLatency {
+		msn, index := t.nextPart()
+		fmt.Fprintf(&b, "#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"%s\"\n", partURI(msn, index, ext))
+	}
+
+	return []byte(b.String())
+}
+
+// nextPart returns the media sequence number and index of the next part to become visible.
+// The caller must hold t.mu.
+func (t *track) nextPart() (uint64, int) {
+	if n := len(t.segments); n > 0 {
+		last := t.segments[n-1]
+		if !last.complete {
+			return last.msn, len(last.parts)
+		}
+	}
+	return t.nextMSN, 0
+}
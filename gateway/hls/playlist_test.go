@@ -0,0 +1,93 @@
+package hls
+
+import (
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTrack(h *Handler, segments ...*segment) *track {
+	t := newTrack(h, "/live", "video")
+	t.segments = segments
+	if n := len(segments); n > 0 {
+		t.nextMSN = segments[n-1].msn + 1
+	}
+	return t
+}
+
+func testSegment(msn uint64, complete bool, durations ...time.Duration) *segment {
+	seg := &segment{msn: msn, ended: complete, complete: complete}
+	for _, d := range durations {
+		seg.parts = append(seg.parts, &part{data: []byte{byte(msn)}, duration: d})
+	}
+	return seg
+}
+
+func TestPlaylist(t *testing.T) {
+	tr := testTrack(&Handler{Segments: 2},
+		testSegment(3, true, time.Second),
+		testSegment(4, true, 500*time.Millisecond, 1500*time.Millisecond),
+		testSegment(5, true, 2*time.Second),
+		testSegment(6, false, time.Second),
+	)
+
+	want := "#EXTM3U\n" +
+		"#EXT-X-VERSION:3\n" +
+		"#EXT-X-TARGETDURATION:2\n" +
+		"#EXT-X-MEDIA-SEQUENCE:4\n" +
+		"#EXTINF:2.000,\n4.m4s\n" +
+		"#EXTINF:2.000,\n5.m4s\n"
+
+	assert.Equal(t, want, string(tr.playlist()))
+}
+
+func TestPlaylist_LowLatency(t *testing.T) {
+	h := &Handler{
+		Segments:   3,
+		PartTarget: 500 * time.Millisecond,
+		Extension:  ".ts",
+	}
+	tr := testTrack(h,
+		testSegment(0, true, time.Second),
+		testSegment(1, true, time.Second),
+		testSegment(2, true, 500*time.Millisecond, 500*time.Millisecond),
+		testSegment(3, false, 250*time.Millisecond),
+	)
+
+	want := "#EXTM3U\n" +
+		"#EXT-X-VERSION:9\n" +
+		"#EXT-X-TARGETDURATION:2\n" +
+		"#EXT-X-SERVER-CONTROL:CAN-BLOCK-RELOAD=YES,PART-HOLD-BACK=1.500\n" +
+		"#EXT-X-PART-INF:PART-TARGET=0.500\n" +
+		"#EXT-X-MEDIA-SEQUENCE:0\n" +
+		"#EXTINF:1.000,\n0.ts\n" +
+		"#EXT-X-PART:DURATION=1.000,URI=\"1.0.ts\",INDEPENDENT=YES\n" +
+		"#EXTINF:1.000,\n1.ts\n" +
+		"#EXT-X-PART:DURATION=0.500,URI=\"2.0.ts\",INDEPENDENT=YES\n" +
+		"#EXT-X-PART:DURATION=0.500,URI=\"2.1.ts\"\n" +
+		"#EXTINF:1.000,\n2.ts\n" +
+		"#EXT-X-PART:DURATION=0.250,URI=\"3.0.ts\",INDEPENDENT=YES\n" +
+		"#EXT-X-PRELOAD-HINT:TYPE=PART,URI=\"3.1.ts\"\n"
+
+	assert.Equal(t, want, string(tr.playlist()))
+}
+
+func TestPlaylist_InitAndEnd(t *testing.T) {
+	h := &Handler{
+		InitTrack: func(name moqt.TrackName) moqt.TrackName { return name + ".init" },
+	}
+	tr := testTrack(h, testSegment(0, true, time.Second))
+	tr.err = errTrackEnded
+
+	want := "#EXTM3U\n" +
+		"#EXT-X-VERSION:6\n" +
+		"#EXT-X-TARGETDURATION:2\n" +
+		"#EXT-X-MEDIA-SEQUENCE:0\n" +
+		"#EXT-X-MAP:URI=\"init.m4s\"\n" +
+		"#EXTINF:1.000,\n0.m4s\n" +
+		"#EXT-X-ENDLIST\n"
+
+	assert.Equal(t, want, string(tr.playlist()))
+}
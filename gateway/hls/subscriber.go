@@ -0,0 +1,84 @@
+package hls
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicmem"
+)
+
+// Subscriber subscribes to tracks. *moqt.Session implements it.
+type Subscriber interface {
+	Subscribe(path moqt.BroadcastPath, name moqt.TrackName, config *moqt.TrackConfig) (*moqt.TrackReader, error)
+}
+
+var _ Subscriber = (*moqt.Session)(nil)
+
+// MuxSubscriber subscribes to the tracks published on a local TrackMux.
+// The subscriptions go through an in-memory session, which is established
+// on the first call to Subscribe.
+type MuxSubscriber struct {
+	Mux *moqt.TrackMux
+
+	once   sync.Once
+	server *moqt.Server
+	sess   *moqt.Session
+	err    error
+}
+
+var _ Subscriber = (*MuxSubscriber)(nil)
+
+// Subscribe subscribes to the track on the TrackMux.
+func (s *MuxSubscriber) Subscribe(path moqt.BroadcastPath, name moqt.TrackName, config *moqt.TrackConfig) (*moqt.TrackReader, error) {
+	s.once.Do(s.dial)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.sess.Subscribe(path, name, config)
+}
+
+func (s *MuxSubscriber) dial() {
+	mux := s.Mux
+	if mux == nil {
+		mux = moqt.DefaultMux
+	}
+
+	clientConn, serverConn := quicmem.Pipe(moqt.NextProtoMOQ)
+
+	s.server = &moqt.Server{
+		SetupHandler: moqt.SetupHandlerFunc(func(w moqt.SetupResponseWriter, r *moqt.SetupRequest) {
+			_, _ = moqt.Accept(w, r, mux)
+		}),
+	}
+	go func() { _ = s.server.ServeQUICConn(serverConn) }()
+
+	client := &moqt.Client{
+		DialQUICFunc: func(context.Context, string, *tls.Config, *quic.Config) (quic.Connection, error) {
+			return clientConn, nil
+		},
+	}
+
+	s.sess, s.err = client.DialQUIC(context.Background(), clientConn.RemoteAddr().String(), "/", nil)
+	if s.err != nil {
+		_ = s.server.Close()
+	}
+}
+
+// Close closes the in-memory session.
+func (s *MuxSubscriber) Close() error {
+	s.once.Do(func() {
+		s.err = moqt.ErrClientClosed
+	})
+
+	if s.sess == nil {
+		return nil
+	}
+
+	err := s.sess.CloseWithError(moqt.NoError, moqt.SessionErrorText(moqt.NoError))
+	_ = s.server.Close()
+
+	return err
+}
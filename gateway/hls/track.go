@@ -0,0 +1,384 @@
+package hls
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+var errTrackEnded = errors.New("hls: track ended")
+
+// part is a frame of a group, served as an LL-HLS partial segment.
+type part struct {
+	data     []byte
+	duration time.Duration
+}
+
+// segment is a group, served as a media segment.
+type segment struct {
+	msn   uint64
+	parts []*part
+
+	// ended is set when the group has been read to the end.
+	ended bool
+	// complete is set when the durations of all the parts are known.
+	complete bool
+}
+
+func (s *segment) duration() time.Duration {
+	var d time.Duration
+	for _, p := range s.parts {
+		d += p.duration
+	}
+	return d
+}
+
+func (s *segment) data() []byte {
+	var n int
+	for _, p := range s.parts {
+		n += len(p.data)
+	}
+
+	b := make([]byte, 0, n)
+	for _, p := range s.parts {
+		b = append(b, p.data...)
+	}
+	return b
+}
+
+// track packages the groups of a subscribed track into segments.
+//
+// The duration of a frame is the time until the next frame arrives, so a
+// frame becomes visible as a part when the next one is received, and a
+// segment completes with the first frame of the next group.
+type track struct {
+	h    *Handler
+	path moqt.BroadcastPath
+	name moqt.TrackName
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	signal chan struct{}
+
+	// segments holds the retained segments in order. Only the last one may be incomplete.
+	segments []*segment
+	nextMSN  uint64
+
+	// pending is the latest frame, whose duration is not known yet.
+	pending        *part
+	pendingSegment *segment
+	pendingAt      time.Time
+
+	init    []byte
+	hasInit bool
+
+	targetDuration time.Duration
+
+	// err is set when the subscription ends.
+	err error
+
+	lastAccess time.Time
+}
+
+func newTrack(h *Handler, path moqt.BroadcastPath, name moqt.TrackName) *track {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &track{
+		h:              h,
+		path:           path,
+		name:           name,
+		ctx:            ctx,
+		cancel:         cancel,
+		signal:         make(chan struct{}),
+		targetDuration: h.targetDuration(),
+		lastAccess:     time.Now(),
+	}
+}
+
+// broadcast wakes up the waiting requests. The caller must hold t.mu.
+func (t *track) broadcast() {
+	close(t.signal)
+	t.signal = make(chan struct{})
+}
+
+func (t *track) touch() {
+	t.mu.Lock()
+	t.lastAccess = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *track) idleSince() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lastAccess
+}
+
+// waitFor blocks until cond holds, the track ends, ctx is done or timeout passes.
+// cond is called with t.mu held.
+func (t *track) waitFor(ctx context.Context, timeout time.Duration, cond func() bool) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for {
+		if cond() {
+			return true
+		}
+		if t.err != nil {
+			return false
+		}
+
+		signal := t.signal
+		t.mu.Unlock()
+		select {
+		case <-signal:
+			t.mu.Lock()
+		case <-ctx.Done():
+			t.mu.Lock()
+			return cond()
+		case <-timer.C:
+			t.mu.Lock()
+			return cond()
+		}
+	}
+}
+
+func (t *track) run() {
+	if initName := t.h.initTrack(t.name); initName != "" {
+		go t.runInit(initName)
+	}
+
+	tr, err := t.h.Subscriber.Subscribe(t.path, t.name, t.h.TrackConfig)
+	if err != nil {
+		t.end(err)
+		return
+	}
+	defer tr.Close()
+
+	frame := moqt.NewFrame(0)
+	for {
+		gr, err := tr.AcceptGroup(t.ctx)
+		if err != nil {
+			t.end(err)
+			return
+		}
+
+		t.readGroup(gr, frame)
+	}
+}
+
+func (t *track) readGroup(gr *moqt.GroupReader, frame *moqt.Frame) {
+	var seg *segment
+	for {
+		err := gr.ReadFrame(frame)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				gr.CancelRead(moqt.InternalGroupErrorCode)
+			}
+			break
+		}
+
+		if seg == nil {
+			seg = t.startSegment()
+		}
+		t.addFrame(seg, frame.Body())
+	}
+
+	if seg != nil {
+		t.endSegment(seg)
+	}
+}
+
+func (t *track) startSegment() *segment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seg := &segment{msn: t.nextMSN}
+	t.nextMSN++
+	t.segments = append(t.segments, seg)
+
+	// Keep a few segments that left the playlist for clients still fetching them
+	if keep := t.h.segmentCount() + retainedSegments + 1; len(t.segments) > keep {
+		t.segments = t.segments[len(t.segments)-keep:]
+	}
+
+	return seg
+}
+
+func (t *track) addFrame(seg *segment, data []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.flushPending(now.Sub(t.pendingAt))
+
+	t.pending = &part{data: append([]byte(nil), data...)}
+	t.pendingSegment = seg
+	t.pendingAt = now
+}
+
+func (t *track) endSegment(seg *segment) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seg.ended = true
+	if t.pendingSegment != seg {
+		seg.complete = true
+		t.broadcast()
+	}
+}
+
+// flushPending makes the pending frame visible with the given duration.
+// The caller must hold t.mu.
+func (t *track) flushPending(d time.Duration) {
+	if t.pending == nil {
+		return
+	}
+
+	t.pending.duration = d
+	seg := t.pendingSegment
+	seg.parts = append(seg.parts, t.pending)
+	if seg.ended {
+		seg.complete = true
+		if sd := seg.duration(); sd > t.targetDuration {
+			t.targetDuration = sd
+		}
+	}
+
+	t.pending = nil
+	t.pendingSegment = nil
+	t.broadcast()
+}
+
+func (t *track) end(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.err != nil {
+		return
+	}
+
+	// The last frame lasts as long as the average frame before it
+	if t.pending != nil {
+		t.pendingSegment.ended = true
+		t.flushPending(t.averagePartDuration())
+	}
+	for _, seg := range t.segments {
+		seg.ended = true
+		seg.complete = true
+	}
+
+	if err == nil || errors.Is(err, context.Canceled) {
+		err = errTrackEnded
+	}
+	t.err = err
+	t.broadcast()
+
+	// Let the handler forget the track, so that it is subscribed again on the next request
+	t.cancel()
+}
+
+// averagePartDuration returns the average duration of the retained parts.
+// The caller must hold t.mu.
+func (t *track) averagePartDuration() time.Duration {
+	var total time.Duration
+	var n int
+	for _, seg := range t.segments {
+		for _, p := range seg.parts {
+			total += p.duration
+			n++
+		}
+	}
+	if n == 0 {
+		if t.h.PartTarget > 0 {
+			return t.h.PartTarget
+		}
+		return t.targetDuration
+	}
+	return total / time.Duration(n)
+}
+
+func (t *track) runInit(name moqt.TrackName) {
+	tr, err := t.h.Subscriber.Subscribe(t.path, name, t.h.TrackConfig)
+	if err != nil {
+		t.h.logger().Debug("failed to subscribe to the init track",
+			"broadcast_path", t.path,
+			"track_name", name,
+			"error", err,
+		)
+		return
+	}
+	defer tr.Close()
+
+	frame := moqt.NewFrame(0)
+	for {
+		gr, err := tr.AcceptGroup(t.ctx)
+		if err != nil {
+			return
+		}
+
+		var init []byte
+		for {
+			if err := gr.ReadFrame(frame); err != nil {
+				break
+			}
+			init = append(init, frame.Body()...)
+		}
+
+		t.mu.Lock()
+		t.init = init
+		t.hasInit = true
+		t.broadcast()
+		t.mu.Unlock()
+	}
+}
+
+// findSegment returns the retained segment with the media sequence number.
+// The caller must hold t.mu.
+func (t *track) findSegment(msn uint64) *segment {
+	if len(t.segments) == 0 {
+		return nil
+	}
+	first := t.segments[0].msn
+	if msn < first || msn >= first+uint64(len(t.segments)) {
+		return nil
+	}
+	return t.segments[msn-first]
+}
+
+// hasSegment reports whether the segment is complete.
+// The caller must hold t.mu.
+func (t *track) hasSegment(msn uint64) bool {
+	seg := t.findSegment(msn)
+	return seg != nil && seg.complete
+}
+
+// hasPart reports whether the part of the segment is visible.
+// The caller must hold t.mu.
+func (t *track) hasPart(msn uint64, index int) bool {
+	seg := t.findSegment(msn)
+	return seg != nil && index < len(seg.parts)
+}
+
+// listable reports whether a playlist would list any segment or part.
+// The caller must hold t.mu.
+func (t *track) listable() bool {
+	for _, seg := range t.segments {
+		if seg.complete || (t.h.PartTarget > 0 && len(seg.parts) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *track) close() {
+	t.cancel()
+}
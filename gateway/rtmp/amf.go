@@ -0,0 +1,290 @@
+package rtmp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// AMF0 type markers.
+const (
+	amf0Number      byte = 0x00
+	amf0Boolean     byte = 0x01
+	amf0String      byte = 0x02
+	amf0Object      byte = 0x03
+	amf0Null        byte = 0x05
+	amf0Undefined   byte = 0x06
+	amf0ECMAArray   byte = 0x08
+	amf0ObjectEnd   byte = 0x09
+	amf0StrictArray byte = 0x0a
+	amf0Date        byte = 0x0b
+	amf0LongString  byte = 0x0c
+)
+
+// amfObject is an AMF0 object whose properties are encoded in order.
+type amfObject []amfProperty
+
+type amfProperty struct {
+	Key   string
+	Value any
+}
+
+// amfUndefined is the AMF0 undefined value.
+type amfUndefined struct{}
+
+var (
+	errAMFUnsupported = errors.New("rtmp: unsupported amf0 type")
+	errAMFTooDeep     = errors.New("rtmp: amf0 values nested too deeply")
+)
+
+// maxAMFDepth is the deepest nesting of objects and arrays decoded.
+const maxAMFDepth = 32
+
+// encodeAMF0 appends the AMF0 encoding of the values to b.
+// Supported values are float64, int, bool, string, nil, amfUndefined,
+// amfObject, map[string]any and []any.
+func encodeAMF0(b []byte, values ...any) ([]byte, error) {
+	var err error
+	for _, v := range values {
+		b, err = appendAMF0(b, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+func appendAMF0(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case float64:
+		b = append(b, amf0Number)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case int:
+		return appendAMF0(b, float64(v))
+	case bool:
+		if v {
+			return append(b, amf0Boolean, 1), nil
+		}
+		return append(b, amf0Boolean, 0), nil
+	case string:
+		if len(v) > math.MaxUint16 {
+			b = append(b, amf0LongString)
+			b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+			return append(b, v...), nil
+		}
+		b = append(b, amf0String)
+		return appendAMF0Key(b, v), nil
+	case nil:
+		return append(b, amf0Null), nil
+	case amfUndefined:
+		return append(b, amf0Undefined), nil
+	case amfObject:
+		b = append(b, amf0Object)
+		var err error
+		for _, p := range v {
+			b = appendAMF0Key(b, p.Key)
+			b, err = appendAMF0(b, p.Value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(b, 0, 0, amf0ObjectEnd), nil
+	case map[string]any:
+		b = append(b, amf0ECMAArray)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+		var err error
+		for key, value := range v {
+			b = appendAMF0Key(b, key)
+			b, err = appendAMF0(b, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(b, 0, 0, amf0ObjectEnd), nil
+	case []any:
+		b = append(b, amf0StrictArray)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+		var err error
+		for _, value := range v {
+			b, err = appendAMF0(b, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", errAMFUnsupported, v)
+	}
+}
+
+func appendAMF0Key(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// decodeAMF0 decodes every AMF0 value in b.
+// Objects and ECMA arrays decode to map[string]any, strict arrays to []any,
+// numbers and dates to float64, and null and undefined to nil.
+func decodeAMF0(b []byte) ([]any, error) {
+	d := amfDecoder{b: b}
+
+	var values []any
+	for len(d.b) > 0 {
+		v, err := d.value()
+		if err != nil {
+			return values, err
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+type amfDecoder struct {
+	b     []byte
+	depth int
+}
+
+func (d *amfDecoder) next(n int) ([]byte, error) {
+	if len(d.b) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	p := d.b[:n]
+	d.b = d.b[n:]
+	return p, nil
+}
+
+func (d *amfDecoder) value() (any, error) {
+	marker, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch marker[0] {
+	case amf0Number:
+		p, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(p)), nil
+	case amf0Boolean:
+		p, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return p[0] != 0, nil
+	case amf0String:
+		return d.key()
+	case amf0LongString:
+		p, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		s, err := d.next(int(binary.BigEndian.Uint32(p)))
+		if err != nil {
+			return nil, err
+		}
+		return string(s), nil
+	case amf0Null, amf0Undefined:
+		return nil, nil
+	case amf0Object:
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		return d.properties()
+	case amf0ECMAArray:
+		// The count is only a hint; the properties end with an object end marker
+		if _, err := d.next(4); err != nil {
+			return nil, err
+		}
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		return d.properties()
+	case amf0StrictArray:
+		p, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(p)
+		if int(n) > len(d.b) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err := d.enter(); err != nil {
+			return nil, err
+		}
+		defer d.leave()
+		values := make([]any, 0, n)
+		for range n {
+			v, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case amf0Date:
+		p, err := d.next(10) // 8-byte milliseconds and a 2-byte time zone
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(p)), nil
+	default:
+		return nil, fmt.Errorf("%w: 0x%02x", errAMFUnsupported, marker[0])
+	}
+}
+
+// enter descends into an object or array, failing past maxAMFDepth.
+func (d *amfDecoder) enter() error {
+	if d.depth >= maxAMFDepth {
+		return errAMFTooDeep
+	}
+	d.depth++
+	return nil
+}
+
+func (d *amfDecoder) leave() {
+	d.depth--
+}
+
+func (d *amfDecoder) key() (string, error) {
+	p, err := d.next(2)
+	if err != nil {
+		return "", err
+	}
+	s, err := d.next(int(binary.BigEndian.Uint16(p)))
+	if err != nil {
+		return "", err
+	}
+	return string(s), nil
+}
+
+func (d *amfDecoder) properties() (map[string]any, error) {
+	obj := make(map[string]any)
+	for {
+		key, err := d.key()
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			end, err := d.next(1)
+			if err != nil {
+				return nil, err
+			}
+			if end[0] != amf0ObjectEnd {
+				return nil, fmt.Errorf("rtmp: expected amf0 object end, got 0x%02x", end[0])
+			}
+			return obj, nil
+		}
+
+		v, err := d.value()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = v
+	}
+}
@@ -0,0 +1,119 @@
+package rtmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMF0_RoundTrip(t *testing.T) {
+	b, err := encodeAMF0(nil,
+		"connect",
+		1,
+		amfObject{
+			{"app", "live"},
+			{"tcUrl", "rtmp://localhost/live"},
+			{"fpad", false},
+		},
+		nil,
+		amfUndefined{},
+		map[string]any{"width": 1280.0},
+		[]any{"a", 2.0},
+	)
+	require.NoError(t, err)
+
+	values, err := decodeAMF0(b)
+	require.NoError(t, err)
+	require.Len(t, values, 7)
+
+	assert.Equal(t, "connect", values[0])
+	assert.Equal(t, 1.0, values[1])
+	assert.Equal(t, map[string]any{
+		"app":   "live",
+		"tcUrl": "rtmp://localhost/live",
+		"fpad":  false,
+	}, values[2])
+	assert.Nil(t, values[3])
+	assert.Nil(t, values[4])
+	assert.Equal(t, map[string]any{"width": 1280.0}, values[5])
+	assert.Equal(t, []any{"a", 2.0}, values[6])
+}
+
+func TestAMF0_LongString(t *testing.T) {
+	long := string(make([]byte, 70000))
+
+	b, err := encodeAMF0(nil, long)
+	require.NoError(t, err)
+	assert.Equal(t, amf0LongString, b[0])
+
+	values, err := decodeAMF0(b)
+	require.NoError(t, err)
+	assert.Equal(t, []any{long}, values)
+}
+
+func TestEncodeAMF0_Unsupported(t *testing.T) {
+	_, err := encodeAMF0(nil, struct{}{})
+	assert.ErrorIs(t, err, errAMFUnsupported)
+}
+
+func TestDecodeAMF0_Errors(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated number":  {amf0Number, 0, 0},
+		"truncated string":  {amf0String, 0, 5, 'a'},
+		"unterminated obj":  {amf0Object, 0, 1, 'a', amf0Null},
+		"bad object end":    {amf0Object, 0, 0, 0x01},
+		"unsupported type":  {0x10},
+		"huge strict array": {amf0StrictArray, 0xff, 0xff, 0xff, 0xff},
+	}
+
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeAMF0(b)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDecodeAMF0_Depth(t *testing.T) {
+	nested := func(depth int) []byte {
+		var b []byte
+		for range depth - 1 {
+			b = append(b, amf0StrictArray, 0, 0, 0, 1)
+		}
+		return append(b, amf0StrictArray, 0, 0, 0, 0)
+	}
+	nestedObjects := func(depth int) []byte {
+		var b []byte
+		for range depth {
+			b = append(b, amf0Object, 0, 1, 'a')
+		}
+		b = append(b, amf0Null)
+		for range depth {
+			b = append(b, 0, 0, amf0ObjectEnd)
+		}
+		return b
+	}
+
+	tests := map[string]struct {
+		b       []byte
+		wantErr error
+	}{
+		"at limit":     {b: nested(maxAMFDepth)},
+		"over limit":   {b: nested(maxAMFDepth + 1), wantErr: errAMFTooDeep},
+		"objects":      {b: nestedObjects(maxAMFDepth)},
+		"deep objects": {b: nestedObjects(maxAMFDepth + 1), wantErr: errAMFTooDeep},
+		"very deep":    {b: nested(1 << 20), wantErr: errAMFTooDeep},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := decodeAMF0(tt.b)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,368 @@
+package rtmp
+
+import (
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// Track names of a broadcast published by the gateway.
+// Every frame is a complete FLV tag (see ParseFLVTag).
+const (
+	VideoTrack    moqt.TrackName = "video"
+	AudioTrack    moqt.TrackName = "audio"
+	MetadataTrack moqt.TrackName = "metadata"
+)
+
+// audioGroupFrames is the number of audio frames per group when the stream has no video.
+const audioGroupFrames = 50
+
+func newBroadcast() *broadcast {
+	return &broadcast{
+		video:    newTrack(),
+		audio:    newTrack(),
+		metadata: newTrack(),
+	}
+}
+
+// broadcast maps the media of one RTMP publish session to tracks.
+//
+// Video groups start at keyframes. Audio groups start together with video
+// groups, or every audioGroupFrames frames when there is no video. Every
+// metadata update is its own group. Video and audio groups begin with the
+// latest codec sequence header so that each group can be decoded on its own.
+type broadcast struct {
+	video    *track
+	audio    *track
+	metadata *track
+
+	videoHeader []byte
+	audioHeader []byte
+	hasVideo    bool
+}
+
+var _ moqt.TrackHandler = (*broadcast)(nil)
+
+func (b *broadcast) ServeTrack(tw *moqt.TrackWriter) {
+	switch tw.TrackName {
+	case VideoTrack:
+		b.video.serve(tw)
+	case AudioTrack:
+		b.audio.serve(tw)
+	case MetadataTrack:
+		b.metadata.serve(tw)
+	default:
+		moqt.NotFound(tw)
+	}
+}
+
+func (b *broadcast) writeVideo(timestamp uint32, data []byte) {
+	tag := appendFLVTag(nil, flvTagVideo, timestamp, data)
+
+	keyframe, header := videoInfo(data)
+	if header {
+		b.videoHeader = tag
+		if b.video.started() {
+			b.video.writeFrame(tag)
+		}
+		return
+	}
+
+	if keyframe {
+		b.hasVideo = true
+
+		b.video.startGroup()
+		if b.videoHeader != nil {
+			b.video.writeFrame(b.videoHeader)
+		}
+
+		if b.audio.started() {
+			b.audio.startGroup()
+			if b.audioHeader != nil {
+				b.audio.writeFrame(b.audioHeader)
+			}
+		}
+	} else if !b.video.started() {
+		// Wait for the first keyframe
+		return
+	}
+
+	b.video.writeFrame(tag)
+}
+
+func (b *broadcast) writeAudio(timestamp uint32, data []byte) {
+	tag := appendFLVTag(nil, flvTagAudio, timestamp, data)
+
+	if audioSequenceHeader(data) {
+		b.audioHeader = tag
+		if b.audio.started() {
+			b.audio.writeFrame(tag)
+		}
+		return
+	}
+
+	if !b.audio.started() || (!b.hasVideo && b.audio.frameCount() >= audioGroupFrames) {
+		b.audio.startGroup()
+		if b.audioHeader != nil {
+			b.audio.writeFrame(b.audioHeader)
+		}
+	}
+
+	b.audio.writeFrame(tag)
+}
+
+func (b *broadcast) writeMetadata(timestamp uint32, data []byte) {
+	b.metadata.startGroup()
+	b.metadata.writeFrame(appendFLVTag(nil, flvTagScriptData, timestamp, data))
+}
+
+func (b *broadcast) close() {
+	b.video.close()
+	b.audio.close()
+	b.metadata.close()
+}
+
+// maxPending is the number of frames queued for a subscriber before
+// the rest of its current group is dropped.
+const maxPending = 256
+
+func newTrack() *track {
+	return &track{
+		subs: make(map[*moqt.TrackWriter]*subscriber),
+		done: make(chan struct{}),
+	}
+}
+
+// track fans the groups of one media type out to the subscribers.
+// The frames of the current group are kept, so a new subscriber
+// starts with the whole current group.
+//
+// Frames are queued for every subscriber and written by the goroutine
+// serving it, so a slow subscriber does not hold up the others or the
+// RTMP connection. A subscriber whose queue is full loses the rest of
+// its current group, which is canceled, and resumes at the next group.
+type track struct {
+	mu sync.Mutex
+
+	seq    moqt.GroupSequence
+	frames [][]byte
+	open   bool
+
+	subs   map[*moqt.TrackWriter]*subscriber
+	closed bool
+	done   chan struct{}
+}
+
+// pending is an item queued for a subscriber.
+type pending struct {
+	kind  pendingKind
+	seq   moqt.GroupSequence
+	frame []byte
+}
+
+type pendingKind int
+
+const (
+	pendingStart pendingKind = iota
+	pendingFrame
+	pendingCancel
+)
+
+// subscriber is the queue of one subscription.
+// Its fields are guarded by track.mu.
+type subscriber struct {
+	queue []pending
+	// lagging is set when the queue overflowed until the next group starts.
+	lagging bool
+	wake    chan struct{}
+}
+
+// push queues p and wakes the goroutine serving s. The caller must hold t.mu.
+func (s *subscriber) push(p pending) {
+	switch p.kind {
+	case pendingStart:
+		s.lagging = false
+		if len(s.queue) >= maxPending {
+			// Skip the backlog and start over at the new group
+			s.queue = append(s.queue[:0], pending{kind: pendingCancel})
+		}
+	case pendingFrame:
+		if s.lagging {
+			return
+		}
+		if len(s.queue) >= maxPending {
+			s.lagging = true
+			p = pending{kind: pendingCancel}
+		}
+	}
+
+	s.queue = append(s.queue, p)
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (t *track) started() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.open
+}
+
+func (t *track) frameCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.frames)
+}
+
+func (t *track) serve(tw *moqt.TrackWriter) {
+	// Capture the context before Close clears the subscribe stream
+	ctx := tw.Context()
+
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		_ = tw.Close()
+		return
+	}
+
+	sub := &subscriber{wake: make(chan struct{}, 1)}
+	if t.open {
+		// Replay the current group
+		sub.push(pending{kind: pendingStart, seq: t.seq})
+		for _, b := range t.frames {
+			sub.push(pending{kind: pendingFrame, frame: b})
+		}
+	}
+	t.subs[tw] = sub
+	t.mu.Unlock()
+
+	w := &groupSender{tw: tw}
+	defer func() {
+		t.mu.Lock()
+		delete(t.subs, tw)
+		t.mu.Unlock()
+
+		w.close()
+		_ = tw.Close()
+	}()
+
+	for {
+		var done bool
+		select {
+		case <-sub.wake:
+		case <-t.done:
+			// Send what is queued before ending the subscription
+			done = true
+		case <-ctx.Done():
+			return
+		}
+
+		t.mu.Lock()
+		queue := sub.queue
+		sub.queue = nil
+		t.mu.Unlock()
+
+		for _, p := range queue {
+			w.send(p)
+		}
+
+		if done {
+			return
+		}
+	}
+}
+
+// groupSender writes the queued items of one subscriber.
+type groupSender struct {
+	tw    *moqt.TrackWriter
+	gw    *moqt.GroupWriter
+	frame *moqt.Frame
+}
+
+func (w *groupSender) send(p pending) {
+	switch p.kind {
+	case pendingStart:
+		w.close()
+		gw, err := w.tw.OpenGroupAt(p.seq)
+		if err != nil {
+			return
+		}
+		w.gw = gw
+	case pendingFrame:
+		if w.gw == nil {
+			return
+		}
+		if w.frame == nil {
+			w.frame = moqt.NewFrame(len(p.frame))
+		}
+		w.frame.Reset()
+		_, _ = w.frame.Write(p.frame)
+
+		if err := w.gw.WriteFrame(w.frame); err != nil {
+			// The subscriber resumes at the next group
+			w.cancel()
+		}
+	case pendingCancel:
+		w.cancel()
+	}
+}
+
+func (w *groupSender) cancel() {
+	if w.gw != nil {
+		w.gw.CancelWrite(moqt.InternalGroupErrorCode)
+		w.gw = nil
+	}
+}
+
+func (w *groupSender) close() {
+	if w.gw != nil {
+		_ = w.gw.Close()
+		w.gw = nil
+	}
+}
+
+func (t *track) startGroup() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.open {
+		t.seq++
+	}
+	t.open = true
+	t.frames = t.frames[:0]
+
+	for _, sub := range t.subs {
+		sub.push(pending{kind: pendingStart, seq: t.seq})
+	}
+}
+
+func (t *track) writeFrame(b []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.open {
+		return
+	}
+
+	t.frames = append(t.frames, b)
+
+	for _, sub := range t.subs {
+		sub.push(pending{kind: pendingFrame, frame: b})
+	}
+}
+
+func (t *track) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return
+	}
+	t.closed = true
+
+	close(t.done)
+}
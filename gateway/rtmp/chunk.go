@@ -0,0 +1,394 @@
+package rtmp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+// RTMP message type IDs.
+const (
+	typeSetChunkSize     uint8 = 1
+	typeAbort            uint8 = 2
+	typeAcknowledgement  uint8 = 3
+	typeUserControl      uint8 = 4
+	typeWindowAckSize    uint8 = 5
+	typeSetPeerBandwidth uint8 = 6
+	typeAudio            uint8 = 8
+	typeVideo            uint8 = 9
+	typeDataAMF3         uint8 = 15
+	typeCommandAMF3      uint8 = 17
+	typeDataAMF0         uint8 = 18
+	typeCommandAMF0      uint8 = 20
+)
+
+// Chunk stream IDs used for messages sent by the server.
+const (
+	csidControl uint32 = 2
+	csidCommand uint32 = 3
+)
+
+const (
+	defaultChunkSize = 128
+	maxChunkSize     = 1 << 24
+
+	// maxMessageSize bounds the length of a message a peer may send.
+	maxMessageSize = 8 << 20
+
+	// maxChunkStreams bounds the number of chunk streams a peer may open.
+	maxChunkStreams = 64
+
+	handshakeSize = 1536
+	rtmpVersion   = 3
+
+	// extendedTimestamp marks a timestamp carried in the extended timestamp field.
+	extendedTimestamp = 0xffffff
+)
+
+var (
+	errInvalidChunkSize    = errors.New("rtmp: invalid chunk size")
+	errMessageTooLarge     = errors.New("rtmp: message too large")
+	errTooManyChunkStreams = errors.New("rtmp: too many chunk streams")
+)
+
+// message is a complete RTMP message reassembled from chunks.
+type message struct {
+	typeID    uint8
+	streamID  uint32
+	timestamp uint32
+	payload   []byte
+}
+
+// serverHandshake performs the simple (non-digest) RTMP handshake as the server.
+func serverHandshake(rw *bufio.ReadWriter) error {
+	c0c1 := make([]byte, 1+handshakeSize)
+	if _, err := io.ReadFull(rw, c0c1); err != nil {
+		return err
+	}
+	if c0c1[0] != rtmpVersion {
+		return fmt.Errorf("rtmp: unsupported version %d", c0c1[0])
+	}
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	s0s1s2[0] = rtmpVersion
+	s1 := s0s1s2[1 : 1+handshakeSize]
+	binary.BigEndian.PutUint32(s1[0:4], uint32(time.Now().UnixMilli()))
+	_, _ = rand.Read(s1[8:])
+	// S2 echoes C1
+	copy(s0s1s2[1+handshakeSize:], c0c1[1:])
+
+	if _, err := rw.Write(s0s1s2); err != nil {
+		return err
+	}
+	if err := rw.Flush(); err != nil {
+		return err
+	}
+
+	c2 := make([]byte, handshakeSize)
+	_, err := io.ReadFull(rw, c2)
+	return err
+}
+
+// chunkStream is the header state of one chunk stream.
+type chunkStream struct {
+	timestamp uint32
+	delta     uint32
+	length    uint32
+	typeID    uint8
+	streamID  uint32
+	extended  bool
+
+	payload []byte
+}
+
+// chunkReader reassembles messages from the chunks of an RTMP connection.
+type chunkReader struct {
+	r         *bufio.Reader
+	chunkSize uint32
+	streams   map[uint32]*chunkStream
+
+	// bytesRead counts the bytes read for acknowledgements.
+	bytesRead uint64
+}
+
+func newChunkReader(r *bufio.Reader) *chunkReader {
+	return &chunkReader{
+		r:         r,
+		chunkSize: defaultChunkSize,
+		streams:   make(map[uint32]*chunkStream),
+	}
+}
+
+func (cr *chunkReader) setChunkSize(size uint32) error {
+	// The most significant bit must be zero
+	if size == 0 || size > maxChunkSize {
+		return errInvalidChunkSize
+	}
+	cr.chunkSize = size
+	return nil
+}
+
+// abort discards the partially received message of a chunk stream.
+func (cr *chunkReader) abort(csid uint32) {
+	if cs, ok := cr.streams[csid]; ok {
+		cs.payload = nil
+	}
+}
+
+func (cr *chunkReader) read(p []byte) error {
+	n, err := io.ReadFull(cr.r, p)
+	cr.bytesRead += uint64(n)
+	return err
+}
+
+func (cr *chunkReader) readUint(n int) (uint32, error) {
+	var buf [4]byte
+	if err := cr.read(buf[4-n:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(buf[:]), nil
+}
+
+// readMessage reads chunks until a message is complete.
+func (cr *chunkReader) readMessage() (*message, error) {
+	for {
+		msg, err := cr.readChunk()
+		if err != nil {
+			return nil, err
+		}
+		if msg != nil {
+			return msg, nil
+		}
+	}
+}
+
+func (cr *chunkReader) readChunk() (*message, error) {
+	var b [1]byte
+	if err := cr.read(b[:]); err != nil {
+		return nil, err
+	}
+
+	format := b[0] >> 6
+	csid := uint32(b[0] & 0x3f)
+	switch csid {
+	case 0:
+		v, err := cr.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		csid = 64 + v
+	case 1:
+		var p [2]byte
+		if err := cr.read(p[:]); err != nil {
+			return nil, err
+		}
+		csid = 64 + uint32(p[0]) + uint32(p[1])*256
+	}
+
+	cs, ok := cr.streams[csid]
+	if !ok {
+		if format != 0 {
+			return nil, fmt.Errorf("rtmp: chunk stream %d starts with format %d", csid, format)
+		}
+		if len(cr.streams) >= maxChunkStreams {
+			return nil, errTooManyChunkStreams
+		}
+		cs = &chunkStream{}
+		cr.streams[csid] = cs
+	}
+
+	starting := len(cs.payload) == 0
+
+	switch format {
+	case 0:
+		ts, err := cr.readUint(3)
+		if err != nil {
+			return nil, err
+		}
+		if cs.length, err = cr.readUint(3); err != nil {
+			return nil, err
+		}
+		typeID, err := cr.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		cs.typeID = uint8(typeID)
+
+		var sid [4]byte
+		if err := cr.read(sid[:]); err != nil {
+			return nil, err
+		}
+		cs.streamID = binary.LittleEndian.Uint32(sid[:])
+
+		cs.extended = ts == extendedTimestamp
+		if cs.extended {
+			if ts, err = cr.readUint(4); err != nil {
+				return nil, err
+			}
+		}
+		cs.timestamp = ts
+		cs.delta = 0
+	case 1, 2:
+		delta, err := cr.readUint(3)
+		if err != nil {
+			return nil, err
+		}
+		if format == 1 {
+			if cs.length, err = cr.readUint(3); err != nil {
+				return nil, err
+			}
+			typeID, err := cr.readUint(1)
+			if err != nil {
+				return nil, err
+			}
+			cs.typeID = uint8(typeID)
+		}
+
+		cs.extended = delta == extendedTimestamp
+		if cs.extended {
+			if delta, err = cr.readUint(4); err != nil {
+				return nil, err
+			}
+		}
+		cs.delta = delta
+		cs.timestamp += delta
+	case 3:
+		if cs.extended {
+			// The extended timestamp is repeated in continuation chunks
+			if _, err := cr.readUint(4); err != nil {
+				return nil, err
+			}
+		}
+		if starting {
+			cs.timestamp += cs.delta
+		}
+	}
+
+	if cs.length > maxMessageSize {
+		return nil, fmt.Errorf("%w: %d bytes", errMessageTooLarge, cs.length)
+	}
+	if uint32(len(cs.payload)) > cs.length {
+		return nil, fmt.Errorf("rtmp: chunk stream %d shortened a message in progress", csid)
+	}
+
+	// The payload grows as chunks arrive rather than by the announced length
+	if starting {
+		cs.payload = make([]byte, 0, min(cs.length, cr.chunkSize))
+	}
+
+	n := min(cr.chunkSize, cs.length-uint32(len(cs.payload)))
+	start := len(cs.payload)
+	cs.payload = slices.Grow(cs.payload, int(n))[:start+int(n)]
+	if err := cr.read(cs.payload[start:]); err != nil {
+		return nil, err
+	}
+
+	if uint32(len(cs.payload)) < cs.length {
+		return nil, nil
+	}
+
+	msg := &message{
+		typeID:    cs.typeID,
+		streamID:  cs.streamID,
+		timestamp: cs.timestamp,
+		payload:   cs.payload,
+	}
+	cs.payload = nil
+
+	return msg, nil
+}
+
+// chunkWriter splits messages into chunks.
+type chunkWriter struct {
+	w         *bufio.Writer
+	chunkSize uint32
+}
+
+func newChunkWriter(w *bufio.Writer) *chunkWriter {
+	return &chunkWriter{
+		w:         w,
+		chunkSize: defaultChunkSize,
+	}
+}
+
+// writeMessage writes a message on a chunk stream with an ID below 64 and flushes it.
+func (cw *chunkWriter) writeMessage(csid uint32, msg *message) error {
+	ts := msg.timestamp
+	extended := ts >= extendedTimestamp
+	if extended {
+		ts = extendedTimestamp
+	}
+
+	var header [16]byte
+	header[0] = byte(csid & 0x3f)
+	header[1], header[2], header[3] = byte(ts>>16), byte(ts>>8), byte(ts)
+	l := len(msg.payload)
+	header[4], header[5], header[6] = byte(l>>16), byte(l>>8), byte(l)
+	header[7] = msg.typeID
+	binary.LittleEndian.PutUint32(header[8:12], msg.streamID)
+	n := 12
+	if extended {
+		binary.BigEndian.PutUint32(header[12:16], msg.timestamp)
+		n = 16
+	}
+
+	if _, err := cw.w.Write(header[:n]); err != nil {
+		return err
+	}
+
+	payload := msg.payload
+	for {
+		size := min(len(payload), int(cw.chunkSize))
+		if _, err := cw.w.Write(payload[:size]); err != nil {
+			return err
+		}
+		payload = payload[size:]
+		if len(payload) == 0 {
+			break
+		}
+
+		// Continuation chunk
+		cont := [5]byte{0xc0 | byte(csid&0x3f)}
+		m := 1
+		if extended {
+			binary.BigEndian.PutUint32(cont[1:], msg.timestamp)
+			m = 5
+		}
+		if _, err := cw.w.Write(cont[:m]); err != nil {
+			return err
+		}
+	}
+
+	return cw.w.Flush()
+}
+
+func (cw *chunkWriter) writeControl(typeID uint8, payload []byte) error {
+	return cw.writeMessage(csidControl, &message{typeID: typeID, payload: payload})
+}
+
+func (cw *chunkWriter) setChunkSize(size uint32) error {
+	err := cw.writeControl(typeSetChunkSize, binary.BigEndian.AppendUint32(nil, size))
+	if err != nil {
+		return err
+	}
+	cw.chunkSize = size
+	return nil
+}
+
+// writeCommand writes an AMF0 command message.
+func (cw *chunkWriter) writeCommand(streamID uint32, values ...any) error {
+	payload, err := encodeAMF0(nil, values...)
+	if err != nil {
+		return err
+	}
+	return cw.writeMessage(csidCommand, &message{
+		typeID:   typeCommandAMF0,
+		streamID: streamID,
+		payload:  payload,
+	})
+}
@@ -0,0 +1,191 @@
+package rtmp
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunk_RoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		chunkSize uint32
+		msg       *message
+	}{
+		"single chunk": {
+			chunkSize: defaultChunkSize,
+			msg:       &message{typeID: typeVideo, streamID: 1, timestamp: 40, payload: []byte("frame")},
+		},
+		"continuation chunks": {
+			chunkSize: 16,
+			msg:       &message{typeID: typeAudio, streamID: 1, timestamp: 1000, payload: bytes.Repeat([]byte{0xab}, 100)},
+		},
+		"extended timestamp": {
+			chunkSize: 16,
+			msg:       &message{typeID: typeVideo, streamID: 1, timestamp: 0x01000000, payload: bytes.Repeat([]byte{0xcd}, 50)},
+		},
+		"empty payload": {
+			chunkSize: defaultChunkSize,
+			msg:       &message{typeID: typeDataAMF0, payload: []byte{}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			cw := newChunkWriter(bufio.NewWriter(&buf))
+			cw.chunkSize = tt.chunkSize
+			require.NoError(t, cw.writeMessage(csidCommand, tt.msg))
+
+			written := buf.Len()
+
+			cr := newChunkReader(bufio.NewReader(&buf))
+			require.NoError(t, cr.setChunkSize(tt.chunkSize))
+
+			msg, err := cr.readMessage()
+			require.NoError(t, err)
+			assert.Equal(t, tt.msg.typeID, msg.typeID)
+			assert.Equal(t, tt.msg.streamID, msg.streamID)
+			assert.Equal(t, tt.msg.timestamp, msg.timestamp)
+			assert.Equal(t, tt.msg.payload, msg.payload)
+			assert.Equal(t, uint64(written), cr.bytesRead)
+		})
+	}
+}
+
+func TestChunkReader_CompressedHeaders(t *testing.T) {
+	var b []byte
+	// Type 0: timestamp 100, length 2, video, stream 1
+	b = append(b, 0x04, 0, 0, 100, 0, 0, 2, typeVideo, 1, 0, 0, 0, 'a', 'b')
+	// Type 2: delta 40
+	b = append(b, 0x84, 0, 0, 40, 'c', 'd')
+	// Type 3: the same delta again
+	b = append(b, 0xc4, 'e', 'f')
+	// Type 1: delta 10, length 1, audio
+	b = append(b, 0x44, 0, 0, 10, 0, 0, 1, typeAudio, 'g')
+
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader(b)))
+
+	want := []message{
+		{typeID: typeVideo, streamID: 1, timestamp: 100, payload: []byte("ab")},
+		{typeID: typeVideo, streamID: 1, timestamp: 140, payload: []byte("cd")},
+		{typeID: typeVideo, streamID: 1, timestamp: 180, payload: []byte("ef")},
+		{typeID: typeAudio, streamID: 1, timestamp: 190, payload: []byte("g")},
+	}
+
+	for _, w := range want {
+		msg, err := cr.readMessage()
+		require.NoError(t, err)
+		assert.Equal(t, w, *msg)
+	}
+}
+
+func TestChunkReader_FirstChunkNotType0(t *testing.T) {
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader([]byte{0xc4, 'a'})))
+
+	_, err := cr.readMessage()
+	assert.Error(t, err)
+}
+
+func TestChunkReader_Limits(t *testing.T) {
+	// Type 0 headers on chunk stream csid announcing a message of length n
+	header := func(csid byte, n uint32) []byte {
+		return []byte{csid, 0, 0, 0, byte(n >> 16), byte(n >> 8), byte(n), typeVideo, 1, 0, 0, 0}
+	}
+
+	tests := map[string]struct {
+		b       []byte
+		wantErr error
+	}{
+		"message too large": {
+			b:       header(4, maxMessageSize+1),
+			wantErr: errMessageTooLarge,
+		},
+		"too many chunk streams": {
+			b: func() []byte {
+				var b []byte
+				for i := range maxChunkStreams + 1 {
+					// Two-byte chunk stream IDs from 64
+					b = append(b, 0x00, byte(i))
+					b = append(b, header(0, 1)[1:]...)
+					b = append(b, 'a')
+				}
+				return b
+			}(),
+			wantErr: errTooManyChunkStreams,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cr := newChunkReader(bufio.NewReader(bytes.NewReader(tt.b)))
+
+			var err error
+			for err == nil {
+				_, err = cr.readMessage()
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestChunkReader_GrowsWithPayload(t *testing.T) {
+	// A type 0 header announcing the maximum length, with a single chunk sent
+	n := uint32(maxMessageSize)
+	b := []byte{0x04, 0, 0, 0, byte(n >> 16), byte(n >> 8), byte(n), typeVideo, 1, 0, 0, 0}
+	b = append(b, bytes.Repeat([]byte{'a'}, defaultChunkSize)...)
+
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader(b)))
+
+	msg, err := cr.readChunk()
+	require.NoError(t, err)
+	assert.Nil(t, msg)
+	assert.Less(t, cap(cr.streams[4].payload), 4*defaultChunkSize)
+}
+
+func TestChunkReader_SetChunkSize(t *testing.T) {
+	cr := newChunkReader(bufio.NewReader(bytes.NewReader(nil)))
+
+	assert.ErrorIs(t, cr.setChunkSize(0), errInvalidChunkSize)
+	assert.ErrorIs(t, cr.setChunkSize(maxChunkSize+1), errInvalidChunkSize)
+	assert.NoError(t, cr.setChunkSize(4096))
+	assert.Equal(t, uint32(4096), cr.chunkSize)
+}
+
+func TestServerHandshake(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- serverHandshake(bufio.NewReadWriter(bufio.NewReader(server), bufio.NewWriter(server)))
+	}()
+
+	c1 := bytes.Repeat([]byte{0x5a}, handshakeSize)
+	_, err := client.Write(append([]byte{rtmpVersion}, c1...))
+	require.NoError(t, err)
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	_, err = io.ReadFull(client, s0s1s2)
+	require.NoError(t, err)
+	assert.Equal(t, byte(rtmpVersion), s0s1s2[0])
+	assert.Equal(t, c1, s0s1s2[1+handshakeSize:], "S2 must echo C1")
+
+	_, err = client.Write(s0s1s2[1 : 1+handshakeSize])
+	require.NoError(t, err)
+
+	require.NoError(t, <-errCh)
+}
+
+func TestServerHandshake_UnsupportedVersion(t *testing.T) {
+	b := make([]byte, 1+handshakeSize)
+	b[0] = 6 // Encrypted RTMP
+
+	rw := bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(b)), bufio.NewWriter(&bytes.Buffer{}))
+	assert.Error(t, serverHandshake(rw))
+}
@@ -0,0 +1,277 @@
+package rtmp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+const (
+	// windowAckSize is the acknowledgement window requested from the peer.
+	windowAckSize = 2500000
+
+	// outChunkSize is the chunk size used for messages sent by the server.
+	outChunkSize = 4096
+
+	// publishStreamID is the message stream ID returned by createStream.
+	publishStreamID = 1
+)
+
+// eventStreamBegin is the user control event sent when a stream starts.
+const eventStreamBegin uint16 = 0
+
+// conn is a server-side RTMP connection.
+type conn struct {
+	server *Server
+	nc     net.Conn
+	logger *slog.Logger
+
+	cr *chunkReader
+	cw *chunkWriter
+
+	app string
+
+	// peerWindow is the acknowledgement window size set by the peer.
+	peerWindow uint32
+	acked      uint64
+
+	// Publishing state
+	path      moqt.BroadcastPath
+	broadcast *broadcast
+	end       moqt.EndAnnouncementFunc
+}
+
+func (c *conn) serve() error {
+	defer c.nc.Close()
+	defer c.unpublish()
+
+	rw := bufio.NewReadWriter(bufio.NewReader(c.nc), bufio.NewWriter(c.nc))
+
+	// The deadline covers the handshake and the connect command
+	_ = c.nc.SetDeadline(time.Now().Add(c.server.handshakeTimeout()))
+
+	if err := serverHandshake(rw); err != nil {
+		return fmt.Errorf("rtmp: handshake: %w", err)
+	}
+
+	c.cr = newChunkReader(rw.Reader)
+	c.cw = newChunkWriter(rw.Writer)
+
+	for {
+		msg, err := c.cr.readMessage()
+		if err != nil {
+			return err
+		}
+
+		if err := c.handleMessage(msg); err != nil {
+			return err
+		}
+
+		if err := c.acknowledge(); err != nil {
+			return err
+		}
+	}
+}
+
+// acknowledge sends an acknowledgement when the peer's window has been received.
+func (c *conn) acknowledge() error {
+	if c.peerWindow == 0 || c.cr.bytesRead-c.acked < uint64(c.peerWindow) {
+		return nil
+	}
+	c.acked = c.cr.bytesRead
+
+	return c.cw.writeControl(typeAcknowledgement, binary.BigEndian.AppendUint32(nil, uint32(c.acked)))
+}
+
+func (c *conn) handleMessage(msg *message) error {
+	switch msg.typeID {
+	case typeSetChunkSize:
+		if len(msg.payload) < 4 {
+			return errors.New("rtmp: short set chunk size message")
+		}
+		return c.cr.setChunkSize(binary.BigEndian.Uint32(msg.payload))
+	case typeAbort:
+		if len(msg.payload) < 4 {
+			return errors.New("rtmp: short abort message")
+		}
+		c.cr.abort(binary.BigEndian.Uint32(msg.payload))
+	case typeWindowAckSize:
+		if len(msg.payload) < 4 {
+			return errors.New("rtmp: short window acknowledgement size message")
+		}
+		c.peerWindow = binary.BigEndian.Uint32(msg.payload)
+	case typeCommandAMF0:
+		return c.handleCommand(msg.payload)
+	case typeCommandAMF3:
+		// AMF3 command messages start with a format byte followed by AMF0 values
+		if len(msg.payload) < 1 {
+			return nil
+		}
+		return c.handleCommand(msg.payload[1:])
+	case typeDataAMF0:
+		if c.broadcast != nil {
+			c.broadcast.writeMetadata(msg.timestamp, scriptData(msg.payload))
+		}
+	case typeDataAMF3:
+		if c.broadcast != nil && len(msg.payload) > 1 {
+			c.broadcast.writeMetadata(msg.timestamp, scriptData(msg.payload[1:]))
+		}
+	case typeVideo:
+		if c.broadcast != nil {
+			c.broadcast.writeVideo(msg.timestamp, msg.payload)
+		}
+	case typeAudio:
+		if c.broadcast != nil {
+			c.broadcast.writeAudio(msg.timestamp, msg.payload)
+		}
+	}
+
+	return nil
+}
+
+func (c *conn) handleCommand(payload []byte) error {
+	values, err := decodeAMF0(payload)
+	if err != nil {
+		return fmt.Errorf("rtmp: invalid command: %w", err)
+	}
+	if len(values) < 2 {
+		return errors.New("rtmp: invalid command")
+	}
+
+	name, _ := values[0].(string)
+	txn, _ := values[1].(float64)
+	args := values[2:]
+
+	c.logger.Debug("received command", "name", name)
+
+	switch name {
+	case "connect":
+		return c.onConnect(txn, args)
+	case "releaseStream", "FCPublish":
+		return c.cw.writeCommand(0, "_result", txn, nil, amfUndefined{})
+	case "createStream":
+		return c.cw.writeCommand(0, "_result", txn, nil, publishStreamID)
+	case "publish":
+		return c.onPublish(args)
+	case "FCUnpublish", "closeStream", "deleteStream":
+		c.unpublish()
+		return nil
+	case "play":
+		_ = c.onStatus("error", "NetStream.Play.Failed", "Playback is not supported.")
+		return errors.New("rtmp: playback is not supported")
+	default:
+		return nil
+	}
+}
+
+func (c *conn) onConnect(txn float64, args []any) error {
+	if len(args) > 0 {
+		if obj, ok := args[0].(map[string]any); ok {
+			c.app, _ = obj["app"].(string)
+		}
+	}
+
+	if err := c.cw.writeControl(typeWindowAckSize, binary.BigEndian.AppendUint32(nil, windowAckSize)); err != nil {
+		return err
+	}
+	// Dynamic limit type
+	if err := c.cw.writeControl(typeSetPeerBandwidth, append(binary.BigEndian.AppendUint32(nil, windowAckSize), 2)); err != nil {
+		return err
+	}
+	if err := c.cw.setChunkSize(outChunkSize); err != nil {
+		return err
+	}
+
+	err := c.cw.writeCommand(0, "_result", txn,
+		amfObject{
+			{"fmsVer", "FMS/3,0,1,123"},
+			{"capabilities", 31},
+		},
+		amfObject{
+			{"level", "status"},
+			{"code", "NetConnection.Connect.Success"},
+			{"description", "Connection succeeded."},
+			{"objectEncoding", 0},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// The handshake is complete; media may arrive at any pace from now on
+	return c.nc.SetDeadline(time.Time{})
+}
+
+func (c *conn) onPublish(args []any) error {
+	if c.broadcast != nil {
+		return errors.New("rtmp: already publishing")
+	}
+
+	var key string
+	if len(args) > 1 {
+		key, _ = args[1].(string)
+	}
+
+	mapPath := c.server.BroadcastPath
+	if mapPath == nil {
+		mapPath = DefaultBroadcastPath
+	}
+
+	path, err := mapPath(c.app, key)
+	if err == nil {
+		err = c.server.reserve(path)
+	}
+	if err != nil {
+		c.logger.Info("rejected publish request", "app", c.app, "error", err)
+		_ = c.onStatus("error", "NetStream.Publish.BadName", err.Error())
+		return err
+	}
+
+	ann, end := moqt.NewAnnouncement(context.Background(), path)
+	c.path = path
+	c.broadcast = newBroadcast()
+	c.end = end
+	c.server.mux().Announce(ann, c.broadcast)
+
+	c.logger.Info("started publishing", "broadcast_path", path)
+
+	event := binary.BigEndian.AppendUint16(nil, eventStreamBegin)
+	event = binary.BigEndian.AppendUint32(event, publishStreamID)
+	if err := c.cw.writeControl(typeUserControl, event); err != nil {
+		return err
+	}
+
+	return c.onStatus("status", "NetStream.Publish.Start", fmt.Sprintf("%s is now published.", path))
+}
+
+func (c *conn) onStatus(level, code, description string) error {
+	return c.cw.writeCommand(publishStreamID, "onStatus", 0, nil, amfObject{
+		{"level", level},
+		{"code", code},
+		{"description", description},
+	})
+}
+
+// unpublish ends the broadcast of the connection, if any.
+func (c *conn) unpublish() {
+	if c.broadcast == nil {
+		return
+	}
+
+	c.end()
+	c.broadcast.close()
+	c.server.release(c.path)
+
+	c.logger.Info("stopped publishing", "broadcast_path", c.path)
+
+	c.broadcast = nil
+	c.end = nil
+	c.path = ""
+}
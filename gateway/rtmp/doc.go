@@ -0,0 +1,27 @@
+// Package rtmp implements an RTMP ingest gateway that publishes into a moqt.TrackMux.
+//
+// Encoders such as OBS or ffmpeg publish to the Server with a stream key.
+// Each publish request is mapped to a broadcast path, "/<stream key>" by
+// default, and announced on the TrackMux for as long as the encoder publishes.
+//
+// A broadcast has three tracks carrying FLV tags (see ParseFLVTag):
+//
+//   - VideoTrack: a group per GOP, starting at each keyframe
+//   - AudioTrack: groups aligned with the video groups
+//   - MetadataTrack: a group per onMetaData update
+//
+// Video and audio groups begin with the codec sequence header, and a new
+// subscriber starts with the current group, so every group is decodable on its own.
+/*
+	mux := moqt.NewTrackMux()
+
+	ingest := &rtmp.Server{
+	    Addr: ":1935",
+	    Mux:  mux,
+	}
+	go ingest.ListenAndServe()
+
+	// ffmpeg -re -i input.mp4 -c copy -f flv rtmp://localhost/live/stream
+	// is now announced on mux as "/stream".
+*/
+package rtmp
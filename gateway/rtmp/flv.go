@@ -0,0 +1,99 @@
+package rtmp
+
+import "encoding/binary"
+
+// FLV tag types, identical to the RTMP message type IDs of the same media.
+const (
+	flvTagAudio      = typeAudio
+	flvTagVideo      = typeVideo
+	flvTagScriptData = typeDataAMF0
+)
+
+const flvTagHeaderSize = 11
+
+// appendFLVTag appends an FLV tag, without the trailing previous tag size, to b.
+func appendFLVTag(b []byte, tagType uint8, timestamp uint32, data []byte) []byte {
+	var header [flvTagHeaderSize]byte
+	header[0] = tagType
+	l := len(data)
+	header[1], header[2], header[3] = byte(l>>16), byte(l>>8), byte(l)
+	header[4], header[5], header[6] = byte(timestamp>>16), byte(timestamp>>8), byte(timestamp)
+	header[7] = byte(timestamp >> 24)
+	// Stream ID is always zero
+
+	b = append(b, header[:]...)
+	return append(b, data...)
+}
+
+// ParseFLVTag splits an FLV tag as carried in the frames of the gateway tracks
+// into its type, timestamp in milliseconds and data.
+func ParseFLVTag(b []byte) (tagType uint8, timestamp uint32, data []byte, ok bool) {
+	if len(b) < flvTagHeaderSize {
+		return 0, 0, nil, false
+	}
+
+	l := int(b[1])<<16 | int(b[2])<<8 | int(b[3])
+	if len(b) != flvTagHeaderSize+l {
+		return 0, 0, nil, false
+	}
+
+	timestamp = uint32(b[4])<<16 | uint32(b[5])<<8 | uint32(b[6]) | uint32(b[7])<<24
+
+	return b[0], timestamp, b[flvTagHeaderSize:], true
+}
+
+// videoInfo reports whether an FLV video tag body is a keyframe or a codec sequence header.
+// Both legacy (AVC, HEVC) and enhanced RTMP headers are recognized.
+func videoInfo(data []byte) (keyframe, sequenceHeader bool) {
+	if len(data) < 1 {
+		return false, false
+	}
+
+	frameType := (data[0] >> 4) & 0x07
+	keyframe = frameType == 1
+
+	if data[0]&0x80 != 0 {
+		// Enhanced RTMP: the low nibble is the packet type, 0 is SequenceStart
+		return keyframe, data[0]&0x0f == 0
+	}
+
+	codecID := data[0] & 0x0f
+	switch codecID {
+	case 7, 12: // AVC, HEVC
+		return keyframe, len(data) > 1 && data[1] == 0
+	default:
+		return keyframe, false
+	}
+}
+
+// audioSequenceHeader reports whether an FLV audio tag body is a codec sequence header.
+func audioSequenceHeader(data []byte) bool {
+	if len(data) < 1 {
+		return false
+	}
+
+	switch data[0] >> 4 {
+	case 10: // AAC: the second byte is the AAC packet type, 0 is the sequence header
+		return len(data) > 1 && data[1] == 0
+	case 9: // Enhanced RTMP: the low nibble is the packet type, 0 is SequenceStart
+		return data[0]&0x0f == 0
+	default:
+		return false
+	}
+}
+
+// scriptData strips the "@setDataFrame" marker that encoders put before "onMetaData".
+func scriptData(payload []byte) []byte {
+	const marker = "@setDataFrame"
+
+	if len(payload) < 3 || payload[0] != amf0String {
+		return payload
+	}
+
+	l := int(binary.BigEndian.Uint16(payload[1:3]))
+	if len(payload) < 3+l || string(payload[3:3+l]) != marker {
+		return payload
+	}
+
+	return payload[3+l:]
+}
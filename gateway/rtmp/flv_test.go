@@ -0,0 +1,72 @@
+package rtmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFLVTag_RoundTrip(t *testing.T) {
+	tag := appendFLVTag(nil, flvTagVideo, 0x12345678, []byte("data"))
+	assert.Len(t, tag, flvTagHeaderSize+4)
+
+	tagType, timestamp, data, ok := ParseFLVTag(tag)
+	assert.True(t, ok)
+	assert.Equal(t, flvTagVideo, tagType)
+	assert.Equal(t, uint32(0x12345678), timestamp)
+	assert.Equal(t, []byte("data"), data)
+}
+
+func TestParseFLVTag_Invalid(t *testing.T) {
+	_, _, _, ok := ParseFLVTag([]byte{flvTagVideo, 0, 0})
+	assert.False(t, ok)
+
+	tag := appendFLVTag(nil, flvTagAudio, 0, []byte("data"))
+	_, _, _, ok = ParseFLVTag(tag[:len(tag)-1])
+	assert.False(t, ok)
+}
+
+func TestVideoInfo(t *testing.T) {
+	tests := map[string]struct {
+		data           []byte
+		keyframe       bool
+		sequenceHeader bool
+	}{
+		"empty":                {data: nil},
+		"avc sequence header":  {data: []byte{0x17, 0x00}, keyframe: true, sequenceHeader: true},
+		"avc keyframe":         {data: []byte{0x17, 0x01}, keyframe: true},
+		"avc inter frame":      {data: []byte{0x27, 0x01}},
+		"hevc sequence header": {data: []byte{0x1c, 0x00}, keyframe: true, sequenceHeader: true},
+		"vp6 keyframe":         {data: []byte{0x14, 0x00}, keyframe: true},
+		"enhanced sequence":    {data: []byte{0x90, 'a', 'v', '0', '1'}, keyframe: true, sequenceHeader: true},
+		"enhanced keyframe":    {data: []byte{0x91, 'a', 'v', '0', '1'}, keyframe: true},
+		"enhanced inter frame": {data: []byte{0xa1, 'a', 'v', '0', '1'}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			keyframe, sequenceHeader := videoInfo(tt.data)
+			assert.Equal(t, tt.keyframe, keyframe)
+			assert.Equal(t, tt.sequenceHeader, sequenceHeader)
+		})
+	}
+}
+
+func TestAudioSequenceHeader(t *testing.T) {
+	assert.True(t, audioSequenceHeader([]byte{0xaf, 0x00}))
+	assert.False(t, audioSequenceHeader([]byte{0xaf, 0x01}))
+	assert.True(t, audioSequenceHeader([]byte{0x90, 'O', 'p', 'u', 's'}))
+	assert.False(t, audioSequenceHeader([]byte{0x91, 'O', 'p', 'u', 's'}))
+	assert.False(t, audioSequenceHeader([]byte{0x2f}))
+	assert.False(t, audioSequenceHeader(nil))
+}
+
+func TestScriptData(t *testing.T) {
+	meta, _ := encodeAMF0(nil, "onMetaData", map[string]any{"width": 1280.0})
+	withMarker, _ := encodeAMF0(nil, "@setDataFrame")
+	withMarker = append(withMarker, meta...)
+
+	assert.Equal(t, meta, scriptData(withMarker))
+	assert.Equal(t, meta, scriptData(meta))
+	assert.Equal(t, []byte{0x01}, scriptData([]byte{0x01}))
+}
@@ -0,0 +1,214 @@
+package rtmp
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close is called.
+var ErrServerClosed = errors.New("rtmp: server closed")
+
+// DefaultBroadcastPath maps a stream key to "/<stream key>".
+// Query parameters appended to the stream key are removed.
+func DefaultBroadcastPath(app, streamKey string) (moqt.BroadcastPath, error) {
+	key, _, _ := strings.Cut(streamKey, "?")
+	if key == "" {
+		return "", errors.New("rtmp: empty stream key")
+	}
+	return moqt.BroadcastPath("/" + key), nil
+}
+
+// Server accepts RTMP publishers and announces each published stream
+// as a broadcast on a TrackMux.
+//
+// A broadcast has the tracks VideoTrack, AudioTrack and MetadataTrack,
+// and ends when the publisher stops publishing or disconnects.
+type Server struct {
+	// Addr is the TCP address to listen on, ":1935" if empty.
+	Addr string
+
+	// Mux receives the broadcasts. If nil, moqt.DefaultMux is used.
+	Mux *moqt.TrackMux
+
+	// BroadcastPath maps the application name and stream key of a publish
+	// request to a broadcast path. Returning an error rejects the request.
+	// If nil, DefaultBroadcastPath is used.
+	BroadcastPath func(app, streamKey string) (moqt.BroadcastPath, error)
+
+	// HandshakeTimeout bounds the RTMP handshake and connect command. Zero means 10 seconds.
+	HandshakeTimeout time.Duration
+
+	Logger *slog.Logger
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[*conn]struct{}
+	active    map[moqt.BroadcastPath]struct{}
+	wg        sync.WaitGroup
+
+	inShutdown atomic.Bool
+}
+
+func (s *Server) init() {
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+		s.conns = make(map[*conn]struct{})
+		s.active = make(map[moqt.BroadcastPath]struct{})
+	}
+}
+
+func (s *Server) mux() *moqt.TrackMux {
+	if s.Mux != nil {
+		return s.Mux
+	}
+	return moqt.DefaultMux
+}
+
+func (s *Server) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
+func (s *Server) handshakeTimeout() time.Duration {
+	if s.HandshakeTimeout > 0 {
+		return s.HandshakeTimeout
+	}
+	return 10 * time.Second
+}
+
+// ListenAndServe listens on s.Addr and serves RTMP connections.
+func (s *Server) ListenAndServe() error {
+	if s.inShutdown.Load() {
+		return ErrServerClosed
+	}
+
+	addr := s.Addr
+	if addr == "" {
+		addr = ":1935"
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on the listener until it fails or the server is closed.
+// The listener is closed when Serve returns.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.inShutdown.Load() {
+		s.mu.Unlock()
+		ln.Close()
+		return ErrServerClosed
+	}
+	s.init()
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ln)
+		s.mu.Unlock()
+		ln.Close()
+	}()
+
+	logger := s.logger().With("listener_address", ln.Addr())
+	logger.Info("serving RTMP")
+
+	for {
+		nc, err := ln.Accept()
+		if err != nil {
+			if s.inShutdown.Load() {
+				return ErrServerClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		c := &conn{
+			server: s,
+			nc:     nc,
+			logger: logger.With("remote_address", nc.RemoteAddr()),
+		}
+
+		s.mu.Lock()
+		if s.inShutdown.Load() {
+			s.mu.Unlock()
+			nc.Close()
+			return ErrServerClosed
+		}
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, c)
+				s.mu.Unlock()
+			}()
+
+			if err := c.serve(); err != nil {
+				c.logger.Debug("connection closed", "error", err)
+			}
+		}()
+	}
+}
+
+// Close closes the listeners and all connections, ending their broadcasts.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.inShutdown.Swap(true) {
+		s.mu.Unlock()
+		return ErrServerClosed
+	}
+	s.init()
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for c := range s.conns {
+		c.nc.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// reserve marks the broadcast path as published.
+func (s *Server) reserve(path moqt.BroadcastPath) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.active[path]; ok {
+		return fmt.Errorf("rtmp: %s is already being published", path)
+	}
+	s.active[path] = struct{}{}
+
+	return nil
+}
+
+func (s *Server) release(path moqt.BroadcastPath) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.active, path)
+}
@@ -0,0 +1,305 @@
+package rtmp
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/moqt/moqttest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultBroadcastPath(t *testing.T) {
+	path, err := DefaultBroadcastPath("live", "stream")
+	require.NoError(t, err)
+	assert.Equal(t, moqt.BroadcastPath("/stream"), path)
+
+	path, err = DefaultBroadcastPath("live", "stream?token=secret")
+	require.NoError(t, err)
+	assert.Equal(t, moqt.BroadcastPath("/stream"), path)
+
+	_, err = DefaultBroadcastPath("live", "")
+	assert.Error(t, err)
+}
+
+func TestServer_Publish(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	addr := startRTMPServer(t, &Server{Mux: mux})
+
+	pub := dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.Start", pub.publish(t, "stream"))
+
+	videoHeader := []byte{0x17, 0x00, 0, 0, 0, 'h'}
+	audioHeader := []byte{0xaf, 0x00, 0x12, 0x10}
+	metadata, _ := encodeAMF0(nil, "onMetaData", map[string]any{"width": 1280.0})
+	setDataFrame, _ := encodeAMF0(nil, "@setDataFrame")
+
+	pub.send(t, typeDataAMF0, 0, append(setDataFrame, metadata...))
+	pub.send(t, typeVideo, 0, videoHeader)
+	pub.send(t, typeAudio, 0, audioHeader)
+	pub.send(t, typeVideo, 0, []byte{0x17, 0x01, 0, 0, 0, 'k', '0'})
+	pub.send(t, typeAudio, 10, []byte{0xaf, 0x01, 'a', '0'})
+	pub.send(t, typeVideo, 33, []byte{0x27, 0x01, 0, 0, 0, 'p', '0'})
+
+	sess := dialMOQT(t, mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// A late subscriber starts with the current group
+	video, err := sess.Subscribe("/stream", VideoTrack, nil)
+	require.NoError(t, err)
+
+	gr, err := video.AcceptGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, moqt.GroupSequence(0), gr.GroupSequence())
+	assert.Equal(t, []tag{
+		{flvTagVideo, 0, videoHeader},
+		{flvTagVideo, 0, []byte{0x17, 0x01, 0, 0, 0, 'k', '0'}},
+		{flvTagVideo, 33, []byte{0x27, 0x01, 0, 0, 0, 'p', '0'}},
+	}, readTags(t, gr, 3))
+
+	// The next keyframe starts a new group beginning with the sequence header
+	pub.send(t, typeVideo, 66, []byte{0x17, 0x01, 0, 0, 0, 'k', '1'})
+
+	gr, err = video.AcceptGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, moqt.GroupSequence(1), gr.GroupSequence())
+	assert.Equal(t, []tag{
+		{flvTagVideo, 0, videoHeader},
+		{flvTagVideo, 66, []byte{0x17, 0x01, 0, 0, 0, 'k', '1'}},
+	}, readTags(t, gr, 2))
+
+	audio, err := sess.Subscribe("/stream", AudioTrack, nil)
+	require.NoError(t, err)
+
+	gr, err = audio.AcceptGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []tag{
+		{flvTagAudio, 0, audioHeader},
+	}, readTags(t, gr, 1))
+
+	meta, err := sess.Subscribe("/stream", MetadataTrack, nil)
+	require.NoError(t, err)
+
+	gr, err = meta.AcceptGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []tag{
+		{flvTagScriptData, 0, metadata},
+	}, readTags(t, gr, 1))
+}
+
+func TestServer_PublishDuplicateKey(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	addr := startRTMPServer(t, &Server{Mux: mux})
+
+	first := dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.Start", first.publish(t, "stream"))
+
+	second := dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.BadName", second.publish(t, "stream"))
+}
+
+func TestServer_PublishRejected(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	addr := startRTMPServer(t, &Server{
+		Mux: mux,
+		BroadcastPath: func(app, streamKey string) (moqt.BroadcastPath, error) {
+			if streamKey != "secret" {
+				return "", errors.New("invalid stream key")
+			}
+			return moqt.BroadcastPath("/" + app + "/camera"), nil
+		},
+	})
+
+	pub := dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.BadName", pub.publish(t, "guess"))
+
+	pub = dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.Start", pub.publish(t, "secret"))
+
+	ann, _ := mux.TrackHandler("/live/camera")
+	require.NotNil(t, ann)
+	assert.True(t, ann.IsActive())
+}
+
+func TestServer_DisconnectEndsBroadcast(t *testing.T) {
+	mux := moqt.NewTrackMux()
+	addr := startRTMPServer(t, &Server{Mux: mux})
+
+	pub := dialRTMP(t, addr, "live")
+	require.Equal(t, "NetStream.Publish.Start", pub.publish(t, "stream"))
+
+	ann, _ := mux.TrackHandler("/stream")
+	require.NotNil(t, ann)
+
+	pub.nc.Close()
+
+	select {
+	case <-ann.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast did not end after the publisher disconnected")
+	}
+
+	// The stream key can be published again
+	pub = dialRTMP(t, addr, "live")
+	assert.Equal(t, "NetStream.Publish.Start", pub.publish(t, "stream"))
+}
+
+func TestServer_Close(t *testing.T) {
+	s := &Server{Mux: moqt.NewTrackMux()}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Serve(ln) }()
+
+	pub := dialRTMP(t, ln.Addr().String(), "live")
+	require.Equal(t, "NetStream.Publish.Start", pub.publish(t, "stream"))
+
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, <-errCh, ErrServerClosed)
+	assert.ErrorIs(t, s.Close(), ErrServerClosed)
+	assert.ErrorIs(t, s.Serve(ln), ErrServerClosed)
+}
+
+type tag struct {
+	tagType   uint8
+	timestamp uint32
+	data      []byte
+}
+
+func readTags(t *testing.T, gr *moqt.GroupReader, n int) []tag {
+	t.Helper()
+
+	var tags []tag
+	frame := moqt.NewFrame(0)
+	for range n {
+		require.NoError(t, gr.ReadFrame(frame))
+
+		tagType, timestamp, data, ok := ParseFLVTag(frame.Body())
+		require.True(t, ok)
+		tags = append(tags, tag{tagType, timestamp, append([]byte(nil), data...)})
+	}
+
+	return tags
+}
+
+func startRTMPServer(t *testing.T, s *Server) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() { _ = s.Serve(ln) }()
+	t.Cleanup(func() { _ = s.Close() })
+
+	return ln.Addr().String()
+}
+
+// dialMOQT connects a MOQ session in memory to a server serving mux.
+func dialMOQT(t *testing.T, mux *moqt.TrackMux) *moqt.Session {
+	t.Helper()
+
+	return moqttest.Connect(t, nil, mux).Client
+}
+
+// testPublisher is a minimal RTMP publishing client.
+type testPublisher struct {
+	nc net.Conn
+	cr *chunkReader
+	cw *chunkWriter
+}
+
+func dialRTMP(t *testing.T, addr, app string) *testPublisher {
+	t.Helper()
+
+	nc, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { nc.Close() })
+	require.NoError(t, nc.SetDeadline(time.Now().Add(5*time.Second)))
+
+	rw := bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc))
+
+	c0c1 := make([]byte, 1+handshakeSize)
+	c0c1[0] = rtmpVersion
+	_, err = rw.Write(c0c1)
+	require.NoError(t, err)
+	require.NoError(t, rw.Flush())
+
+	s0s1s2 := make([]byte, 1+2*handshakeSize)
+	_, err = io.ReadFull(rw, s0s1s2)
+	require.NoError(t, err)
+
+	_, err = rw.Write(s0s1s2[1 : 1+handshakeSize])
+	require.NoError(t, err)
+	require.NoError(t, rw.Flush())
+
+	p := &testPublisher{
+		nc: nc,
+		cr: newChunkReader(rw.Reader),
+		cw: newChunkWriter(rw.Writer),
+	}
+
+	require.NoError(t, p.cw.writeCommand(0, "connect", 1, amfObject{{"app", app}}))
+	assert.Equal(t, "_result", p.readCommand(t)[0])
+
+	return p
+}
+
+// publish requests to publish the stream key and returns the status code of the response.
+func (p *testPublisher) publish(t *testing.T, key string) string {
+	t.Helper()
+
+	require.NoError(t, p.cw.writeCommand(0, "createStream", 2, nil))
+	assert.Equal(t, "_result", p.readCommand(t)[0])
+
+	require.NoError(t, p.cw.writeCommand(publishStreamID, "publish", 3, nil, key, "live"))
+	values := p.readCommand(t)
+	require.Equal(t, "onStatus", values[0])
+	require.Len(t, values, 4)
+
+	info, ok := values[3].(map[string]any)
+	require.True(t, ok)
+
+	code, _ := info["code"].(string)
+	return code
+}
+
+func (p *testPublisher) send(t *testing.T, typeID uint8, timestamp uint32, payload []byte) {
+	t.Helper()
+
+	require.NoError(t, p.cw.writeMessage(4, &message{
+		typeID:    typeID,
+		streamID:  publishStreamID,
+		timestamp: timestamp,
+		payload:   payload,
+	}))
+}
+
+// readCommand reads messages until a command arrives, applying chunk size changes.
+func (p *testPublisher) readCommand(t *testing.T) []any {
+	t.Helper()
+
+	for {
+		msg, err := p.cr.readMessage()
+		require.NoError(t, err)
+
+		switch msg.typeID {
+		case typeSetChunkSize:
+			require.NoError(t, p.cr.setChunkSize(binary.BigEndian.Uint32(msg.payload)))
+		case typeCommandAMF0:
+			values, err := decodeAMF0(msg.payload)
+			require.NoError(t, err)
+			return values
+		}
+	}
+}
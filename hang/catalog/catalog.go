@@ -0,0 +1,302 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// TrackName is the name of the track on which a broadcast publishes its catalog.
+const TrackName moqt.TrackName = "catalog.json"
+
+// DefaultVersion is the catalog format version assumed when the version field is omitted.
+const DefaultVersion uint64 = 1
+
+// MaxDescriptionLength is the maximum length, in characters, of description fields.
+const MaxDescriptionLength = 500
+
+// Schema identifiers defined by the catalog format.
+// Other values are treated as application-defined schema URIs.
+const (
+	SchemaVideo      = "video"
+	SchemaAudio      = "audio"
+	SchemaCaptions   = "captions"
+	SchemaLocation   = "location"
+	SchemaUser       = "user"
+	SchemaTimeseries = "timeseries"
+)
+
+var (
+	// ErrNoTracks is returned when a catalog has no tracks field.
+	ErrNoTracks = errors.New("catalog: tracks are required")
+)
+
+// Catalog is the root object published on the catalog.json track.
+// It describes the tracks available in a broadcast and their metadata.
+type Catalog struct {
+	// Version is the catalog format version. Zero means DefaultVersion.
+	Version uint64 `json:"version,omitempty"`
+
+	// Description is an optional human-readable summary of the broadcast.
+	Description string `json:"description,omitempty"`
+
+	// Tracks holds the track definitions keyed by track name.
+	Tracks map[string]Track `json:"tracks"`
+}
+
+// Track describes a single track listed in a Catalog.
+type Track struct {
+	// Name is the track identifier. It must match the key in Catalog.Tracks.
+	Name string `json:"name"`
+
+	// Description is an optional human-readable description.
+	Description string `json:"description,omitempty"`
+
+	// Priority is the relative selection priority of the track.
+	Priority moqt.TrackPriority `json:"priority"`
+
+	// Schema identifies the shape of Config, e.g. "video", "audio" or a URI.
+	Schema string `json:"schema"`
+
+	// Config holds the schema-specific configuration.
+	// Use DecodeConfig and SetConfig to convert it from and to typed values.
+	Config json.RawMessage `json:"config"`
+
+	// Dependencies lists other tracks in the catalog this track depends on.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// New returns an empty Catalog of the default version.
+func New() *Catalog {
+	return &Catalog{
+		Version: DefaultVersion,
+		Tracks:  make(map[string]Track),
+	}
+}
+
+// Parse decodes and validates a catalog.json document.
+// Unknown fields are ignored for forward compatibility and a missing version
+// is set to DefaultVersion.
+func Parse(b []byte) (*Catalog, error) {
+	var c Catalog
+	err := json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: invalid json: %w", err)
+	}
+
+	if c.Version == 0 {
+		c.Version = DefaultVersion
+	}
+
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Marshal validates the catalog and encodes it as JSON.
+func (c *Catalog) Marshal() ([]byte, error) {
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(c)
+}
+
+// AddTrack adds or replaces the track keyed by its name.
+func (c *Catalog) AddTrack(t Track) {
+	if c.Tracks == nil {
+		c.Tracks = make(map[string]Track)
+	}
+	c.Tracks[t.Name] = t
+}
+
+// RemoveTrack removes the named track and reports whether it was present.
+func (c *Catalog) RemoveTrack(name string) bool {
+	_, ok := c.Tracks[name]
+	delete(c.Tracks, name)
+	return ok
+}
+
+// Track returns the named track and whether it exists.
+func (c *Catalog) Track(name string) (Track, bool) {
+	t, ok := c.Tracks[name]
+	return t, ok
+}
+
+// TracksBySchema returns the tracks of the given schema ordered by descending priority.
+func (c *Catalog) TracksBySchema(schema string) []Track {
+	var tracks []Track
+	for _, t := range c.Tracks {
+		if t.Schema == schema {
+			tracks = append(tracks, t)
+		}
+	}
+
+	slices.SortFunc(tracks, func(a, b Track) int {
+		if a.Priority != b.Priority {
+			return int(b.Priority) - int(a.Priority)
+		}
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+
+	return tracks
+}
+
+// Clone returns a deep copy of the catalog.
+func (c *Catalog) Clone() *Catalog {
+	if c == nil {
+		return nil
+	}
+
+	clone := &Catalog{
+		Version:     c.Version,
+		Description: c.Description,
+	}
+	if c.Tracks != nil {
+		clone.Tracks = make(map[string]Track, len(c.Tracks))
+		for name, t := range c.Tracks {
+			t.Config = slices.Clone(t.Config)
+			t.Dependencies = slices.Clone(t.Dependencies)
+			clone.Tracks[name] = t
+		}
+	}
+
+	return clone
+}
+
+// Validate reports whether the catalog conforms to the documented schema.
+// Configs of the well-known schemas are validated; configs of other
+// schemas are only required to be present.
+func (c *Catalog) Validate() error {
+	if c.Tracks == nil {
+		return ErrNoTracks
+	}
+
+	if utf8.RuneCountInString(c.Description) > MaxDescriptionLength {
+		return &ValidationError{Field: "description", Reason: "too long"}
+	}
+
+	for key, t := range c.Tracks {
+		if t.Name == "" {
+			return &ValidationError{Track: key, Field: "name", Reason: "must not be empty"}
+		}
+		if t.Name != key {
+			return &ValidationError{Track: key, Field: "name", Reason: fmt.Sprintf("%q does not match the key", t.Name)}
+		}
+
+		err := t.validate()
+		if err != nil {
+			return err
+		}
+
+		for _, dep := range t.Dependencies {
+			if dep == t.Name {
+				return &ValidationError{Track: key, Field: "dependencies", Reason: "track depends on itself"}
+			}
+			if _, ok := c.Tracks[dep]; !ok {
+				return &ValidationError{Track: key, Field: "dependencies", Reason: fmt.Sprintf("unknown track %q", dep)}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (t Track) validate() error {
+	if utf8.RuneCountInString(t.Description) > MaxDescriptionLength {
+		return &ValidationError{Track: t.Name, Field: "description", Reason: "too long"}
+	}
+
+	if t.Schema == "" {
+		return &ValidationError{Track: t.Name, Field: "schema", Reason: "must not be empty"}
+	}
+
+	if len(t.Config) == 0 || string(t.Config) == "null" {
+		return &ValidationError{Track: t.Name, Field: "config", Reason: "is required"}
+	}
+
+	var cfg interface{ validate() error }
+	switch t.Schema {
+	case SchemaVideo:
+		cfg = &VideoConfig{}
+	case SchemaAudio:
+		cfg = &AudioConfig{}
+	case SchemaLocation:
+		cfg = &LocationConfig{}
+	case SchemaUser:
+		cfg = &UserConfig{}
+	case SchemaTimeseries:
+		cfg = &TimeseriesConfig{}
+	case SchemaCaptions:
+		if len(t.Dependencies) == 0 {
+			return &ValidationError{Track: t.Name, Field: "dependencies", Reason: "captions require at least one dependency"}
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	err := json.Unmarshal(t.Config, cfg)
+	if err != nil {
+		return &ValidationError{Track: t.Name, Field: "config", Reason: err.Error()}
+	}
+
+	err = cfg.validate()
+	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			vErr.Track = t.Name
+			vErr.Field = "config." + vErr.Field
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DecodeConfig decodes the track config into v, which is usually a pointer to
+// one of the schema config types such as VideoConfig.
+func (t Track) DecodeConfig(v any) error {
+	return json.Unmarshal(t.Config, v)
+}
+
+// SetConfig encodes v as the track config.
+func (t *Track) SetConfig(v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t.Config = b
+	return nil
+}
+
+// ValidationError describes a field of a catalog that does not conform to the schema.
+type ValidationError struct {
+	// Track is the key of the offending track, or empty for root fields.
+	Track string
+	// Field is the JSON name of the offending field.
+	Field string
+	// Reason describes the violation.
+	Reason string
+}
+
+func (err *ValidationError) Error() string {
+	if err.Track == "" {
+		return fmt.Sprintf("catalog: %s %s", err.Field, err.Reason)
+	}
+	return fmt.Sprintf("catalog: track %q: %s %s", err.Track, err.Field, err.Reason)
+}
@@ -0,0 +1,312 @@
+package catalog
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const webCameraCatalog = `{
+  "version": 1,
+  "description": "Example catalog",
+  "tracks": {
+    "camera-main": {
+      "name": "camera-main",
+      "priority": 128,
+      "schema": "video",
+      "config": {
+        "codec": "avc1.640028",
+        "framerate": 30,
+        "container": "cmaf"
+      }
+    },
+    "mic": {
+      "name": "mic",
+      "priority": 200,
+      "schema": "audio",
+      "config": {
+        "codec": "opus",
+        "sampleRate": 48000,
+        "numberOfChannels": 2,
+        "container": "loc"
+      }
+    }
+  }
+}`
+
+const droneCatalog = `{
+  "version": 1,
+  "description": "Drone telemetry catalog example",
+  "tracks": {
+    "drone-telemetry": {
+      "name": "drone-telemetry",
+      "priority": 180,
+      "schema": "timeseries",
+      "config": {
+        "measurements": {
+          "temperature": { "type": "temperature", "unit": "celsius", "interval": 1000, "min": -40, "max": 85 },
+          "altitude": { "type": "altitude", "unit": "meter", "interval": 500 }
+        }
+      }
+    }
+  }
+}`
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(webCameraCatalog))
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), c.Version)
+	assert.Equal(t, "Example catalog", c.Description)
+	assert.Len(t, c.Tracks, 2)
+
+	camera, ok := c.Track("camera-main")
+	require.True(t, ok)
+	assert.Equal(t, SchemaVideo, camera.Schema)
+	assert.EqualValues(t, 128, camera.Priority)
+
+	var video VideoConfig
+	require.NoError(t, camera.DecodeConfig(&video))
+	assert.Equal(t, "avc1.640028", video.Codec)
+	assert.Equal(t, uint64(30), video.Framerate)
+	assert.Equal(t, ContainerCMAF, video.Container)
+	assert.True(t, video.LatencyOptimized(), "optimizeForLatency should default to true")
+
+	mic, ok := c.Track("mic")
+	require.True(t, ok)
+	var audio AudioConfig
+	require.NoError(t, mic.DecodeConfig(&audio))
+	assert.Equal(t, uint64(48000), audio.SampleRate)
+	assert.Equal(t, uint64(2), audio.NumberOfChannels)
+}
+
+func TestParse_Timeseries(t *testing.T) {
+	c, err := Parse([]byte(droneCatalog))
+	require.NoError(t, err)
+
+	track, ok := c.Track("drone-telemetry")
+	require.True(t, ok)
+
+	var ts TimeseriesConfig
+	require.NoError(t, track.DecodeConfig(&ts))
+	require.Contains(t, ts.Measurements, "temperature")
+	require.NotNil(t, ts.Measurements["temperature"].Min)
+	assert.Equal(t, -40.0, *ts.Measurements["temperature"].Min)
+	assert.Nil(t, ts.Measurements["altitude"].Max)
+}
+
+func TestParse_DefaultsAndUnknownFields(t *testing.T) {
+	doc := `{
+		"future": true,
+		"tracks": {
+			"chat": {"name": "chat", "priority": 1, "schema": "https://example.com/chat", "config": {"anything": 1}, "extra": "ignored"}
+		}
+	}`
+
+	c, err := Parse([]byte(doc))
+	require.NoError(t, err)
+	assert.Equal(t, DefaultVersion, c.Version, "missing version should default")
+	assert.Contains(t, c.Tracks, "chat")
+}
+
+func TestParse_InvalidJSON(t *testing.T) {
+	_, err := Parse([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestCatalog_Validate(t *testing.T) {
+	video := `{"codec":"avc1","container":"cmaf"}`
+
+	tests := map[string]struct {
+		catalog string
+		track   string
+		field   string
+		err     error
+	}{
+		"missing tracks": {
+			catalog: `{"version":1}`,
+			err:     ErrNoTracks,
+		},
+		"empty tracks": {
+			catalog: `{"tracks":{}}`,
+		},
+		"name mismatch": {
+			catalog: `{"tracks":{"a":{"name":"b","priority":1,"schema":"video","config":` + video + `}}}`,
+			track:   "a",
+			field:   "name",
+		},
+		"empty name": {
+			catalog: `{"tracks":{"a":{"name":"","priority":1,"schema":"video","config":` + video + `}}}`,
+			track:   "a",
+			field:   "name",
+		},
+		"missing schema": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"config":` + video + `}}}`,
+			track:   "a",
+			field:   "schema",
+		},
+		"missing config": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"video"}}}`,
+			track:   "a",
+			field:   "config",
+		},
+		"video without codec": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"video","config":{"container":"loc"}}}}`,
+			track:   "a",
+			field:   "config.codec",
+		},
+		"video with unknown container": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"video","config":{"codec":"vp09","container":"mkv"}}}}`,
+			track:   "a",
+			field:   "config.container",
+		},
+		"audio without sample rate": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"audio","config":{"codec":"opus","numberOfChannels":2,"container":"loc"}}}}`,
+			track:   "a",
+			field:   "config.sampleRate",
+		},
+		"captions without dependencies": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"captions","config":{}}}}`,
+			track:   "a",
+			field:   "dependencies",
+		},
+		"unknown dependency": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"video","config":` + video + `,"dependencies":["b"]}}}`,
+			track:   "a",
+			field:   "dependencies",
+		},
+		"self dependency": {
+			catalog: `{"tracks":{"a":{"name":"a","priority":1,"schema":"video","config":` + video + `,"dependencies":["a"]}}}`,
+			track:   "a",
+			field:   "dependencies",
+		},
+		"user with invalid id": {
+			catalog: `{"tracks":{"u":{"name":"u","priority":1,"schema":"user","config":{"id":"x","name":"Alice","avatar":"https://example.com/a.png"}}}}`,
+			track:   "u",
+			field:   "config.id",
+		},
+		"user with relative avatar": {
+			catalog: `{"tracks":{"u":{"name":"u","priority":1,"schema":"user","config":{"id":"0b6c6f8e-3c3a-4c59-9a8e-6f1f0ac0c3f1","name":"Alice","avatar":"a.png"}}}}`,
+			track:   "u",
+			field:   "config.avatar",
+		},
+		"timeseries without measurements": {
+			catalog: `{"tracks":{"t":{"name":"t","priority":1,"schema":"timeseries","config":{"measurements":{}}}}}`,
+			track:   "t",
+			field:   "config.measurements",
+		},
+		"captions with dependency": {
+			catalog: `{"tracks":{"v":{"name":"v","priority":1,"schema":"video","config":` + video + `},"c":{"name":"c","priority":1,"schema":"captions","config":{},"dependencies":["v"]}}}`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var c Catalog
+			require.NoError(t, json.Unmarshal([]byte(tt.catalog), &c))
+
+			err := c.Validate()
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			if tt.field == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var vErr *ValidationError
+			require.True(t, errors.As(err, &vErr), "expected ValidationError, got %v", err)
+			assert.Equal(t, tt.track, vErr.Track)
+			assert.Equal(t, tt.field, vErr.Field)
+		})
+	}
+}
+
+func TestCatalog_ValidateDescriptionLength(t *testing.T) {
+	c := New()
+	c.Description = strings.Repeat("あ", MaxDescriptionLength)
+	assert.NoError(t, c.Validate(), "length is counted in characters")
+
+	c.Description += "a"
+	assert.Error(t, c.Validate())
+}
+
+func TestCatalog_MarshalRoundTrip(t *testing.T) {
+	c := New()
+	c.Description = "round trip"
+
+	video := Track{Name: "video", Priority: 2, Schema: SchemaVideo}
+	require.NoError(t, video.SetConfig(VideoConfig{Codec: "avc1.64001f", CodedWidth: 1280, CodedHeight: 720, Container: ContainerLOC}))
+	c.AddTrack(video)
+
+	captions := Track{Name: "captions", Priority: 1, Schema: SchemaCaptions, Dependencies: []string{"video"}}
+	require.NoError(t, captions.SetConfig(struct{}{}))
+	c.AddTrack(captions)
+
+	b, err := c.Marshal()
+	require.NoError(t, err)
+
+	parsed, err := Parse(b)
+	require.NoError(t, err)
+	assert.Equal(t, c.Description, parsed.Description)
+	assert.Equal(t, []string{"video"}, parsed.Tracks["captions"].Dependencies)
+
+	var cfg VideoConfig
+	require.NoError(t, parsed.Tracks["video"].DecodeConfig(&cfg))
+	assert.Equal(t, uint64(1280), cfg.CodedWidth)
+}
+
+func TestCatalog_MarshalInvalid(t *testing.T) {
+	c := &Catalog{}
+	_, err := c.Marshal()
+	assert.ErrorIs(t, err, ErrNoTracks)
+}
+
+func TestCatalog_RemoveTrack(t *testing.T) {
+	c, err := Parse([]byte(webCameraCatalog))
+	require.NoError(t, err)
+
+	assert.True(t, c.RemoveTrack("mic"))
+	assert.False(t, c.RemoveTrack("mic"))
+	_, ok := c.Track("mic")
+	assert.False(t, ok)
+}
+
+func TestCatalog_TracksBySchema(t *testing.T) {
+	c := New()
+	for _, tr := range []Track{
+		{Name: "L0", Priority: 200, Schema: SchemaVideo},
+		{Name: "L2", Priority: 120, Schema: SchemaVideo},
+		{Name: "L1", Priority: 150, Schema: SchemaVideo},
+		{Name: "mic", Priority: 210, Schema: SchemaAudio},
+	} {
+		c.AddTrack(tr)
+	}
+
+	tracks := c.TracksBySchema(SchemaVideo)
+	require.Len(t, tracks, 3)
+	assert.Equal(t, "L0", tracks[0].Name)
+	assert.Equal(t, "L1", tracks[1].Name)
+	assert.Equal(t, "L2", tracks[2].Name)
+}
+
+func TestCatalog_Clone(t *testing.T) {
+	c, err := Parse([]byte(webCameraCatalog))
+	require.NoError(t, err)
+
+	clone := c.Clone()
+	assert.Equal(t, c, clone)
+
+	clone.Tracks["mic"].Config[0] = '['
+	clone.RemoveTrack("camera-main")
+	assert.Equal(t, byte('{'), c.Tracks["mic"].Config[0], "config should be deep-copied")
+	assert.Contains(t, c.Tracks, "camera-main")
+
+	var nilCatalog *Catalog
+	assert.Nil(t, nilCatalog.Clone())
+}
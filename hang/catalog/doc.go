@@ -0,0 +1,40 @@
+// Package catalog implements the hang catalog format.
+//
+// A catalog is a JSON document published on the catalog.json track of a
+// broadcast. It lists the tracks of the broadcast together with their
+// priority, schema-specific configuration and dependencies, so that
+// subscribers can discover media without trial-and-error subscriptions.
+//
+// # Publishing
+//
+// A Publisher serves the catalog track and republishes the full catalog as a
+// new group every time it is updated.
+/*
+	c := catalog.New()
+	video := catalog.Track{Name: "video", Priority: 128, Schema: catalog.SchemaVideo}
+	_ = video.SetConfig(catalog.VideoConfig{Codec: "avc1.640028", Container: catalog.ContainerCMAF})
+	c.AddTrack(video)
+
+	pub, err := catalog.NewPublisher(c)
+	if err != nil {
+	    log.Fatal(err)
+	}
+	pub.Publish(ctx, mux, "/room/alice.hang", mediaHandler)
+*/
+//
+// # Subscribing
+//
+// A Subscriber subscribes to the catalog track and yields parsed catalogs
+// whenever the publisher sends a new one.
+/*
+	sub, err := catalog.Subscribe(sess, "/room/alice.hang", nil)
+	if err != nil {
+	    log.Fatal(err)
+	}
+	defer sub.Close()
+
+	for c := range sub.Catalogs(ctx) {
+	    // react to the catalog
+	}
+*/
+package catalog
@@ -0,0 +1,153 @@
+package catalog
+
+import (
+	"context"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// NewPublisher creates a Publisher serving the given catalog.
+// The catalog is validated and copied, so later changes to c have no effect
+// until they are passed to Update.
+func NewPublisher(c *Catalog) (*Publisher, error) {
+	p := &Publisher{
+		updated: make(chan struct{}),
+	}
+
+	err := p.set(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Publisher serves a catalog on the catalog.json track.
+// Every subscriber receives the current catalog as a single-frame group
+// when it subscribes and a new group each time the catalog is updated.
+type Publisher struct {
+	mu      sync.Mutex
+	catalog *Catalog
+	data    []byte
+
+	// updated is closed and replaced whenever the catalog changes
+	updated chan struct{}
+}
+
+var _ moqt.TrackHandler = (*Publisher)(nil)
+
+// Catalog returns a copy of the catalog currently being served.
+func (p *Publisher) Catalog() *Catalog {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.catalog.Clone()
+}
+
+// Update replaces the served catalog and delivers it to all current subscribers as a new group.
+func (p *Publisher) Update(c *Catalog) error {
+	return p.set(c)
+}
+
+func (p *Publisher) set(c *Catalog) error {
+	if c == nil {
+		return ErrNoTracks
+	}
+
+	c = c.Clone()
+	if c.Version == 0 {
+		c.Version = DefaultVersion
+	}
+
+	data, err := c.Marshal()
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.catalog = c
+	p.data = data
+	close(p.updated)
+	p.updated = make(chan struct{})
+
+	return nil
+}
+
+// ServeTrack writes the catalog to the track writer and keeps writing a new
+// group on every update until the subscription ends.
+func (p *Publisher) ServeTrack(tw *moqt.TrackWriter) {
+	// Capture the context up front as it is no longer reachable once the writer is closed
+	ctx := tw.Context()
+
+	for {
+		p.mu.Lock()
+		data := p.data
+		updated := p.updated
+		p.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := writeCatalog(tw, data)
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Handler returns a TrackHandler that serves the catalog track with the
+// Publisher and delegates every other track of the broadcast to media.
+// If media is nil, other tracks are answered with a track-not-found error.
+func (p *Publisher) Handler(media moqt.TrackHandler) moqt.TrackHandler {
+	return moqt.TrackHandlerFunc(func(tw *moqt.TrackWriter) {
+		if tw.TrackName == TrackName {
+			p.ServeTrack(tw)
+			return
+		}
+
+		if media == nil {
+			moqt.NotFound(tw)
+			return
+		}
+
+		media.ServeTrack(tw)
+	})
+}
+
+// Publish registers the broadcast on the mux with the catalog track served
+// by the Publisher and the other tracks served by media.
+// The broadcast stays announced until ctx is canceled.
+func (p *Publisher) Publish(ctx context.Context, mux *moqt.TrackMux, path moqt.BroadcastPath, media moqt.TrackHandler) {
+	if mux == nil {
+		mux = moqt.DefaultMux
+	}
+	mux.Publish(ctx, path, p.Handler(media))
+}
+
+func writeCatalog(tw *moqt.TrackWriter, data []byte) error {
+	gw, err := tw.OpenGroup()
+	if err != nil {
+		return err
+	}
+
+	frame := moqt.NewFrame(len(data))
+	_, _ = frame.Write(data)
+
+	err = gw.WriteFrame(frame)
+	if err != nil {
+		gw.CancelWrite(moqt.InternalGroupErrorCode)
+		return err
+	}
+
+	return gw.Close()
+}
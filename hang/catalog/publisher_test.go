@@ -0,0 +1,76 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublisher(t *testing.T) {
+	c, err := Parse([]byte(webCameraCatalog))
+	require.NoError(t, err)
+
+	p, err := NewPublisher(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, c, p.Catalog())
+
+	// The publisher keeps its own copy
+	c.RemoveTrack("mic")
+	assert.Contains(t, p.Catalog().Tracks, "mic")
+}
+
+func TestNewPublisher_Invalid(t *testing.T) {
+	_, err := NewPublisher(nil)
+	assert.ErrorIs(t, err, ErrNoTracks)
+
+	_, err = NewPublisher(&Catalog{})
+	assert.ErrorIs(t, err, ErrNoTracks)
+}
+
+func TestPublisher_Update(t *testing.T) {
+	p, err := NewPublisher(New())
+	require.NoError(t, err)
+
+	p.mu.Lock()
+	updated := p.updated
+	p.mu.Unlock()
+
+	c, err := Parse([]byte(droneCatalog))
+	require.NoError(t, err)
+	require.NoError(t, p.Update(c))
+
+	select {
+	case <-updated:
+	default:
+		t.Fatal("update should notify subscribers")
+	}
+
+	assert.Contains(t, p.Catalog().Tracks, "drone-telemetry")
+
+	p.mu.Lock()
+	data := p.data
+	p.mu.Unlock()
+	parsed, err := Parse(data)
+	require.NoError(t, err)
+	assert.Equal(t, c.Description, parsed.Description)
+	assert.JSONEq(t, string(c.Tracks["drone-telemetry"].Config), string(parsed.Tracks["drone-telemetry"].Config))
+}
+
+func TestPublisher_UpdateInvalidKeepsCatalog(t *testing.T) {
+	c, err := Parse([]byte(webCameraCatalog))
+	require.NoError(t, err)
+	p, err := NewPublisher(c)
+	require.NoError(t, err)
+
+	err = p.Update(&Catalog{})
+	assert.Error(t, err)
+	assert.Equal(t, c, p.Catalog(), "invalid update must not replace the catalog")
+}
+
+func TestPublisher_DefaultsVersion(t *testing.T) {
+	p, err := NewPublisher(&Catalog{Tracks: map[string]Track{}})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultVersion, p.Catalog().Version)
+}
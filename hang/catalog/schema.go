@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"net/url"
+	"regexp"
+)
+
+// Container values for media tracks.
+const (
+	ContainerLOC  = "loc"
+	ContainerCMAF = "cmaf"
+)
+
+// VideoConfig is the config of a track with the "video" schema.
+type VideoConfig struct {
+	Codec               string  `json:"codec"`
+	Description         string  `json:"description,omitempty"`
+	CodedWidth          uint64  `json:"codedWidth,omitempty"`
+	CodedHeight         uint64  `json:"codedHeight,omitempty"`
+	DisplayAspectWidth  uint64  `json:"displayAspectWidth,omitempty"`
+	DisplayAspectHeight uint64  `json:"displayAspectHeight,omitempty"`
+	Framerate           uint64  `json:"framerate,omitempty"`
+	Bitrate             uint64  `json:"bitrate,omitempty"`
+	OptimizeForLatency  *bool   `json:"optimizeForLatency,omitempty"`
+	Rotation            float64 `json:"rotation,omitempty"`
+	Flip                bool    `json:"flip,omitempty"`
+	Container           string  `json:"container"`
+}
+
+// LatencyOptimized reports the optimizeForLatency hint, which defaults to true.
+func (c VideoConfig) LatencyOptimized() bool {
+	return c.OptimizeForLatency == nil || *c.OptimizeForLatency
+}
+
+func (c *VideoConfig) validate() error {
+	if c.Codec == "" {
+		return &ValidationError{Field: "codec", Reason: "is required"}
+	}
+	return validateContainer(c.Container)
+}
+
+// AudioConfig is the config of a track with the "audio" schema.
+type AudioConfig struct {
+	Codec            string `json:"codec"`
+	Description      string `json:"description,omitempty"`
+	SampleRate       uint64 `json:"sampleRate"`
+	NumberOfChannels uint64 `json:"numberOfChannels"`
+	Bitrate          uint64 `json:"bitrate,omitempty"`
+	Container        string `json:"container"`
+}
+
+func (c *AudioConfig) validate() error {
+	if c.Codec == "" {
+		return &ValidationError{Field: "codec", Reason: "is required"}
+	}
+	if c.SampleRate == 0 {
+		return &ValidationError{Field: "sampleRate", Reason: "is required"}
+	}
+	if c.NumberOfChannels == 0 {
+		return &ValidationError{Field: "numberOfChannels", Reason: "is required"}
+	}
+	return validateContainer(c.Container)
+}
+
+// LocationConfig is the config of a track with the "location" schema.
+type LocationConfig struct {
+	ID     uint64 `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+func (c *LocationConfig) validate() error {
+	if c.Name == "" {
+		return &ValidationError{Field: "name", Reason: "is required"}
+	}
+	return validateURL("avatar", c.Avatar)
+}
+
+// UserConfig is the config of a track with the "user" schema.
+type UserConfig struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Avatar string `json:"avatar"`
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (c *UserConfig) validate() error {
+	if !uuidPattern.MatchString(c.ID) {
+		return &ValidationError{Field: "id", Reason: "must be a UUID"}
+	}
+	if c.Name == "" {
+		return &ValidationError{Field: "name", Reason: "is required"}
+	}
+	return validateURL("avatar", c.Avatar)
+}
+
+// TimeseriesConfig is the config of a track with the "timeseries" schema.
+type TimeseriesConfig struct {
+	Measurements map[string]Measurement `json:"measurements"`
+}
+
+// Measurement describes a named series within a timeseries track.
+// Min and Max are informative bounds and are not enforced.
+type Measurement struct {
+	Type     string   `json:"type"`
+	Unit     string   `json:"unit"`
+	Interval uint64   `json:"interval"`
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+}
+
+func (c *TimeseriesConfig) validate() error {
+	if len(c.Measurements) == 0 {
+		return &ValidationError{Field: "measurements", Reason: "is required"}
+	}
+	for name, m := range c.Measurements {
+		if m.Type == "" {
+			return &ValidationError{Field: "measurements." + name + ".type", Reason: "is required"}
+		}
+		if m.Unit == "" {
+			return &ValidationError{Field: "measurements." + name + ".unit", Reason: "is required"}
+		}
+		if m.Interval == 0 {
+			return &ValidationError{Field: "measurements." + name + ".interval", Reason: "is required"}
+		}
+	}
+	return nil
+}
+
+func validateContainer(container string) error {
+	switch container {
+	case ContainerLOC, ContainerCMAF:
+		return nil
+	case "":
+		return &ValidationError{Field: "container", Reason: "is required"}
+	default:
+		return &ValidationError{Field: "container", Reason: "must be \"loc\" or \"cmaf\""}
+	}
+}
+
+func validateURL(field, s string) error {
+	if s == "" {
+		return &ValidationError{Field: field, Reason: "is required"}
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return &ValidationError{Field: field, Reason: "must be an absolute URL"}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// Subscribe subscribes to the catalog track of the broadcast at path and
+// returns a Subscriber reading from it.
+func Subscribe(sess *moqt.Session, path moqt.BroadcastPath, config *moqt.TrackConfig) (*Subscriber, error) {
+	tr, err := sess.Subscribe(path, TrackName, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSubscriber(tr), nil
+}
+
+// NewSubscriber returns a Subscriber reading catalogs from an existing
+// subscription to a catalog track.
+func NewSubscriber(tr *moqt.TrackReader) *Subscriber {
+	return &Subscriber{
+		track: tr,
+	}
+}
+
+// Subscriber reads catalogs published on a catalog.json track.
+// Each group of the track carries a complete catalog; groups older than the
+// last one read are discarded.
+type Subscriber struct {
+	track *moqt.TrackReader
+
+	mu       sync.Mutex
+	latest   *Catalog
+	lastSeq  moqt.GroupSequence
+	received bool
+}
+
+// Next blocks until the next catalog is received and returns it parsed.
+// A catalog that fails to parse is reported as an error; later calls continue
+// with the following group.
+func (s *Subscriber) Next(ctx context.Context) (*Catalog, error) {
+	for {
+		gr, err := s.track.AcceptGroup(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		seq := gr.GroupSequence()
+
+		s.mu.Lock()
+		stale := s.received && seq <= s.lastSeq
+		s.mu.Unlock()
+
+		if stale {
+			gr.CancelRead(moqt.ExpiredGroupErrorCode)
+			continue
+		}
+
+		data, err := readGroup(gr)
+		if err != nil {
+			return nil, err
+		}
+
+		c, err := Parse(data)
+
+		s.mu.Lock()
+		if !s.received || seq > s.lastSeq {
+			s.lastSeq = seq
+			s.received = true
+			if err == nil {
+				s.latest = c
+			}
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return c.Clone(), nil
+	}
+}
+
+// Latest returns the most recently received catalog, or nil if none has been received yet.
+func (s *Subscriber) Latest() *Catalog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.latest.Clone()
+}
+
+// Catalogs returns an iterator that yields each received catalog until ctx
+// is canceled or the subscription ends. Groups that cannot be read or parsed
+// are skipped.
+func (s *Subscriber) Catalogs(ctx context.Context) iter.Seq[*Catalog] {
+	return func(yield func(*Catalog) bool) {
+		trackCtx := s.track.Context()
+		for {
+			c, err := s.Next(ctx)
+			if err != nil {
+				if ctx.Err() != nil || trackCtx.Err() != nil {
+					return
+				}
+				continue
+			}
+
+			if !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// TrackReader returns the underlying subscription.
+func (s *Subscriber) TrackReader() *moqt.TrackReader {
+	return s.track
+}
+
+// Close ends the subscription to the catalog track.
+func (s *Subscriber) Close() error {
+	return s.track.Close()
+}
+
+func readGroup(gr *moqt.GroupReader) ([]byte, error) {
+	var data []byte
+	frame := moqt.NewFrame(0)
+	for {
+		err := gr.ReadFrame(frame)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return data, nil
+			}
+			return nil, err
+		}
+		data = append(data, frame.Body()...)
+	}
+}
@@ -0,0 +1,45 @@
+// Package room implements hang rooms and participants on top of moqt.
+//
+// A room is a broadcast path prefix such as "/conference/". Each participant
+// publishes a broadcast directly under it, named after the participant with
+// the ".hang" extension, e.g. "/conference/alice.hang".
+//
+// # Joining
+//
+// Join announces a participant on a TrackMux. Tracks are registered on the
+// returned LocalParticipant and the participant leaves when Leave is called
+// or its context is canceled.
+/*
+	r, err := room.New("/conference")
+	if err != nil {
+	    log.Fatal(err)
+	}
+
+	alice, err := r.Join(ctx, mux, "alice")
+	if err != nil {
+	    log.Fatal(err)
+	}
+	defer alice.Leave()
+
+	alice.PublishTrackFunc("video", serveVideo)
+*/
+//
+// # Watching
+//
+// Watch requests the announcements of the room from a peer and reports
+// participants joining and leaving.
+/*
+	w, err := r.Watch(sess)
+	if err != nil {
+	    log.Fatal(err)
+	}
+	defer w.Close()
+
+	for e := range w.Events(ctx) {
+	    if e.Type == room.Joined {
+	        tr, err := e.Participant.Subscribe("video", nil)
+	        // ...
+	    }
+	}
+*/
+package room
@@ -0,0 +1,151 @@
+package room
+
+import (
+	"sync"
+
+	"github.com/okdaichi/gomoqt/hang/catalog"
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+func newLocalParticipant(name string, path moqt.BroadcastPath, ann *moqt.Announcement, end moqt.EndAnnouncementFunc) *LocalParticipant {
+	return &LocalParticipant{
+		name:         name,
+		path:         path,
+		announcement: ann,
+		end:          end,
+		tracks:       make(map[moqt.TrackName]moqt.TrackHandler),
+	}
+}
+
+// LocalParticipant is a participant published by this endpoint.
+// It serves the tracks registered with PublishTrack and answers any other
+// track with a track-not-found error.
+type LocalParticipant struct {
+	name string
+	path moqt.BroadcastPath
+
+	announcement *moqt.Announcement
+	end          moqt.EndAnnouncementFunc
+
+	mu      sync.RWMutex
+	tracks  map[moqt.TrackName]moqt.TrackHandler
+	catalog *catalog.Publisher
+}
+
+var _ moqt.TrackHandler = (*LocalParticipant)(nil)
+
+// Name returns the participant name.
+func (p *LocalParticipant) Name() string {
+	return p.name
+}
+
+// BroadcastPath returns the broadcast path the participant publishes at.
+func (p *LocalParticipant) BroadcastPath() moqt.BroadcastPath {
+	return p.path
+}
+
+// PublishTrack registers the handler serving the named track.
+// A handler already registered for the name is replaced.
+func (p *LocalParticipant) PublishTrack(name moqt.TrackName, handler moqt.TrackHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tracks[name] = handler
+}
+
+// PublishTrackFunc registers a function serving the named track.
+func (p *LocalParticipant) PublishTrackFunc(name moqt.TrackName, f func(tw *moqt.TrackWriter)) {
+	p.PublishTrack(name, moqt.TrackHandlerFunc(f))
+}
+
+// RemoveTrack unregisters the named track. Subscriptions that are already
+// being served are not affected.
+func (p *LocalParticipant) RemoveTrack(name moqt.TrackName) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.tracks, name)
+}
+
+// PublishCatalog publishes c on the catalog.json track of the participant,
+// or delivers it as an update when a catalog is already published.
+func (p *LocalParticipant) PublishCatalog(c *catalog.Catalog) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.catalog != nil {
+		return p.catalog.Update(c)
+	}
+
+	pub, err := catalog.NewPublisher(c)
+	if err != nil {
+		return err
+	}
+	p.catalog = pub
+	p.tracks[catalog.TrackName] = pub
+
+	return nil
+}
+
+// ServeTrack dispatches a subscription to the handler registered for its track name.
+func (p *LocalParticipant) ServeTrack(tw *moqt.TrackWriter) {
+	p.mu.RLock()
+	handler, ok := p.tracks[tw.TrackName]
+	p.mu.RUnlock()
+
+	if !ok {
+		moqt.NotFound(tw)
+		return
+	}
+
+	handler.ServeTrack(tw)
+}
+
+// Leave ends the participant's announcement so that it leaves the room.
+// Subscriptions being served are closed by the mux.
+func (p *LocalParticipant) Leave() {
+	p.end()
+}
+
+// Done returns a channel that is closed once the participant has left the room.
+func (p *LocalParticipant) Done() <-chan struct{} {
+	return p.announcement.Done()
+}
+
+// RemoteParticipant is a participant discovered through a Watcher.
+type RemoteParticipant struct {
+	name string
+
+	announcement *moqt.Announcement
+	sess         *moqt.Session
+}
+
+// Name returns the participant name.
+func (p *RemoteParticipant) Name() string {
+	return p.name
+}
+
+// BroadcastPath returns the broadcast path the participant publishes at.
+func (p *RemoteParticipant) BroadcastPath() moqt.BroadcastPath {
+	return p.announcement.BroadcastPath()
+}
+
+// Present reports whether the participant is still in the room.
+func (p *RemoteParticipant) Present() bool {
+	return p.announcement.IsActive()
+}
+
+// Done returns a channel that is closed once the participant has left the room.
+func (p *RemoteParticipant) Done() <-chan struct{} {
+	return p.announcement.Done()
+}
+
+// Subscribe subscribes to a track published by the participant.
+func (p *RemoteParticipant) Subscribe(name moqt.TrackName, config *moqt.TrackConfig) (*moqt.TrackReader, error) {
+	return p.sess.Subscribe(p.BroadcastPath(), name, config)
+}
+
+// SubscribeCatalog subscribes to the participant's catalog track.
+func (p *RemoteParticipant) SubscribeCatalog(config *moqt.TrackConfig) (*catalog.Subscriber, error) {
+	return catalog.Subscribe(p.sess, p.BroadcastPath(), config)
+}
@@ -0,0 +1,60 @@
+package room
+
+import (
+	"context"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/hang/catalog"
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalParticipant_PublishTrack(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	p, err := r.Join(context.Background(), moqt.NewTrackMux(), "alice")
+	require.NoError(t, err)
+	defer p.Leave()
+
+	p.PublishTrackFunc("video", func(tw *moqt.TrackWriter) {})
+
+	p.mu.RLock()
+	_, ok := p.tracks["video"]
+	p.mu.RUnlock()
+	assert.True(t, ok)
+
+	p.RemoveTrack("video")
+
+	p.mu.RLock()
+	_, ok = p.tracks["video"]
+	p.mu.RUnlock()
+	assert.False(t, ok)
+}
+
+func TestLocalParticipant_PublishCatalog(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	p, err := r.Join(context.Background(), moqt.NewTrackMux(), "alice")
+	require.NoError(t, err)
+	defer p.Leave()
+
+	assert.Error(t, p.PublishCatalog(nil))
+
+	c := catalog.New()
+	c.AddTrack(catalog.Track{Name: "chat", Priority: 1, Schema: "https://example.com/chat", Config: []byte(`{}`)})
+	require.NoError(t, p.PublishCatalog(c))
+
+	p.mu.RLock()
+	pub := p.catalog
+	_, ok := p.tracks[catalog.TrackName]
+	p.mu.RUnlock()
+	require.NotNil(t, pub)
+	assert.True(t, ok)
+
+	c.Description = "updated"
+	require.NoError(t, p.PublishCatalog(c))
+	assert.Equal(t, "updated", pub.Catalog().Description, "later calls should update the same publisher")
+}
@@ -0,0 +1,102 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// Extension is the suffix appended to a participant name to form its broadcast path.
+const Extension = ".hang"
+
+var (
+	// ErrInvalidRoom is returned when a room path is not an absolute path.
+	ErrInvalidRoom = errors.New("room: invalid room path")
+
+	// ErrInvalidName is returned when a participant name is empty or contains a slash.
+	ErrInvalidName = errors.New("room: invalid participant name")
+)
+
+// New returns a Room for the given path, e.g. "/conference/standup".
+// A trailing slash is added when missing.
+func New(path string) (*Room, error) {
+	if path == "" || path[0] != '/' {
+		return nil, ErrInvalidRoom
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return &Room{prefix: path}, nil
+}
+
+// Room is a collection of participants that publish broadcasts under a common prefix.
+// The participant "alice" of the room "/conference/" publishes at "/conference/alice.hang".
+type Room struct {
+	prefix string
+}
+
+// Prefix returns the announcement prefix of the room. It always ends with a slash.
+func (r *Room) Prefix() string {
+	return r.prefix
+}
+
+// BroadcastPath returns the broadcast path of the named participant.
+func (r *Room) BroadcastPath(name string) moqt.BroadcastPath {
+	return moqt.BroadcastPath(r.prefix + name + Extension)
+}
+
+// ParticipantName returns the participant name for a broadcast path in the room.
+// It reports false when the path is not a participant broadcast of this room,
+// for instance when it is nested deeper or lacks the participant extension.
+func (r *Room) ParticipantName(path moqt.BroadcastPath) (string, bool) {
+	suffix, ok := path.GetSuffix(r.prefix)
+	if !ok || strings.Contains(suffix, "/") {
+		return "", false
+	}
+
+	name, ok := strings.CutSuffix(suffix, Extension)
+	if !ok || name == "" {
+		return "", false
+	}
+
+	return name, true
+}
+
+// Join announces the named participant on the mux and returns it so that it can publish tracks.
+// The participant stays in the room until ctx is canceled or Leave is called.
+// If mux is nil, moqt.DefaultMux is used.
+func (r *Room) Join(ctx context.Context, mux *moqt.TrackMux, name string) (*LocalParticipant, error) {
+	if !isValidName(name) {
+		return nil, ErrInvalidName
+	}
+	if mux == nil {
+		mux = moqt.DefaultMux
+	}
+
+	path := r.BroadcastPath(name)
+	ann, end := moqt.NewAnnouncement(ctx, path)
+
+	p := newLocalParticipant(name, path, ann, end)
+
+	mux.Announce(ann, p)
+
+	return p, nil
+}
+
+// Watch requests the announcements of the room from the remote peer of sess
+// and returns a Watcher reporting participants joining and leaving.
+func (r *Room) Watch(sess *moqt.Session) (*Watcher, error) {
+	ar, err := sess.AcceptAnnounce(r.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWatcher(r, sess, ar), nil
+}
+
+func isValidName(name string) bool {
+	return name != "" && !strings.Contains(name, "/")
+}
@@ -0,0 +1,108 @@
+package room
+
+import (
+	"context"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	r, err := New("/conference/standup")
+	require.NoError(t, err)
+	assert.Equal(t, "/conference/standup/", r.Prefix())
+
+	r, err = New("/conference/")
+	require.NoError(t, err)
+	assert.Equal(t, "/conference/", r.Prefix())
+
+	_, err = New("")
+	assert.ErrorIs(t, err, ErrInvalidRoom)
+
+	_, err = New("conference")
+	assert.ErrorIs(t, err, ErrInvalidRoom)
+}
+
+func TestRoom_BroadcastPath(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	assert.Equal(t, moqt.BroadcastPath("/conference/alice.hang"), r.BroadcastPath("alice"))
+}
+
+func TestRoom_ParticipantName(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		path moqt.BroadcastPath
+		name string
+		ok   bool
+	}{
+		"participant":       {path: "/conference/alice.hang", name: "alice", ok: true},
+		"dotted name":       {path: "/conference/alice.b.hang", name: "alice.b", ok: true},
+		"other room":        {path: "/lobby/alice.hang"},
+		"nested path":       {path: "/conference/sub/alice.hang"},
+		"missing extension": {path: "/conference/alice"},
+		"empty name":        {path: "/conference/.hang"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, ok := r.ParticipantName(tt.path)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.name, got)
+		})
+	}
+}
+
+func TestRoom_Join(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	mux := moqt.NewTrackMux()
+
+	p, err := r.Join(context.Background(), mux, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", p.Name())
+	assert.Equal(t, r.BroadcastPath("alice"), p.BroadcastPath())
+
+	ann, handler := mux.TrackHandler(p.BroadcastPath())
+	require.NotNil(t, ann)
+	assert.Same(t, p, handler)
+
+	p.Leave()
+
+	select {
+	case <-p.Done():
+	default:
+		t.Fatal("participant should be done after Leave")
+	}
+
+	ann, _ = mux.TrackHandler(p.BroadcastPath())
+	assert.Nil(t, ann, "handler should be removed after Leave")
+}
+
+func TestRoom_JoinInvalidName(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	for _, name := range []string{"", "a/b"} {
+		_, err := r.Join(context.Background(), moqt.NewTrackMux(), name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
+
+func TestRoom_JoinContextCanceled(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p, err := r.Join(ctx, moqt.NewTrackMux(), "bob")
+	require.NoError(t, err)
+
+	cancel()
+	<-p.Done()
+}
@@ -0,0 +1,196 @@
+package room
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+)
+
+// EventType describes what happened to a participant.
+type EventType int
+
+const (
+	// Joined reports that a participant started publishing in the room.
+	Joined EventType = iota
+	// Left reports that a participant stopped publishing in the room.
+	Left
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Joined:
+		return "joined"
+	case Left:
+		return "left"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event is a change of the participants in a room.
+type Event struct {
+	Type        EventType
+	Participant *RemoteParticipant
+	Time        time.Time
+}
+
+func newWatcher(room *Room, sess *moqt.Session, ar *moqt.AnnouncementReader) *Watcher {
+	w := &Watcher{
+		room:         room,
+		sess:         sess,
+		reader:       ar,
+		participants: make(map[string]*RemoteParticipant),
+		notifyCh:     make(chan struct{}, 1),
+	}
+
+	if ar != nil {
+		go w.receive()
+	}
+
+	return w
+}
+
+// Watcher tracks the participants of a room as announced by a remote peer.
+// Announcements that do not map to a participant of the room are ignored.
+// A participant who leaves before its Joined event is read produces no event.
+type Watcher struct {
+	room   *Room
+	sess   *moqt.Session
+	reader *moqt.AnnouncementReader
+
+	mu           sync.Mutex
+	participants map[string]*RemoteParticipant
+	pendings     []Event
+	notifyCh     chan struct{}
+}
+
+func (w *Watcher) receive() {
+	ctx := w.reader.Context()
+	for ann := range w.reader.Announcements(ctx) {
+		w.announced(ann)
+	}
+}
+
+func (w *Watcher) announced(ann *moqt.Announcement) {
+	name, ok := w.room.ParticipantName(ann.BroadcastPath())
+	if !ok || !ann.IsActive() {
+		return
+	}
+
+	p := &RemoteParticipant{
+		name:         name,
+		announcement: ann,
+		sess:         w.sess,
+	}
+
+	w.mu.Lock()
+	w.participants[name] = p
+	w.push(Event{Type: Joined, Participant: p, Time: time.Now()})
+	w.mu.Unlock()
+
+	ann.AfterFunc(func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if w.participants[name] == p {
+			delete(w.participants, name)
+		}
+
+		// Drop the Joined event if it was not read yet, so that the events
+		// of participants who already left do not pile up unread
+		if i := slices.IndexFunc(w.pendings, func(e Event) bool { return e.Participant == p }); i >= 0 {
+			w.pendings = slices.Delete(w.pendings, i, i+1)
+			return
+		}
+		w.push(Event{Type: Left, Participant: p, Time: time.Now()})
+	})
+}
+
+// push queues an event. The caller must hold w.mu.
+func (w *Watcher) push(e Event) {
+	w.pendings = append(w.pendings, e)
+
+	select {
+	case w.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// NextEvent blocks until a participant joins or leaves the room, or until
+// ctx or the underlying announce stream is done.
+func (w *Watcher) NextEvent(ctx context.Context) (Event, error) {
+	var done <-chan struct{}
+	if w.reader != nil {
+		done = w.reader.Context().Done()
+	}
+
+	for {
+		w.mu.Lock()
+		if len(w.pendings) > 0 {
+			e := w.pendings[0]
+			w.pendings = w.pendings[1:]
+			w.mu.Unlock()
+			return e, nil
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return Event{}, ctx.Err()
+		case <-done:
+			return Event{}, moqt.Cause(w.reader.Context())
+		case <-w.notifyCh:
+		}
+	}
+}
+
+// Events returns an iterator yielding participant events until ctx or the
+// underlying announce stream is done.
+func (w *Watcher) Events(ctx context.Context) iter.Seq[Event] {
+	return func(yield func(Event) bool) {
+		for {
+			e, err := w.NextEvent(ctx)
+			if err != nil {
+				return
+			}
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// Participants returns the participants currently in the room.
+func (w *Watcher) Participants() []*RemoteParticipant {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	list := make([]*RemoteParticipant, 0, len(w.participants))
+	for _, p := range w.participants {
+		list = append(list, p)
+	}
+
+	return list
+}
+
+// Participant returns the named participant if it is in the room.
+func (w *Watcher) Participant(name string) (*RemoteParticipant, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	p, ok := w.participants[name]
+	return p, ok
+}
+
+// Close stops watching the room.
+func (w *Watcher) Close() error {
+	if w.reader == nil {
+		return nil
+	}
+	return w.reader.Close()
+}
@@ -0,0 +1,140 @@
+package room
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Announced(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	w := newWatcher(r, nil, nil)
+
+	ann, end := moqt.NewAnnouncement(context.Background(), r.BroadcastPath("alice"))
+	w.announced(ann)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	e, err := w.NextEvent(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Joined, e.Type)
+	assert.Equal(t, "alice", e.Participant.Name())
+	assert.True(t, e.Participant.Present())
+
+	p, ok := w.Participant("alice")
+	require.True(t, ok)
+	assert.Same(t, e.Participant, p)
+	assert.Len(t, w.Participants(), 1)
+
+	end()
+
+	e, err = w.NextEvent(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Left, e.Type)
+	assert.Equal(t, "alice", e.Participant.Name())
+	assert.False(t, e.Participant.Present())
+	assert.Empty(t, w.Participants())
+}
+
+func TestWatcher_IgnoresOtherPaths(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	w := newWatcher(r, nil, nil)
+
+	for _, path := range []moqt.BroadcastPath{"/conference/sub/alice.hang", "/conference/alice", "/lobby/bob.hang"} {
+		ann, end := moqt.NewAnnouncement(context.Background(), path)
+		defer end()
+		w.announced(ann)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = w.NextEvent(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, w.Participants())
+}
+
+func TestWatcher_Rejoin(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	w := newWatcher(r, nil, nil)
+
+	first, endFirst := moqt.NewAnnouncement(context.Background(), r.BroadcastPath("alice"))
+	w.announced(first)
+	second, endSecond := moqt.NewAnnouncement(context.Background(), r.BroadcastPath("alice"))
+	defer endSecond()
+	w.announced(second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	var types []EventType
+	for e := range w.Events(ctx) {
+		types = append(types, e.Type)
+		if len(types) == 2 {
+			break
+		}
+	}
+
+	// The stale announcement ending must not remove the newer participant
+	endFirst()
+
+	for e := range w.Events(ctx) {
+		types = append(types, e.Type)
+		break
+	}
+	assert.Equal(t, []EventType{Joined, Joined, Left}, types)
+
+	p, ok := w.Participant("alice")
+	require.True(t, ok)
+	assert.True(t, p.Present())
+}
+
+func TestWatcher_LeftBeforeRead(t *testing.T) {
+	r, err := New("/conference")
+	require.NoError(t, err)
+
+	w := newWatcher(r, nil, nil)
+
+	// Participants joining and leaving without a reader leave no events behind
+	for range 100 {
+		ann, end := moqt.NewAnnouncement(context.Background(), r.BroadcastPath("alice"))
+		w.announced(ann)
+		end()
+	}
+
+	bob, endBob := moqt.NewAnnouncement(context.Background(), r.BroadcastPath("bob"))
+	defer endBob()
+	w.announced(bob)
+
+	// The announcements may run their end handlers on other goroutines
+	require.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return len(w.pendings) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	e, err := w.NextEvent(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Joined, e.Type)
+	assert.Equal(t, "bob", e.Participant.Name())
+}
+
+func TestEventType_String(t *testing.T) {
+	assert.Equal(t, "joined", Joined.String())
+	assert.Equal(t, "left", Left.String())
+	assert.Equal(t, "EventType(7)", EventType(7).String())
+}
@@ -15,6 +15,8 @@ export interface SubscribeMessageInit {
 	broadcastPath?: string;
 	trackName?: string;
 	trackPriority?: number;
+	startGroup?: number;
+	deliveryTimeout?: number;
 }
 
 export class SubscribeMessage {
@@ -22,24 +24,40 @@ export class SubscribeMessage {
 	broadcastPath: string;
 	trackName: string;
 	trackPriority: number;
+	/**
+	 * The first group the publisher sends. Omitted from the wire when 0.
+	 */
+	startGroup: number;
+	/**
+	 * The delivery timeout in milliseconds. Omitted from the wire when 0;
+	 * the start group is then written even when 0 so that it precedes it.
+	 */
+	deliveryTimeout: number;
 
 	constructor(init: SubscribeMessageInit = {}) {
 		this.subscribeId = init.subscribeId ?? 0;
 		this.broadcastPath = init.broadcastPath ?? "";
 		this.trackName = init.trackName ?? "";
 		this.trackPriority = init.trackPriority ?? 0;
+		this.startGroup = init.startGroup ?? 0;
+		this.deliveryTimeout = init.deliveryTimeout ?? 0;
 	}
 
 	/**
 	 * Returns the length of the message body (excluding the length prefix).
 	 */
 	get len(): number {
-		return (
-			varintLen(this.subscribeId) +
+		let len = varintLen(this.subscribeId) +
 			stringLen(this.broadcastPath) +
 			stringLen(this.trackName) +
-			varintLen(this.trackPriority)
-		);
+			varintLen(this.trackPriority);
+		if (this.startGroup !== 0 || this.deliveryTimeout !== 0) {
+			len += varintLen(this.startGroup);
+		}
+		if (this.deliveryTimeout !== 0) {
+			len += varintLen(this.deliveryTimeout);
+		}
+		return len;
 	}
 
 	/**
@@ -65,6 +83,16 @@ export class SubscribeMessage {
 		[, err] = await writeVarint(w, this.trackPriority);
 		if (err) return err;
 
+		if (this.startGroup !== 0 || this.deliveryTimeout !== 0) {
+			[, err] = await writeVarint(w, this.startGroup);
+			if (err) return err;
+		}
+
+		if (this.deliveryTimeout !== 0) {
+			[, err] = await writeVarint(w, this.deliveryTimeout);
+			if (err) return err;
+		}
+
 		return undefined;
 	}
 
@@ -108,6 +136,21 @@ export class SubscribeMessage {
 		this.trackPriority = trackPriority;
 		offset += n4;
 
+		// startGroup and deliveryTimeout are optional and trail the required fields
+		this.startGroup = 0;
+		if (offset < buf.length) {
+			const [startGroup, n5] = parseVarint(buf, offset);
+			this.startGroup = startGroup;
+			offset += n5;
+		}
+
+		this.deliveryTimeout = 0;
+		if (offset < buf.length) {
+			const [deliveryTimeout, n6] = parseVarint(buf, offset);
+			this.deliveryTimeout = deliveryTimeout;
+			offset += n6;
+		}
+
 		return undefined;
 	}
 }
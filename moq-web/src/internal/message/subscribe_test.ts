@@ -29,6 +29,28 @@ Deno.test("SubscribeMessage - encode/decode roundtrip - multiple scenarios", asy
 			trackName: "b",
 			trackPriority: 1,
 		},
+		"with start group": {
+			subscribeId: 2,
+			broadcastPath: "path",
+			trackName: "track",
+			trackPriority: 1,
+			startGroup: 42,
+		},
+		"with delivery timeout": {
+			subscribeId: 3,
+			broadcastPath: "path",
+			trackName: "track",
+			trackPriority: 1,
+			deliveryTimeout: 500,
+		},
+		"with start group and delivery timeout": {
+			subscribeId: 4,
+			broadcastPath: "path",
+			trackName: "track",
+			trackPriority: 1,
+			startGroup: 42,
+			deliveryTimeout: 500,
+		},
 	};
 
 	for (const [caseName, input] of Object.entries(testCases)) {
@@ -67,9 +89,39 @@ Deno.test("SubscribeMessage - encode/decode roundtrip - multiple scenarios", asy
 				input.trackPriority,
 				`trackPriority mismatch for ${caseName}`,
 			);
+			assertEquals(
+				decodedMessage.startGroup,
+				"startGroup" in input ? input.startGroup : 0,
+				`startGroup mismatch for ${caseName}`,
+			);
+			assertEquals(
+				decodedMessage.deliveryTimeout,
+				"deliveryTimeout" in input ? input.deliveryTimeout : 0,
+				`deliveryTimeout mismatch for ${caseName}`,
+			);
 		});
 	}
 
+	await t.step(
+		"delivery timeout should be preceded by a zero start group",
+		async () => {
+			const buffer = Buffer.make(200);
+			const message = new SubscribeMessage({
+				subscribeId: 1,
+				broadcastPath: "/a",
+				trackName: "b",
+				trackPriority: 2,
+				deliveryTimeout: 5,
+			});
+			assertEquals(await message.encode(buffer), undefined);
+			// Same bytes as the Go encoder
+			assertEquals(
+				buffer.bytes(),
+				new Uint8Array([0x09, 0x01, 0x02, 0x2f, 0x61, 0x01, 0x62, 0x02, 0x00, 0x05]),
+			);
+		},
+	);
+
 	await t.step(
 		"decode should return error when readVarint fails for message length",
 		async () => {
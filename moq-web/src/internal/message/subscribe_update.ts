@@ -3,22 +3,30 @@ import { parseVarint, readFull, readVarint, varintLen, writeVarint } from "./mes
 
 export interface SubscribeUpdateMessageInit {
 	trackPriority?: number;
+	deliveryTimeout?: number;
 }
 
 export class SubscribeUpdateMessage {
 	trackPriority: number;
+	/**
+	 * The delivery timeout in milliseconds, omitted when 0 as in SUBSCRIBE.
+	 */
+	deliveryTimeout: number;
 
 	constructor(init: SubscribeUpdateMessageInit = {}) {
 		this.trackPriority = init.trackPriority ?? 0;
+		this.deliveryTimeout = init.deliveryTimeout ?? 0;
 	}
 
 	/**
 	 * Returns the length of the message body (excluding the length prefix).
 	 */
 	get len(): number {
-		return (
-			varintLen(this.trackPriority)
-		);
+		let len = varintLen(this.trackPriority);
+		if (this.deliveryTimeout !== 0) {
+			len += varintLen(this.deliveryTimeout);
+		}
+		return len;
 	}
 
 	/**
@@ -34,6 +42,11 @@ export class SubscribeUpdateMessage {
 		[, err] = await writeVarint(w, this.trackPriority);
 		if (err) return err;
 
+		if (this.deliveryTimeout !== 0) {
+			[, err] = await writeVarint(w, this.deliveryTimeout);
+			if (err) return err;
+		}
+
 		return undefined;
 	}
 
@@ -55,6 +68,12 @@ export class SubscribeUpdateMessage {
 			return [val, offset + n];
 		})();
 
+		// deliveryTimeout is optional
+		this.deliveryTimeout = 0;
+		if (offset < buf.length) {
+			[this.deliveryTimeout] = parseVarint(buf, offset);
+		}
+
 		return undefined;
 	}
 }
@@ -16,6 +16,10 @@ Deno.test("SubscribeUpdateMessage - encode/decode roundtrip - multiple scenarios
 		"mid priority": {
 			trackPriority: 10,
 		},
+		"with delivery timeout": {
+			trackPriority: 10,
+			deliveryTimeout: 300,
+		},
 	};
 
 	for (const [caseName, input] of Object.entries(testCases)) {
@@ -37,6 +41,11 @@ Deno.test("SubscribeUpdateMessage - encode/decode roundtrip - multiple scenarios
 				input.trackPriority,
 				`trackPriority mismatch for ${caseName}`,
 			);
+			assertEquals(
+				decodedMessage.deliveryTimeout,
+				"deliveryTimeout" in input ? input.deliveryTimeout : 0,
+				`deliveryTimeout mismatch for ${caseName}`,
+			);
 		});
 	}
 
@@ -38,8 +38,10 @@ func newAnnouncementReader(stream quic.Stream, prefix prefix, initSuffixes []suf
 		for {
 			err = am.Decode(ar.stream)
 			if err != nil {
+				_ = checkMessageSize(ar.stream, err)
 				return
 			}
+			traceMessageParsed(ar.stream, am)
 
 			slog.Debug("received announce message", "message", am)
 
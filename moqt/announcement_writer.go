@@ -3,6 +3,7 @@ package moqt
 import (
 	"context"
 	"errors"
+	"io"
 	"log/slog"
 	"sync"
 
@@ -67,9 +68,9 @@ func (aw *AnnouncementWriter) init(announcements map[*Announcement]struct{}) err
 			suffixes = append(suffixes, sfx)
 		}
 
-		err = message.AnnounceInitMessage{
+		err = aw.writeMessage(message.AnnounceInitMessage{
 			Suffixes: suffixes,
-		}.Encode(aw.stream)
+		})
 		if err != nil {
 			var strErr *quic.StreamError
 			if errors.As(err, &strErr) {
@@ -89,6 +90,16 @@ func (aw *AnnouncementWriter) init(announcements map[*Announcement]struct{}) err
 	return err
 }
 
+// writeMessage writes msg on the announce stream.
+func (aw *AnnouncementWriter) writeMessage(msg interface{ Encode(io.Writer) error }) error {
+	err := msg.Encode(aw.stream)
+	if err != nil {
+		return err
+	}
+	traceMessageCreated(aw.stream, msg)
+	return nil
+}
+
 // registerEndHandler registers handlers for when the announcement ends.
 // It sets up AfterFunc to clean up when the announcement becomes inactive.
 // Caller MUST hold aw.mu.
@@ -99,10 +110,10 @@ func (aw *AnnouncementWriter) registerEndHandler(sfx suffix, ann *Announcement)
 		current, exists := aw.actives[sfx]
 		if exists && current.announcement == ann {
 			delete(aw.actives, sfx)
-			if err := (message.AnnounceMessage{
+			if err := aw.writeMessage(message.AnnounceMessage{
 				AnnounceStatus: message.ENDED,
 				TrackSuffix:    sfx,
-			}).Encode(aw.stream); err != nil {
+			}); err != nil {
 				var strErr *quic.StreamError
 				if errors.As(err, &strErr) {
 					slog.Error("failed to write announce ended message", "error", err, "suffix", sfx, "stream_error", strErr.Error())
@@ -120,10 +131,10 @@ func (aw *AnnouncementWriter) registerEndHandler(sfx suffix, ann *Announcement)
 		aw.mu.Lock()
 		defer aw.mu.Unlock()
 		delete(aw.actives, sfx)
-		if err := (message.AnnounceMessage{
+		if err := aw.writeMessage(message.AnnounceMessage{
 			AnnounceStatus: message.ENDED,
 			TrackSuffix:    sfx,
-		}).Encode(aw.stream); err != nil {
+		}); err != nil {
 			var strErr *quic.StreamError
 			if errors.As(err, &strErr) {
 				slog.Error("failed to write announce ended message (end handler)", "error", err, "suffix", sfx, "stream_error", strErr.Error())
@@ -179,10 +190,10 @@ func (aw *AnnouncementWriter) SendAnnouncement(announcement *Announcement) error
 	defer aw.mu.Unlock()
 
 	// Encode and send ACTIVE announcement
-	err := message.AnnounceMessage{
+	err := aw.writeMessage(message.AnnounceMessage{
 		AnnounceStatus: message.ACTIVE,
 		TrackSuffix:    suffix,
-	}.Encode(aw.stream)
+	})
 	if err != nil {
 		var strErr *quic.StreamError
 		if errors.As(err, &strErr) {
@@ -0,0 +1,105 @@
+package moqt
+
+// NewBufferedGroupWriter returns a BufferedGroupWriter writing to group
+// with a buffer of size bytes. If size is not positive, a 32 KiB buffer
+// is taken from a pool and returned by Close.
+func NewBufferedGroupWriter(group *GroupWriter, size int) *BufferedGroupWriter {
+	if size <= 0 {
+		bp := copyBufferPool.Get().(*[]byte)
+		return &BufferedGroupWriter{
+			group:  group,
+			buf:    (*bp)[:0],
+			pooled: bp,
+		}
+	}
+	return &BufferedGroupWriter{
+		group: group,
+		buf:   make([]byte, 0, size),
+	}
+}
+
+// BufferedGroupWriter coalesces the frames written to a group, so that
+// many small frames go out in a single stream write.
+// Frames are copied into the buffer once; frames larger than the buffer
+// are written directly.
+// Call Flush to send the buffered frames, or Close to flush and close
+// the group.
+type BufferedGroupWriter struct {
+	group  *GroupWriter
+	buf    []byte
+	pooled *[]byte
+
+	// sizes holds the payload sizes of the buffered frames,
+	// which are counted once they are flushed.
+	sizes []int
+}
+
+// WriteFrame buffers the frame, writing the buffer first if the frame
+// does not fit. The frame can be reused as soon as WriteFrame returns.
+func (w *BufferedGroupWriter) WriteFrame(frame *Frame) error {
+	if frame == nil {
+		return nil
+	}
+
+	b := frame.wire()
+	if len(w.buf)+len(b) > cap(w.buf) {
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(b) > cap(w.buf) {
+		if _, err := w.group.stream.Write(b); err != nil {
+			return err
+		}
+		w.group.frameWritten(frame.Len())
+		return nil
+	}
+
+	w.buf = append(w.buf, b...)
+	w.sizes = append(w.sizes, frame.Len())
+
+	return nil
+}
+
+// Flush writes the buffered frames to the group stream.
+func (w *BufferedGroupWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	_, err := w.group.stream.Write(w.buf)
+	if err == nil {
+		for _, size := range w.sizes {
+			w.group.frameWritten(size)
+		}
+	}
+	w.buf = w.buf[:0]
+	w.sizes = w.sizes[:0]
+	return err
+}
+
+// Buffered returns the number of bytes buffered.
+func (w *BufferedGroupWriter) Buffered() int {
+	return len(w.buf)
+}
+
+// Close flushes the buffered frames and closes the group.
+// If the frames cannot be flushed, the group is canceled with
+// InternalGroupErrorCode instead, as it would end incomplete.
+func (w *BufferedGroupWriter) Close() error {
+	err := w.Flush()
+
+	if w.pooled != nil {
+		copyBufferPool.Put(w.pooled)
+		w.pooled = nil
+		w.buf = nil
+	}
+
+	if err != nil {
+		w.group.CancelWrite(InternalGroupErrorCode)
+		return err
+	}
+
+	return w.group.Close()
+}
@@ -0,0 +1,111 @@
+package moqt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// countingWriter counts the writes to it.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func testFrames(sizes ...int) ([]*Frame, []byte) {
+	var wire bytes.Buffer
+	frames := make([]*Frame, len(sizes))
+	for i, size := range sizes {
+		frames[i] = NewFrame(size)
+		_, _ = frames[i].Write(bytes.Repeat([]byte{byte(i)}, size))
+		_ = frames[i].encode(&wire)
+	}
+	return frames, wire.Bytes()
+}
+
+func TestBufferedGroupWriter(t *testing.T) {
+	frames, want := testFrames(10, 20, 30)
+
+	var w countingWriter
+	gw := newGroupWriter(&mockSendStream{Writer: &w}, GroupSequence(1), func() {})
+	bw := NewBufferedGroupWriter(gw, 128)
+
+	for _, f := range frames {
+		require.NoError(t, bw.WriteFrame(f))
+	}
+	assert.Zero(t, w.writes, "frames must be buffered until Flush")
+	assert.Equal(t, len(want), bw.Buffered())
+	assert.Zero(t, gw.frameCount.Load(), "frames must be counted once flushed")
+
+	require.NoError(t, bw.Flush())
+	assert.Equal(t, 1, w.writes)
+	assert.Equal(t, want, w.Bytes())
+	assert.Zero(t, bw.Buffered())
+	assert.Equal(t, uint64(3), gw.frameCount.Load())
+
+	require.NoError(t, bw.Close())
+	assert.Equal(t, 1, w.writes, "Close must not write an empty buffer")
+}
+
+func TestBufferedGroupWriter_Overflow(t *testing.T) {
+	// The second frame does not fit with the first one
+	// and the third one does not fit in the buffer at all
+	frames, want := testFrames(40, 40, 100)
+
+	var w countingWriter
+	gw := newGroupWriter(&mockSendStream{Writer: &w}, GroupSequence(1), func() {})
+	bw := NewBufferedGroupWriter(gw, 64)
+
+	for _, f := range frames {
+		require.NoError(t, bw.WriteFrame(f))
+	}
+	require.NoError(t, bw.Flush())
+
+	assert.Equal(t, want, w.Bytes())
+	assert.Equal(t, 3, w.writes)
+}
+
+func TestBufferedGroupWriter_CloseFlushError(t *testing.T) {
+	frames, _ := testFrames(10)
+
+	mockStream := &MockQUICSendStream{}
+	mockStream.On("Context").Return(context.Background())
+	mockStream.On("Write", mock.Anything).Return(0, errors.New("write failed"))
+	mockStream.On("CancelWrite", quic.StreamErrorCode(InternalGroupErrorCode)).Return()
+
+	gw := newGroupWriter(mockStream, GroupSequence(1), func() {})
+	bw := NewBufferedGroupWriter(gw, 0)
+	require.NoError(t, bw.WriteFrame(frames[0]))
+
+	assert.Error(t, bw.Close())
+	assert.Nil(t, bw.pooled, "the pooled buffer must be returned")
+	assert.Zero(t, gw.frameCount.Load())
+	mockStream.AssertExpectations(t)
+	mockStream.AssertNotCalled(t, "Close")
+}
+
+func TestGroupWriter_WriteFrames(t *testing.T) {
+	frames, want := testFrames(1, 2, 3, 0, 5)
+
+	var w countingWriter
+	gw := newGroupWriter(&mockSendStream{Writer: &w}, GroupSequence(1), func() {})
+
+	require.NoError(t, gw.WriteFrames(append(frames, nil)))
+	assert.Equal(t, want, w.Bytes())
+	assert.Equal(t, 1, w.writes)
+	assert.Equal(t, uint64(5), gw.frameCount.Load())
+
+	require.NoError(t, gw.WriteFrames(nil))
+	assert.Equal(t, 1, w.writes)
+}
@@ -8,12 +8,15 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/okdaichi/gomoqt/moqt/internal/message"
 	"github.com/okdaichi/gomoqt/quic"
 	"github.com/okdaichi/gomoqt/quic/quicgo"
+	"github.com/okdaichi/gomoqt/quic/quicmux"
 	"github.com/okdaichi/gomoqt/webtransport"
 	"github.com/okdaichi/gomoqt/webtransport/webtransportgo"
 )
@@ -50,6 +53,27 @@ type Client struct {
 	 */
 	DialWebTransportFunc webtransport.DialAddrFunc
 
+	/*
+	 * Dial WebSocket function
+	 */
+	DialWebSocketFunc func(ctx context.Context, urlStr string, header http.Header, tlsConfig *tls.Config, quicConfig *quic.Config) (*http.Response, quic.Connection, error)
+
+	/*
+	 * Dial TLS over TCP function
+	 */
+	DialTCPFunc quic.DialAddrFunc
+
+	/*
+	 * Session pool
+	 */
+	// PoolIdleTimeout is how long DialPooled keeps a session open after
+	// its last reference is released. Defaults to DefaultPoolIdleTimeout.
+	PoolIdleTimeout time.Duration
+
+	// PoolHealthCheck, if set, is called before DialPooled hands out a pooled
+	// session. Sessions for which it returns an error are evicted.
+	PoolHealthCheck func(*Session) error
+
 	/*
 	 * Logger
 	 */
@@ -58,6 +82,9 @@ type Client struct {
 	//
 	initOnce sync.Once
 
+	poolMu sync.Mutex
+	pool   map[poolKey]*poolEntry
+
 	sessMu     sync.RWMutex
 	activeSess map[*Session]struct{}
 
@@ -77,16 +104,12 @@ func (c *Client) init() {
 	})
 }
 
-// Dial establishes a new session to the specified URL using either WebTransport (https scheme) or QUIC (moqt scheme).
+// Dial establishes a new session to the specified URL using WebTransport (https scheme), QUIC (moqt scheme)
+// or a WebSocket (ws and wss schemes).
 // The provided TrackMux is used to route incoming service tracks if non-nil.
 // Dial returns the newly created Session or an error.
 func (c *Client) Dial(ctx context.Context, urlStr string, mux *TrackMux) (*Session, error) {
-	var logger *slog.Logger
-	if c.Logger != nil {
-		logger = c.Logger
-	} else {
-		logger = slog.New(slog.DiscardHandler)
-	}
+	logger := c.logger()
 
 	if c.shuttingDown() {
 		logger.Warn("dial rejected: client shutting down")
@@ -106,6 +129,8 @@ func (c *Client) Dial(ctx context.Context, urlStr string, mux *TrackMux) (*Sessi
 		return c.DialWebTransport(ctx, parsedURL.Hostname()+":"+parsedURL.Port(), parsedURL.Path, mux)
 	case "moqt":
 		return c.DialQUIC(ctx, parsedURL.Hostname()+":"+parsedURL.Port(), parsedURL.Path, mux)
+	case "ws", "wss":
+		return c.DialWebSocket(ctx, urlStr, mux)
 	default:
 		logger.Error("unsupported URL scheme", "scheme", parsedURL.Scheme)
 		return nil, ErrInvalidScheme
@@ -123,66 +148,61 @@ func generateSessionID() string {
 // It performs the WebTransport handshake and initializes a MOQ session stream.
 // `host` should be host:port and `path` is the path used for session setup.
 func (c *Client) DialWebTransport(ctx context.Context, host, path string, mux *TrackMux) (*Session, error) {
-	var clientLogger *slog.Logger
-	if c.Logger != nil {
-		clientLogger = c.Logger.With(
-			"host", host,
-		)
-	} else {
-		clientLogger = slog.New(slog.DiscardHandler)
-
-	}
+	logger := c.logger().With("host", host)
 
-	if c.shuttingDown() {
-		clientLogger.Warn("WebTransport dial rejected: client shutting down")
-		return nil, ErrClientClosed
-	}
-
-	c.init()
-
-	dialTimeout := c.Config.setupTimeout()
-	dialCtx, cancelDial := context.WithTimeout(ctx, dialTimeout)
-	defer cancelDial()
-
-	clientLogger.Debug("dialing WebTransport")
-
-	var conn quic.Connection
-	var err error
-
-	if c.DialWebTransportFunc != nil {
-		_, conn, err = c.DialWebTransportFunc(dialCtx, host+path, http.Header{}, c.TLSConfig)
-	} else {
-		_, conn, err = webtransportgo.Dial(dialCtx, "https://"+host+path, http.Header{}, c.TLSConfig)
-	}
-
-	if err != nil {
-		clientLogger.Error("WebTransport dial failed", "error", err)
-		return nil, err
-	}
-
-	connLogger := clientLogger.With(
-		"transport", "webtransport",
-		"local_address", conn.LocalAddr(),
-		"remote_address", conn.RemoteAddr(),
-		"quic_version", conn.ConnectionState().Version,
-		"alpn", conn.ConnectionState().TLS.NegotiatedProtocol,
-	)
+	return c.dial(ctx, "WebTransport", path, webTransportExtensions(), mux, logger,
+		func(ctx context.Context) (quic.Connection, error) {
+			if c.DialWebTransportFunc != nil {
+				_, conn, err := c.DialWebTransportFunc(ctx, host+path, http.Header{}, c.TLSConfig)
+				return conn, err
+			}
+			_, conn, err := webtransportgo.Dial(ctx, "https://"+host+path, http.Header{}, c.TLSConfig)
+			return conn, err
+		})
+}
 
-	connLogger.Info("WebTransport connection established")
+// DialWebSocket establishes a new session over a connection multiplexed
+// on a WebSocket, for networks where UDP is blocked. `urlStr` is a ws or wss
+// URL whose path is used for session setup. The server must handle the
+// request with Server.HandleWebSocket.
+func (c *Client) DialWebSocket(ctx context.Context, urlStr string, mux *TrackMux) (*Session, error) {
+	logger := c.logger().With("url", urlStr)
 
-	sessStream, err := openSessionStream(conn, path, webTransportExtensions(), connLogger)
+	parsedURL, err := url.Parse(urlStr)
 	if err != nil {
-		connLogger.Error("session establishment failed", "error", err)
+		logger.Error("URL parsing failed", "error", err)
 		return nil, err
 	}
 
-	var sess *Session
-	sess = newSession(conn, sessStream, mux, connLogger, func() { c.removeSession(sess) })
-	c.addSession(sess)
+	// The subprotocol is negotiated in place of ALPN
+	header := http.Header{"Sec-WebSocket-Protocol": {NextProtoMOQ}}
 
-	connLogger.Info("moq: established a new session over WebTransport successfully")
+	return c.dial(ctx, "WebSocket", parsedURL.Path, webTransportExtensions(), mux, logger,
+		func(ctx context.Context) (quic.Connection, error) {
+			if c.DialWebSocketFunc != nil {
+				_, conn, err := c.DialWebSocketFunc(ctx, urlStr, header, c.TLSConfig, c.QUICConfig)
+				return conn, err
+			}
+			_, conn, err := quicmux.DialWebSocket(ctx, urlStr, header, c.TLSConfig, c.QUICConfig)
+			return conn, err
+		})
+}
 
-	return sess, nil
+// DialTCP establishes a new session over a connection multiplexed on
+// TLS over TCP, for networks where UDP is blocked. As with DialQUIC, `addr`
+// is host:port, `path` is sent in the session setup and the application
+// protocol is negotiated with the ALPN of TLSConfig. The server must serve
+// the connections with Server.ServeQUICListener and quicmux.ListenAddr.
+func (c *Client) DialTCP(ctx context.Context, addr, path string, mux *TrackMux) (*Session, error) {
+	logger := c.logger().With("address", addr)
+
+	return c.dial(ctx, "TCP", path, quicExtensions(path), mux, logger,
+		func(ctx context.Context) (quic.Connection, error) {
+			if c.DialTCPFunc != nil {
+				return c.DialTCPFunc(ctx, addr, c.TLSConfig, c.QUICConfig)
+			}
+			return quicmux.DialAddr(ctx, addr, c.TLSConfig, c.QUICConfig)
+		})
 }
 
 // TODO: Expose this method if QUIC is supported
@@ -190,63 +210,76 @@ func (c *Client) DialWebTransport(ctx context.Context, host, path string, mux *T
 // address and negotiating a session stream. This uses the QUIC dial function
 // configured on the Client (DialQUICFunc) if present.
 func (c *Client) DialQUIC(ctx context.Context, addr, path string, mux *TrackMux) (*Session, error) {
+	logger := c.logger().With("address", addr)
+
+	return c.dial(ctx, "QUIC", path, quicExtensions(path), mux, logger,
+		func(ctx context.Context) (quic.Connection, error) {
+			if c.DialQUICFunc != nil {
+				return c.DialQUICFunc(ctx, addr, c.TLSConfig, c.QUICConfig)
+			}
+			return quicgo.DialAddrEarly(ctx, addr, c.TLSConfig, c.QUICConfig)
+		})
+}
+
+// dial establishes a session over the connection returned by dialConn
+// within the setup timeout. transport names the transport in the logs.
+func (c *Client) dial(ctx context.Context, transport, path string, extensions *Extension, mux *TrackMux,
+	logger *slog.Logger, dialConn func(context.Context) (quic.Connection, error)) (*Session, error) {
 	if c.shuttingDown() {
+		logger.Warn(transport + " dial rejected: client shutting down")
 		return nil, ErrClientClosed
 	}
 
 	c.init()
 
-	var clientLogger *slog.Logger
-	if c.Logger == nil {
-		clientLogger = slog.New(slog.DiscardHandler)
-	} else {
-		clientLogger = c.Logger
-	}
-
-	dialTimeout := c.Config.setupTimeout()
-	dialCtx, cancelDial := context.WithTimeout(ctx, dialTimeout)
+	dialCtx, cancelDial := context.WithTimeout(ctx, c.Config.setupTimeout())
 	defer cancelDial()
 
-	var conn quic.Connection
-	var err error
-
-	if c.DialQUICFunc != nil {
-		clientLogger.Debug("using custom QUIC dial function")
-		conn, err = c.DialQUICFunc(dialCtx, addr, c.TLSConfig, c.QUICConfig)
-	} else {
-		clientLogger.Debug("using default QUIC dial function")
-		conn, err = quicgo.DialAddrEarly(dialCtx, addr, c.TLSConfig, c.QUICConfig)
-	}
+	logger.Debug("dialing " + transport)
 
+	conn, err := dialConn(dialCtx)
 	if err != nil {
-		clientLogger.Error("QUIC connection failed", "error", err)
+		logger.Error(transport+" dial failed", "error", err)
 		return nil, err
 	}
 
-	connLogger := clientLogger.With(
-		"transport", "quic",
+	connLogger := logger.With(
+		"transport", strings.ToLower(transport),
 		"local_address", conn.LocalAddr(),
 		"remote_address", conn.RemoteAddr(),
 		"quic_version", conn.ConnectionState().Version,
 		"alpn", conn.ConnectionState().TLS.NegotiatedProtocol,
 	)
-	// TODO: Add connection ID
 
-	connLogger.Info("QUIC connection established")
+	connLogger.Info(transport + " connection established")
 
-	sessStream, err := openSessionStream(conn, path, quicExtensions(path), connLogger)
+	conn = newSessionConn(conn, c.Config, RoleClient)
+
+	sessStream, err := openSessionStream(conn, path, extensions, connLogger)
 	if err != nil {
-		connLogger.Error("failed to open session stream", "error", err)
+		connLogger.Error("session establishment failed", "error", err)
 		return nil, err
 	}
 
+	sessStream.metrics, sessStream.role = c.Config.metrics(), RoleClient
+
 	var sess *Session
 	sess = newSession(conn, sessStream, mux, connLogger, func() { c.removeSession(sess) })
 	c.addSession(sess)
 
+	connLogger.Info("moq: established a new session over " + transport + " successfully")
+
 	return sess, nil
 }
 
+// logger returns the logger of the client, discarding logs if it is not set.
+func (c *Client) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.New(slog.DiscardHandler)
+}
+
 func quicExtensions(path string) *Extension {
 	params := NewExtension()
 
@@ -288,6 +321,8 @@ func openSessionStream(
 		return nil, err
 	}
 
+	traceStreamType(stream, ownerLocal, message.StreamTypeSession)
+
 	streamLogger.Debug("moq: opened session stream")
 
 	versions := make([]uint64, len(DefaultClientVersions))
@@ -306,6 +341,7 @@ func openSessionStream(
 		)
 		return nil, err
 	}
+	traceMessageCreated(stream, scm)
 
 	req := &SetupRequest{
 		Path:             path,
@@ -384,6 +420,7 @@ func (c *Client) Close() error {
 	}
 
 	c.sessMu.Lock()
+	active := len(c.activeSess)
 	for sess := range c.activeSess {
 		go func(sess *Session) {
 			_ = sess.CloseWithError(NoError, SessionErrorText(NoError))
@@ -396,7 +433,7 @@ func (c *Client) Close() error {
 	}
 
 	// Wait for active connections to complete if any
-	if len(c.activeSess) > 0 {
+	if active > 0 {
 		<-c.doneChan
 	}
 
@@ -0,0 +1,236 @@
+package moqt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultPoolIdleTimeout is how long a pooled session is kept open
+// after its last reference is released, if Client.PoolIdleTimeout is not set.
+const DefaultPoolIdleTimeout = 30 * time.Second
+
+// poolKey identifies the sessions that can be shared.
+type poolKey struct {
+	url       string
+	tlsConfig *tls.Config
+	mux       *TrackMux
+}
+
+// poolEntry is a pooled session and its references.
+type poolEntry struct {
+	key poolKey
+
+	// ready is closed once the dial completes, setting sess or err.
+	ready chan struct{}
+	sess  *Session
+	err   error
+
+	refs      int
+	idleTimer *time.Timer
+}
+
+// PooledSession is a Session shared through the Client's session pool.
+// Call Release instead of CloseWithError when done with it; the session is
+// closed once it has been idle for Client.PoolIdleTimeout.
+type PooledSession struct {
+	*Session
+
+	release sync.Once
+	client  *Client
+	entry   *poolEntry
+}
+
+// Release returns the session to the pool. It is safe to call multiple times.
+func (s *PooledSession) Release() {
+	s.release.Do(func() {
+		s.client.releaseEntry(s.entry)
+	})
+}
+
+// DialPooled returns a session to urlStr from the Client's session pool,
+// dialing it with Dial if the pool has no healthy session for the same URL,
+// TLS configuration and TrackMux. Concurrent calls for the same key share
+// a single dial, bounded by the setup timeout of the Client; canceling ctx
+// stops waiting for it without canceling it for the other callers.
+// Each PooledSession must be released with Release.
+//
+// A pooled session is healthy while it is open and not going away, and
+// passes Client.PoolHealthCheck if set. Sessions failing the check are
+// evicted from the pool.
+//
+// Pooling is a separate method rather than a Client option for Dial because
+// it changes who owns the session: the caller of Dial owns its Session and
+// may close it, while a pooled session is shared and only the pool closes it,
+// once every holder has released it. Returning a PooledSession puts Release
+// in the signature, so that callers release the session instead of closing
+// it under the other holders.
+func (c *Client) DialPooled(ctx context.Context, urlStr string, mux *TrackMux) (*PooledSession, error) {
+	if c.shuttingDown() {
+		return nil, ErrClientClosed
+	}
+	c.init()
+
+	if mux == nil {
+		mux = DefaultMux
+	}
+	key := poolKey{url: urlStr, tlsConfig: c.TLSConfig, mux: mux}
+
+	for {
+		e, dial := c.acquireEntry(key)
+		if dial {
+			// The dial is shared by every caller waiting for e, so it
+			// outlives the context of the caller that started it
+			dialCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.Config.setupTimeout())
+			go func() {
+				defer cancel()
+				c.dialEntry(dialCtx, e, urlStr, mux)
+			}()
+		}
+
+		select {
+		case <-e.ready:
+		case <-ctx.Done():
+			c.releaseEntry(e)
+			return nil, ctx.Err()
+		}
+
+		if e.err != nil {
+			c.releaseEntry(e)
+			return nil, e.err
+		}
+
+		if !dial {
+			if err := c.checkHealth(e.sess); err != nil {
+				c.evictEntry(e)
+				c.releaseEntry(e)
+				continue
+			}
+		}
+
+		return &PooledSession{Session: e.sess, client: c, entry: e}, nil
+	}
+}
+
+// acquireEntry references the pooled entry for key,
+// adding a new one if there is none. It reports whether the caller has to dial.
+func (c *Client) acquireEntry(key poolKey) (*poolEntry, bool) {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+
+	if c.pool == nil {
+		c.pool = make(map[poolKey]*poolEntry)
+	}
+
+	if e, ok := c.pool[key]; ok {
+		e.refs++
+		if e.idleTimer != nil {
+			e.idleTimer.Stop()
+			e.idleTimer = nil
+		}
+		return e, false
+	}
+
+	e := &poolEntry{
+		key:   key,
+		ready: make(chan struct{}),
+		refs:  1,
+	}
+	c.pool[key] = e
+
+	return e, true
+}
+
+// dialEntry dials the session of e and wakes up the callers waiting for it.
+func (c *Client) dialEntry(ctx context.Context, e *poolEntry, urlStr string, mux *TrackMux) {
+	sess, err := c.Dial(ctx, urlStr, mux)
+
+	c.poolMu.Lock()
+	e.sess, e.err = sess, err
+	if err != nil {
+		if c.pool[e.key] == e {
+			delete(c.pool, e.key)
+		}
+	} else if e.refs == 0 {
+		// Every caller stopped waiting before the dial completed
+		c.idle(e)
+	}
+	c.poolMu.Unlock()
+
+	if err == nil {
+		// Sessions that end are evicted so that the next call redials
+		context.AfterFunc(sess.Context(), func() { c.evictEntry(e) })
+	}
+	close(e.ready)
+}
+
+// evictEntry removes e from the pool so that it is not handed out anymore.
+func (c *Client) evictEntry(e *poolEntry) {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+
+	if c.pool[e.key] == e {
+		delete(c.pool, e.key)
+	}
+}
+
+// releaseEntry drops a reference to e. The session of an unreferenced entry
+// is closed after the idle timeout, or immediately if e was evicted.
+func (c *Client) releaseEntry(e *poolEntry) {
+	c.poolMu.Lock()
+	defer c.poolMu.Unlock()
+
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+
+	// A session still being dialed is handled once the dial completes
+	if e.sess == nil {
+		return
+	}
+
+	c.idle(e)
+}
+
+// idle closes the session of the unreferenced entry e after the idle
+// timeout, or immediately if e was evicted. The caller must hold c.poolMu.
+func (c *Client) idle(e *poolEntry) {
+	if c.pool[e.key] != e {
+		go closePooledSession(e.sess)
+		return
+	}
+
+	idleTimeout := c.PoolIdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = DefaultPoolIdleTimeout
+	}
+	e.idleTimer = time.AfterFunc(idleTimeout, func() {
+		c.poolMu.Lock()
+		if e.refs > 0 || c.pool[e.key] != e {
+			c.poolMu.Unlock()
+			return
+		}
+		delete(c.pool, e.key)
+		c.poolMu.Unlock()
+
+		closePooledSession(e.sess)
+	})
+}
+
+func closePooledSession(sess *Session) {
+	_ = sess.CloseWithError(NoError, SessionErrorText(NoError))
+}
+
+// checkHealth reports why sess cannot be handed out anymore.
+func (c *Client) checkHealth(sess *Session) error {
+	if sess.Context().Err() != nil || sess.terminating() {
+		return fmt.Errorf("moq: pooled session closed: %w", ErrClosedSession)
+	}
+	if c.PoolHealthCheck != nil {
+		return c.PoolHealthCheck(sess)
+	}
+	return nil
+}
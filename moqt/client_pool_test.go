@@ -0,0 +1,225 @@
+package moqt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (d *memoryDialer) dials() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.conns)
+}
+
+func TestClient_DialPooled_SharesSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	client := &Client{DialQUICFunc: d.dial}
+	defer client.Close()
+
+	mux := NewTrackMux()
+
+	var wg sync.WaitGroup
+	sessions := make([]*PooledSession, 8)
+	for i := range sessions {
+		wg.Go(func() {
+			sess, err := client.DialPooled(ctx, "moqt://memory:0/", mux)
+			assert.NoError(t, err)
+			sessions[i] = sess
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, d.dials())
+	for _, sess := range sessions {
+		require.NotNil(t, sess)
+		assert.Same(t, sessions[0].Session, sess.Session)
+	}
+
+	// A different mux gets its own session
+	other, err := client.DialPooled(ctx, "moqt://memory:0/", NewTrackMux())
+	require.NoError(t, err)
+	defer other.Release()
+	assert.NotSame(t, sessions[0].Session, other.Session)
+	assert.Equal(t, 2, d.dials())
+
+	for _, sess := range sessions {
+		sess.Release()
+	}
+}
+
+func TestClient_DialPooled_IdleEviction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	client := &Client{DialQUICFunc: d.dial, PoolIdleTimeout: 50 * time.Millisecond}
+	defer client.Close()
+
+	first, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	first.Release()
+	first.Release()
+
+	// Reused before the idle timeout
+	second, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	assert.Same(t, first.Session, second.Session)
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, second.Context().Err())
+	second.Release()
+
+	select {
+	case <-second.Context().Done():
+	case <-ctx.Done():
+		t.Fatal("idle session was not closed")
+	}
+
+	third, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer third.Release()
+	assert.NotSame(t, first.Session, third.Session)
+}
+
+func TestClient_DialPooled_ClosedSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	client := &Client{DialQUICFunc: d.dial}
+	defer client.Close()
+
+	first, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer first.Release()
+
+	d.drop(false)
+	<-first.Context().Done()
+
+	second, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer second.Release()
+	assert.NotSame(t, first.Session, second.Session)
+	assert.NoError(t, second.Context().Err())
+}
+
+func TestClient_DialPooled_HealthCheck(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var unhealthy *Session
+	d := newMemoryDialer(t, NewTrackMux())
+	client := &Client{
+		DialQUICFunc: d.dial,
+		PoolHealthCheck: func(sess *Session) error {
+			if sess == unhealthy {
+				return errors.New("unhealthy")
+			}
+			return nil
+		},
+	}
+	defer client.Close()
+
+	first, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	unhealthy = first.Session
+
+	second, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer second.Release()
+	assert.NotSame(t, first.Session, second.Session)
+
+	// The evicted session is closed once released
+	assert.NoError(t, first.Context().Err())
+	first.Release()
+	select {
+	case <-first.Context().Done():
+	case <-ctx.Done():
+		t.Fatal("evicted session was not closed")
+	}
+}
+
+func TestClient_DialPooled_DialError(t *testing.T) {
+	d := newMemoryDialer(t, NewTrackMux())
+	d.setFail(true)
+
+	client := &Client{DialQUICFunc: d.dial}
+	defer client.Close()
+
+	_, err := client.DialPooled(context.Background(), "moqt://memory:0/", nil)
+	assert.Error(t, err)
+
+	d.setFail(false)
+	sess, err := client.DialPooled(context.Background(), "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	sess.Release()
+}
+
+func TestClient_DialPooled_WaiterCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	client := &Client{
+		DialQUICFunc: func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+			close(started)
+			<-unblock
+			return d.dial(ctx, addr, tlsConfig, quicConfig)
+		},
+	}
+	defer client.Close()
+
+	// The first caller starts the dial and gives up while it is in flight
+	firstCtx, cancelFirst := context.WithCancel(ctx)
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.DialPooled(firstCtx, "moqt://memory:0/", nil)
+		firstErr <- err
+	}()
+	<-started
+
+	second := make(chan *PooledSession, 1)
+	go func() {
+		sess, err := client.DialPooled(ctx, "moqt://memory:0/", nil)
+		assert.NoError(t, err)
+		second <- sess
+	}()
+	require.Eventually(t, func() bool {
+		client.poolMu.Lock()
+		defer client.poolMu.Unlock()
+		for _, e := range client.pool {
+			return e.refs == 2
+		}
+		return false
+	}, time.Second, time.Millisecond)
+
+	cancelFirst()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+
+	// The other caller still gets the session
+	close(unblock)
+	sess := <-second
+	require.NotNil(t, sess)
+	assert.NoError(t, sess.Context().Err())
+	sess.Release()
+}
+
+func TestClient_DialPooled_ShuttingDown(t *testing.T) {
+	client := &Client{}
+	require.NoError(t, client.Close())
+
+	_, err := client.DialPooled(context.Background(), "moqt://memory:0/", nil)
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/okdaichi/gomoqt/moqt/internal/message"
 	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicmux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -592,6 +593,61 @@ func TestClient_DialWebTransport_ShuttingDown(t *testing.T) {
 	assert.ErrorIs(t, err, ErrClientClosed)
 }
 
+// Test for Client.DialWebSocket with shutting down state
+func TestClient_DialWebSocket_ShuttingDown(t *testing.T) {
+	c := &Client{}
+	c.inShutdown.Store(true)
+
+	_, err := c.DialWebSocket(context.Background(), "wss://example.com/test", NewTrackMux())
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
+
+// Test for Client.DialTCP with shutting down state
+func TestClient_DialTCP_ShuttingDown(t *testing.T) {
+	c := &Client{}
+	c.inShutdown.Store(true)
+
+	_, err := c.DialTCP(context.Background(), "example.com:443", "/test", NewTrackMux())
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrClientClosed)
+}
+
+func TestClient_DialTCP(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ln, err := quicmux.ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{generateTestCert(t)},
+		NextProtos:   []string{NextProtoMOQ},
+	}, nil)
+	require.NoError(t, err)
+
+	paths := make(chan string, 1)
+	server := &Server{
+		SetupHandler: SetupHandlerFunc(func(w SetupResponseWriter, r *SetupRequest) {
+			paths <- r.Path
+			_, _ = Accept(w, r, nil)
+		}),
+	}
+	defer server.Close()
+	go func() { _ = server.ServeQUICListener(ln) }()
+
+	client := &Client{
+		TLSConfig: &tls.Config{
+			InsecureSkipVerify: true,
+			NextProtos:         []string{NextProtoMOQ},
+		},
+	}
+	defer client.Close()
+
+	sess, err := client.DialTCP(ctx, ln.Addr().String(), "/room", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	assert.Equal(t, "/room", <-paths)
+}
+
 // Test for Client.DialQUIC with shutting down state
 func TestClient_DialQUIC_ShuttingDown(t *testing.T) {
 	c := &Client{}
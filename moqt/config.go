@@ -1,7 +1,10 @@
 package moqt
 
 import (
+	"io"
 	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
 )
 
 // Config contains configuration options for MOQ sessions.
@@ -15,8 +18,51 @@ type Config struct {
 	// SetupTimeout is the maximum time to wait for session setup to complete.
 	// If zero, a default timeout of 5 seconds is used.
 	SetupTimeout time.Duration
+
+	// MaxMessageSize is the maximum length in bytes of a control message
+	// received from the peer. A longer message closes the session with
+	// MessageTooLargeErrorCode before it is allocated.
+	// If zero, DefaultMaxMessageSize is used.
+	MaxMessageSize int
+
+	// MaxFrameSize is the maximum length in bytes of a frame received
+	// from the peer. A longer frame read with GroupReader.ReadFrame or
+	// CopyGroup cancels its group with FrameTooLargeErrorCode before it
+	// is allocated. GroupReader.NextFrame streams frames of any length.
+	// If zero, DefaultMaxFrameSize is used.
+	MaxFrameSize int
+
+	// MaxPathLength is the maximum length in bytes of a broadcast path,
+	// a track prefix or a track name requested by the peer. Longer
+	// subscriptions are rejected with PathTooLongErrorCode and longer
+	// announcement requests with InvalidPrefixErrorCode.
+	// If zero, DefaultMaxPathLength is used.
+	MaxPathLength int
+
+	// Metrics, if set, receives instrumentation events from the sessions
+	// and their tracks.
+	Metrics Metrics
+
+	// Qlog, if set, is called for every connection to get the writer its
+	// qlog trace is written to as JSON-SEQ. The trace records the control
+	// messages sent and received with their decoded fields, and the group
+	// streams with their frame counts. The writer is closed when the
+	// connection ends. Returning nil disables tracing for the connection.
+	// See QlogDir.
+	Qlog func(role Role, conn quic.Connection) io.WriteCloser
 }
 
+const (
+	// DefaultMaxMessageSize is the default Config.MaxMessageSize.
+	DefaultMaxMessageSize = 64 << 10
+
+	// DefaultMaxFrameSize is the default Config.MaxFrameSize.
+	DefaultMaxFrameSize = 16 << 20
+
+	// DefaultMaxPathLength is the default Config.MaxPathLength.
+	DefaultMaxPathLength = 4096
+)
+
 // setupTimeout returns the configured setup timeout or a default value.
 func (c *Config) setupTimeout() time.Duration {
 	if c != nil && c.SetupTimeout > 0 {
@@ -35,6 +81,43 @@ func (c *Config) Clone() *Config {
 		// MaxSubscribeID: c.MaxSubscribeID,
 		// NewSessionURI:  c.NewSessionURI,
 		// CheckRoot:      c.CheckRoot,
-		SetupTimeout: c.SetupTimeout,
+		SetupTimeout:   c.SetupTimeout,
+		MaxMessageSize: c.MaxMessageSize,
+		MaxFrameSize:   c.MaxFrameSize,
+		MaxPathLength:  c.MaxPathLength,
+		Metrics:        c.Metrics,
+		Qlog:           c.Qlog,
+	}
+}
+
+// metrics returns the configured Metrics or nil.
+func (c *Config) metrics() Metrics {
+	if c == nil {
+		return nil
+	}
+	return c.Metrics
+}
+
+// maxMessageSize returns the configured maximum message size or the default.
+func (c *Config) maxMessageSize() uint64 {
+	if c != nil && c.MaxMessageSize > 0 {
+		return uint64(c.MaxMessageSize)
+	}
+	return DefaultMaxMessageSize
+}
+
+// maxFrameSize returns the configured maximum frame size or the default.
+func (c *Config) maxFrameSize() uint64 {
+	if c != nil && c.MaxFrameSize > 0 {
+		return uint64(c.MaxFrameSize)
+	}
+	return DefaultMaxFrameSize
+}
+
+// maxPathLength returns the configured maximum path length or the default.
+func (c *Config) maxPathLength() int {
+	if c != nil && c.MaxPathLength > 0 {
+		return c.MaxPathLength
 	}
+	return DefaultMaxPathLength
 }
@@ -89,3 +89,31 @@ func TestConfig_setupTimeout_Boundary(t *testing.T) {
 		assert.Equal(t, 5*time.Minute, timeout, "should accept large timeout")
 	})
 }
+
+func TestConfig_Limits(t *testing.T) {
+	t.Run("nil config returns defaults", func(t *testing.T) {
+		var c *Config
+		assert.Equal(t, uint64(DefaultMaxMessageSize), c.maxMessageSize())
+		assert.Equal(t, uint64(DefaultMaxFrameSize), c.maxFrameSize())
+		assert.Equal(t, DefaultMaxPathLength, c.maxPathLength())
+	})
+
+	t.Run("zero limits return defaults", func(t *testing.T) {
+		c := &Config{}
+		assert.Equal(t, uint64(DefaultMaxMessageSize), c.maxMessageSize())
+		assert.Equal(t, uint64(DefaultMaxFrameSize), c.maxFrameSize())
+		assert.Equal(t, DefaultMaxPathLength, c.maxPathLength())
+	})
+
+	t.Run("configured limits are returned and cloned", func(t *testing.T) {
+		c := &Config{
+			MaxMessageSize: 128,
+			MaxFrameSize:   1024,
+			MaxPathLength:  16,
+		}
+		cloned := c.Clone()
+		assert.Equal(t, uint64(128), cloned.maxMessageSize())
+		assert.Equal(t, uint64(1024), cloned.maxFrameSize())
+		assert.Equal(t, 16, cloned.maxPathLength())
+	})
+}
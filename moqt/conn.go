@@ -0,0 +1,249 @@
+package moqt
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// newSessionConn wraps conn so that its streams carry the limits of config
+// and report to the qlog tracer of the connection, if any.
+func newSessionConn(conn quic.Connection, config *Config, role Role) *sessionConn {
+	return &sessionConn{
+		Connection:     conn,
+		tracer:         newTracer(config, role, conn),
+		maxMessageSize: config.maxMessageSize(),
+		maxFrameSize:   config.maxFrameSize(),
+		maxPathLength:  config.maxPathLength(),
+	}
+}
+
+// sessionConn is the connection of a session.
+type sessionConn struct {
+	quic.Connection
+
+	tracer *tracer
+
+	maxMessageSize uint64
+	maxFrameSize   uint64
+	maxPathLength  int
+}
+
+func (c *sessionConn) OpenStream() (quic.Stream, error) {
+	stream, err := c.Connection.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return &connStream{Stream: stream, conn: c}, nil
+}
+
+func (c *sessionConn) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	stream, err := c.Connection.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &connStream{Stream: stream, conn: c}, nil
+}
+
+func (c *sessionConn) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	stream, err := c.Connection.AcceptStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &connStream{Stream: stream, conn: c}, nil
+}
+
+func (c *sessionConn) OpenUniStream() (quic.SendStream, error) {
+	stream, err := c.Connection.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	return &connSendStream{SendStream: stream, conn: c}, nil
+}
+
+func (c *sessionConn) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	stream, err := c.Connection.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &connSendStream{SendStream: stream, conn: c}, nil
+}
+
+func (c *sessionConn) AcceptUniStream(ctx context.Context) (quic.ReceiveStream, error) {
+	stream, err := c.Connection.AcceptUniStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &connReceiveStream{ReceiveStream: stream, conn: c}, nil
+}
+
+type connStream struct {
+	quic.Stream
+	conn   *sessionConn
+	reader streamReader
+}
+
+func (s *connStream) Read(p []byte) (int, error) {
+	return s.reader.read(s.Stream, p)
+}
+
+// ReadByte implements io.ByteReader so that varints are read without allocating.
+func (s *connStream) ReadByte() (byte, error) {
+	return s.reader.readByte(s.Stream)
+}
+
+// MaxMessageSize implements message.SizeLimiter.
+func (s *connStream) MaxMessageSize() uint64 {
+	return s.conn.maxMessageSize
+}
+
+type connSendStream struct {
+	quic.SendStream
+	conn *sessionConn
+}
+
+type connReceiveStream struct {
+	quic.ReceiveStream
+	conn   *sessionConn
+	reader streamReader
+}
+
+func (s *connReceiveStream) Read(p []byte) (int, error) {
+	return s.reader.read(s.ReceiveStream, p)
+}
+
+// ReadByte implements io.ByteReader so that varints are read without allocating.
+func (s *connReceiveStream) ReadByte() (byte, error) {
+	return s.reader.readByte(s.ReceiveStream)
+}
+
+// MaxMessageSize implements message.SizeLimiter.
+func (s *connReceiveStream) MaxMessageSize() uint64 {
+	return s.conn.maxMessageSize
+}
+
+func (s *connReceiveStream) maxFrameSize() uint64 {
+	return s.conn.maxFrameSize
+}
+
+// streamConn returns the connection of stream, or nil if the stream
+// was not opened or accepted through a sessionConn.
+func streamConn(stream any) *sessionConn {
+	switch s := stream.(type) {
+	case *connStream:
+		return s.conn
+	case *connSendStream:
+		return s.conn
+	case *connReceiveStream:
+		return s.conn
+	}
+	return nil
+}
+
+// connectionStats returns the statistics of conn, or nil if its QUIC
+// implementation does not report them.
+func connectionStats(conn quic.Connection) *quic.ConnectionStats {
+	if c, ok := conn.(*sessionConn); ok {
+		conn = c.Connection
+	}
+	if c, ok := conn.(interface{ ConnectionStats() quic.ConnectionStats }); ok {
+		stats := c.ConnectionStats()
+		return &stats
+	}
+	return nil
+}
+
+// checkMessageSize returns err unless it reports a control message over
+// the size limit of stream. In that case, the session is closed with
+// MessageTooLargeErrorCode and the returned error is a *SessionError.
+func checkMessageSize(stream any, err error) error {
+	if !errors.Is(err, message.ErrMessageTooLarge) {
+		return err
+	}
+
+	code := quic.ApplicationErrorCode(MessageTooLargeErrorCode)
+	text := SessionErrorText(MessageTooLargeErrorCode)
+	if conn := streamConn(stream); conn != nil {
+		_ = conn.CloseWithError(code, text)
+	}
+
+	return &SessionError{
+		ApplicationError: &quic.ApplicationError{
+			ErrorCode:    code,
+			ErrorMessage: text,
+		},
+	}
+}
+
+// maxPathLength returns the path length limit of the connection of stream.
+func maxPathLength(stream any) int {
+	if conn := streamConn(stream); conn != nil {
+		return conn.maxPathLength
+	}
+	return DefaultMaxPathLength
+}
+
+const streamReaderSize = 4 << 10
+
+var streamReaderPool = sync.Pool{
+	New: func() any { return bufio.NewReaderSize(nil, streamReaderSize) },
+}
+
+// streamReader buffers the reads of a stream, so that length prefixes and
+// small messages do not each need a call into the stream.
+// The buffer is taken from a pool on the first read and returned when
+// a read fails, as the buffer is empty then. Later reads go to the stream.
+//
+// Like the stream, a streamReader is not safe for concurrent reads.
+type streamReader struct {
+	buf  *bufio.Reader
+	done bool
+}
+
+func (r *streamReader) get(src io.Reader) *bufio.Reader {
+	if r.buf == nil && !r.done {
+		r.buf = streamReaderPool.Get().(*bufio.Reader)
+		r.buf.Reset(src)
+	}
+	return r.buf
+}
+
+func (r *streamReader) release() {
+	r.buf.Reset(nil)
+	streamReaderPool.Put(r.buf)
+	r.buf = nil
+	r.done = true
+}
+
+func (r *streamReader) read(src io.Reader, p []byte) (int, error) {
+	buf := r.get(src)
+	if buf == nil {
+		return src.Read(p)
+	}
+
+	n, err := buf.Read(p)
+	if err != nil {
+		r.release()
+	}
+	return n, err
+}
+
+func (r *streamReader) readByte(src io.Reader) (byte, error) {
+	buf := r.get(src)
+	if buf == nil {
+		var b [1]byte
+		_, err := io.ReadFull(src, b[:])
+		return b[0], err
+	}
+
+	c, err := buf.ReadByte()
+	if err != nil {
+		r.release()
+	}
+	return c, err
+}
@@ -0,0 +1,125 @@
+package moqt
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionConn_MaxMessageSize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	d.server.Config = &Config{MaxMessageSize: 32}
+	client := &Client{DialQUICFunc: d.dial}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+
+	// The SUBSCRIBE message exceeds the limit of the server
+	path := BroadcastPath("/" + strings.Repeat("a", 64))
+	_, err = sess.Subscribe(path, "video", nil)
+	require.Error(t, err)
+
+	<-sess.Context().Done()
+	var appErr *quic.ApplicationError
+	require.True(t, errors.As(context.Cause(sess.Context()), &appErr))
+	assert.Equal(t, quic.ApplicationErrorCode(MessageTooLargeErrorCode), appErr.ErrorCode)
+}
+
+func TestSessionConn_MaxFrameSize(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		frame := NewFrame(64)
+		_, _ = frame.Write(make([]byte, 64))
+		_ = gw.WriteFrame(frame)
+		_ = gw.Close()
+	})
+
+	d := newMemoryDialer(t, mux)
+	client := &Client{DialQUICFunc: d.dial, Config: &Config{MaxFrameSize: 16}}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	tr, err := sess.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	gr, err := tr.AcceptGroup(ctx)
+	require.NoError(t, err)
+
+	frame := NewFrame(0)
+	err = gr.ReadFrame(frame)
+	var grpErr *GroupError
+	require.ErrorAs(t, err, &grpErr)
+	assert.Equal(t, FrameTooLargeErrorCode, grpErr.GroupErrorCode())
+	assert.Zero(t, frame.Cap(), "the frame must not be allocated")
+}
+
+func TestSessionConn_MaxPathLength(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	d.server.Config = &Config{MaxPathLength: 16}
+	client := &Client{DialQUICFunc: d.dial}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	t.Run("subscribe", func(t *testing.T) {
+		_, err := sess.Subscribe("/live", TrackName(strings.Repeat("v", 17)), nil)
+		var subErr *SubscribeError
+		require.ErrorAs(t, err, &subErr)
+		assert.Equal(t, PathTooLongErrorCode, subErr.SubscribeErrorCode())
+	})
+
+	t.Run("announce please", func(t *testing.T) {
+		_, err := sess.AcceptAnnounce("/" + strings.Repeat("a", 16) + "/")
+		var annErr *AnnounceError
+		require.ErrorAs(t, err, &annErr)
+		assert.Equal(t, InvalidPrefixErrorCode, annErr.AnnounceErrorCode())
+	})
+}
+
+func TestStreamReader(t *testing.T) {
+	data := []byte{0x40, 0x80, 'a', 'b', 'c'}
+	stream := &countingReceiveStream{r: bytes.NewReader(data)}
+	s := &connReceiveStream{ReceiveStream: stream, conn: &sessionConn{}}
+
+	c, err := s.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x40), c)
+
+	rest, err := io.ReadAll(s)
+	require.NoError(t, err)
+	assert.Equal(t, data[1:], rest)
+	assert.Equal(t, 2, stream.reads, "the data must be read at once, then the end of the stream")
+
+	// The buffer is released at the end of the stream
+	assert.Nil(t, s.reader.buf)
+	_, err = s.ReadByte()
+	assert.ErrorIs(t, err, io.EOF)
+	n, err := s.Read(make([]byte, 1))
+	assert.Zero(t, n)
+	assert.ErrorIs(t, err, io.EOF)
+}
@@ -0,0 +1,150 @@
+package dissect
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+)
+
+// codec decodes a control message with the codecs of the message package.
+type codec interface {
+	name() string
+	decode(r io.Reader) error
+
+	// len returns the length of the decoded fields.
+	len() int
+
+	fields() []Field
+}
+
+type sessionClient struct{ m message.SessionClientMessage }
+
+func (c *sessionClient) name() string             { return "SESSION_CLIENT" }
+func (c *sessionClient) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *sessionClient) len() int                 { return c.m.Len() }
+func (c *sessionClient) fields() []Field {
+	versions := make([]hexValue, len(c.m.SupportedVersions))
+	for i, v := range c.m.SupportedVersions {
+		versions[i] = hexValue(v)
+	}
+	return []Field{
+		{"supported_versions", versions},
+		{"parameters", parameters(c.m.Parameters)},
+	}
+}
+
+type sessionServer struct{ m message.SessionServerMessage }
+
+func (c *sessionServer) name() string             { return "SESSION_SERVER" }
+func (c *sessionServer) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *sessionServer) len() int                 { return c.m.Len() }
+func (c *sessionServer) fields() []Field {
+	return []Field{
+		{"selected_version", hexValue(c.m.SelectedVersion)},
+		{"parameters", parameters(c.m.Parameters)},
+	}
+}
+
+type sessionUpdate struct{ m message.SessionUpdateMessage }
+
+func (c *sessionUpdate) name() string             { return "SESSION_UPDATE" }
+func (c *sessionUpdate) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *sessionUpdate) len() int                 { return c.m.Len() }
+func (c *sessionUpdate) fields() []Field {
+	return []Field{{"bitrate", c.m.Bitrate}}
+}
+
+type announcePlease struct{ m message.AnnouncePleaseMessage }
+
+func (c *announcePlease) name() string             { return "ANNOUNCE_PLEASE" }
+func (c *announcePlease) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *announcePlease) len() int                 { return c.m.Len() }
+func (c *announcePlease) fields() []Field {
+	return []Field{{"track_prefix", c.m.TrackPrefix}}
+}
+
+type announceInit struct{ m message.AnnounceInitMessage }
+
+func (c *announceInit) name() string             { return "ANNOUNCE_INIT" }
+func (c *announceInit) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *announceInit) len() int                 { return c.m.Len() }
+func (c *announceInit) fields() []Field {
+	return []Field{{"suffixes", c.m.Suffixes}}
+}
+
+type announce struct{ m message.AnnounceMessage }
+
+func (c *announce) name() string             { return "ANNOUNCE" }
+func (c *announce) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *announce) len() int                 { return c.m.Len() }
+func (c *announce) fields() []Field {
+	var status string
+	switch c.m.AnnounceStatus {
+	case message.ACTIVE:
+		status = "active"
+	case message.ENDED:
+		status = "ended"
+	default:
+		status = fmt.Sprintf("unknown(%#x)", uint64(c.m.AnnounceStatus))
+	}
+	return []Field{
+		{"status", status},
+		{"track_suffix", c.m.TrackSuffix},
+	}
+}
+
+type subscribe struct{ m message.SubscribeMessage }
+
+func (c *subscribe) name() string             { return "SUBSCRIBE" }
+func (c *subscribe) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *subscribe) len() int                 { return c.m.Len() }
+func (c *subscribe) fields() []Field {
+	return []Field{
+		{"subscribe_id", c.m.SubscribeID},
+		{"broadcast_path", c.m.BroadcastPath},
+		{"track_name", c.m.TrackName},
+		{"track_priority", c.m.TrackPriority},
+	}
+}
+
+type subscribeOk struct{ m message.SubscribeOkMessage }
+
+func (c *subscribeOk) name() string             { return "SUBSCRIBE_OK" }
+func (c *subscribeOk) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *subscribeOk) len() int                 { return c.m.Len() }
+func (c *subscribeOk) fields() []Field          { return nil }
+
+type subscribeUpdate struct {
+	m message.SubscribeUpdateMessage
+}
+
+func (c *subscribeUpdate) name() string             { return "SUBSCRIBE_UPDATE" }
+func (c *subscribeUpdate) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *subscribeUpdate) len() int                 { return c.m.Len() }
+func (c *subscribeUpdate) fields() []Field {
+	return []Field{{"track_priority", c.m.TrackPriority}}
+}
+
+type group struct{ m message.GroupMessage }
+
+func (c *group) name() string             { return "GROUP" }
+func (c *group) decode(r io.Reader) error { return c.m.Decode(r) }
+func (c *group) len() int                 { return c.m.Len() }
+func (c *group) fields() []Field {
+	return []Field{
+		{"subscribe_id", c.m.SubscribeID},
+		{"group_sequence", c.m.GroupSequence},
+	}
+}
+
+// parameters formats setup parameters as type:value pairs sorted by type.
+func parameters(params map[uint64][]byte) []string {
+	pairs := make([]string, 0, len(params))
+	for _, typ := range slices.Sorted(maps.Keys(params)) {
+		pairs = append(pairs, fmt.Sprintf("%#x:%x", typ, params[typ]))
+	}
+	return pairs
+}
@@ -0,0 +1,305 @@
+package dissect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+)
+
+// Stream selects how the bytes of a stream are decoded.
+// Bidirectional streams carry different messages in each direction,
+// so the bytes written by the peer accepting the stream are selected
+// by the type of the stream.
+type Stream string
+
+const (
+	// BiStream is the data written by the peer opening a bidirectional
+	// stream, starting with STREAM_TYPE.
+	BiStream Stream = "bidi"
+
+	// UniStream is the data of a unidirectional stream,
+	// starting with STREAM_TYPE.
+	UniStream Stream = "uni"
+
+	// SessionResponse is the data written by the peer accepting a session stream.
+	SessionResponse Stream = "session-response"
+
+	// AnnounceResponse is the data written by the peer accepting an announce stream.
+	AnnounceResponse Stream = "announce-response"
+
+	// SubscribeResponse is the data written by the peer accepting a subscribe stream.
+	SubscribeResponse Stream = "subscribe-response"
+)
+
+// Streams lists the supported values of Stream.
+var Streams = []Stream{BiStream, UniStream, SessionResponse, AnnounceResponse, SubscribeResponse}
+
+var (
+	// ErrMalformed is wrapped by the errors of messages that could not be decoded.
+	ErrMalformed = errors.New("dissect: malformed message")
+
+	// ErrTrailingBytes is wrapped by the errors of messages followed by bytes
+	// they do not account for.
+	ErrTrailingBytes = errors.New("dissect: trailing bytes")
+
+	// ErrUnknownStream is returned for an unsupported Stream.
+	ErrUnknownStream = errors.New("dissect: unknown stream")
+)
+
+// Message is a message found in the bytes of a stream.
+type Message struct {
+	// Offset is the position of the message in the stream.
+	Offset int
+
+	// Length is the number of bytes of the message,
+	// including its length prefix.
+	Length int
+
+	// Type is the name of the message, such as "SUBSCRIBE" or "FRAME".
+	Type string
+
+	// Fields are the decoded fields of the message.
+	Fields []Field
+
+	// Err reports why the message is malformed, or nil.
+	Err error
+}
+
+// Field is a decoded field of a message.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// hexValue is a field value formatted in hexadecimal,
+// such as a stream type or a version.
+type hexValue uint64
+
+func (v hexValue) String() string {
+	return fmt.Sprintf("%#x", uint64(v))
+}
+
+// String formats the message on a single line.
+func (m Message) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%#06x %-17s len=%d", m.Offset, m.Type, m.Length)
+	for _, f := range m.Fields {
+		sb.WriteByte(' ')
+		sb.WriteString(f.Name)
+		sb.WriteByte('=')
+		switch v := f.Value.(type) {
+		case string:
+			fmt.Fprintf(&sb, "%q", v)
+		case []string:
+			fmt.Fprintf(&sb, "%q", v)
+		default:
+			fmt.Fprintf(&sb, "%v", v)
+		}
+	}
+	if m.Err != nil {
+		sb.WriteString(" ERROR: ")
+		sb.WriteString(m.Err.Error())
+	}
+	return sb.String()
+}
+
+// Fprint writes msgs to w, one per line.
+func Fprint(w io.Writer, msgs []Message) error {
+	for _, m := range msgs {
+		if _, err := fmt.Fprintln(w, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Dissect decodes the bytes of a stream as written by the selected side.
+// It returns every message found, up to the first malformed one, which is
+// included with its Err set. The returned error is the Err of that message,
+// or nil if the bytes are well-formed.
+func Dissect(b []byte, stream Stream) ([]Message, error) {
+	d := &dissector{b: b}
+
+	switch stream {
+	case BiStream:
+		typ, ok := d.streamType(biStreamTypes)
+		if !ok {
+			break
+		}
+		switch typ {
+		case message.StreamTypeSession:
+			d.message(&sessionClient{})
+			d.repeat(func() bool { return d.message(&sessionUpdate{}) })
+		case message.StreamTypeAnnounce:
+			d.message(&announcePlease{})
+		case message.StreamTypeSubscribe:
+			d.message(&subscribe{})
+			d.repeat(func() bool { return d.message(&subscribeUpdate{}) })
+		}
+	case UniStream:
+		if _, ok := d.streamType(uniStreamTypes); !ok {
+			break
+		}
+		d.message(&group{})
+		d.repeat(d.frame)
+	case SessionResponse:
+		d.message(&sessionServer{})
+		d.repeat(func() bool { return d.message(&sessionUpdate{}) })
+	case AnnounceResponse:
+		d.message(&announceInit{})
+		d.repeat(func() bool { return d.message(&announce{}) })
+	case SubscribeResponse:
+		d.message(&subscribeOk{})
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStream, stream)
+	}
+
+	d.trailing()
+
+	return d.msgs, d.err
+}
+
+var biStreamTypes = map[message.StreamType]string{
+	message.StreamTypeSession:   "session",
+	message.StreamTypeAnnounce:  "announce",
+	message.StreamTypeSubscribe: "subscribe",
+}
+
+var uniStreamTypes = map[message.StreamType]string{
+	message.StreamTypeGroup: "group",
+}
+
+type dissector struct {
+	b    []byte
+	off  int
+	msgs []Message
+	err  error
+}
+
+func (d *dissector) fail(m Message, err error) {
+	m.Err = err
+	d.msgs = append(d.msgs, m)
+	d.err = err
+}
+
+// repeat calls next until the bytes end or a message is malformed.
+func (d *dissector) repeat(next func() bool) {
+	for d.err == nil && d.off < len(d.b) {
+		if !next() {
+			return
+		}
+	}
+}
+
+func (d *dissector) streamType(types map[message.StreamType]string) (message.StreamType, bool) {
+	m := Message{Offset: d.off, Type: "STREAM_TYPE"}
+	if d.off >= len(d.b) {
+		d.fail(m, fmt.Errorf("%w: missing stream type", ErrMalformed))
+		return 0, false
+	}
+
+	typ := message.StreamType(d.b[d.off])
+	m.Length = 1
+	m.Fields = []Field{{"type", hexValue(typ)}}
+	d.off++
+
+	name, ok := types[typ]
+	if !ok {
+		d.fail(m, fmt.Errorf("%w: unknown stream type %#x", ErrMalformed, byte(typ)))
+		return 0, false
+	}
+	m.Fields = append(m.Fields, Field{"name", name})
+	d.msgs = append(d.msgs, m)
+
+	return typ, true
+}
+
+// body reads the length prefix of the next message and returns
+// the message with its length prefix.
+func (d *dissector) body(m *Message) ([]byte, bool) {
+	size, n, err := message.ReadVarint(d.b[d.off:])
+	if err != nil {
+		m.Length = len(d.b) - d.off
+		d.off = len(d.b)
+		d.fail(*m, fmt.Errorf("%w: truncated length prefix", ErrMalformed))
+		return nil, false
+	}
+
+	left := uint64(len(d.b) - d.off - n)
+	if size > left {
+		m.Length = len(d.b) - d.off
+		d.off = len(d.b)
+		d.fail(*m, fmt.Errorf("%w: length %d exceeds the %d bytes left", ErrMalformed, size, left))
+		return nil, false
+	}
+
+	m.Length = n + int(size)
+	body := d.b[d.off : d.off+m.Length]
+	d.off += m.Length
+
+	return body, true
+}
+
+// message decodes the next control message with c.
+func (d *dissector) message(c codec) bool {
+	if d.err != nil {
+		return false
+	}
+
+	m := Message{Offset: d.off, Type: c.name()}
+	b, ok := d.body(&m)
+	if !ok {
+		return false
+	}
+
+	err := c.decode(bytes.NewReader(b))
+	if err != nil {
+		// The codecs reject bytes left after the fields they know
+		if errors.Is(err, message.ErrMessageTooShort) {
+			_, n, _ := message.ReadVarint(b)
+			m.Fields = c.fields()
+			err = fmt.Errorf("%w: %d bytes after the fields", ErrTrailingBytes, len(b)-n-c.len())
+		} else if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			err = fmt.Errorf("%w: fields exceed the length of the message", ErrMalformed)
+		} else {
+			err = fmt.Errorf("%w: %v", ErrMalformed, err)
+		}
+		d.fail(m, err)
+		return false
+	}
+	m.Fields = c.fields()
+
+	d.msgs = append(d.msgs, m)
+	return true
+}
+
+// frame decodes the next frame of a group stream.
+func (d *dissector) frame() bool {
+	m := Message{Offset: d.off, Type: "FRAME"}
+	b, ok := d.body(&m)
+	if !ok {
+		return false
+	}
+
+	_, n, _ := message.ReadVarint(b)
+	m.Fields = []Field{{"payload_length", len(b) - n}}
+	d.msgs = append(d.msgs, m)
+	return true
+}
+
+// trailing flags the bytes left after the last message a stream may carry.
+func (d *dissector) trailing() {
+	if d.err != nil || d.off >= len(d.b) {
+		return
+	}
+
+	d.fail(Message{
+		Offset: d.off,
+		Length: len(d.b) - d.off,
+		Type:   "UNKNOWN",
+	}, fmt.Errorf("%w: %d bytes after the last message of the stream", ErrTrailingBytes, len(d.b)-d.off))
+}
@@ -0,0 +1,252 @@
+package dissect
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encode concatenates the encoded messages and raw bytes of parts.
+func encode(t *testing.T, parts ...any) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, p := range parts {
+		switch p := p.(type) {
+		case []byte:
+			buf.Write(p)
+		case interface{ Encode(io.Writer) error }:
+			require.NoError(t, p.Encode(&buf))
+		default:
+			t.Fatalf("cannot encode %T", p)
+		}
+	}
+	return buf.Bytes()
+}
+
+func types(msgs []Message) []string {
+	names := make([]string, len(msgs))
+	for i, m := range msgs {
+		names[i] = m.Type
+	}
+	return names
+}
+
+func field(m Message, name string) any {
+	for _, f := range m.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+func TestDissect(t *testing.T) {
+	tests := map[string]struct {
+		stream Stream
+		data   []any
+		want   []string
+	}{
+		"session stream": {
+			stream: BiStream,
+			data: []any{
+				message.StreamTypeSession,
+				message.SessionClientMessage{SupportedVersions: []uint64{0xffffff01}, Parameters: map[uint64][]byte{1: []byte("/")}},
+				message.SessionUpdateMessage{Bitrate: 1000},
+			},
+			want: []string{"STREAM_TYPE", "SESSION_CLIENT", "SESSION_UPDATE"},
+		},
+		"session response": {
+			stream: SessionResponse,
+			data: []any{
+				message.SessionServerMessage{SelectedVersion: 0xffffff01},
+				message.SessionUpdateMessage{Bitrate: 1000},
+				message.SessionUpdateMessage{Bitrate: 2000},
+			},
+			want: []string{"SESSION_SERVER", "SESSION_UPDATE", "SESSION_UPDATE"},
+		},
+		"announce stream": {
+			stream: BiStream,
+			data:   []any{message.StreamTypeAnnounce, message.AnnouncePleaseMessage{TrackPrefix: "/room/"}},
+			want:   []string{"STREAM_TYPE", "ANNOUNCE_PLEASE"},
+		},
+		"announce response": {
+			stream: AnnounceResponse,
+			data: []any{
+				message.AnnounceInitMessage{Suffixes: []string{"alice"}},
+				message.AnnounceMessage{AnnounceStatus: message.ACTIVE, TrackSuffix: "bob"},
+				message.AnnounceMessage{AnnounceStatus: message.ENDED, TrackSuffix: "alice"},
+			},
+			want: []string{"ANNOUNCE_INIT", "ANNOUNCE", "ANNOUNCE"},
+		},
+		"subscribe stream": {
+			stream: BiStream,
+			data: []any{
+				message.StreamTypeSubscribe,
+				message.SubscribeMessage{SubscribeID: 1, BroadcastPath: "/live", TrackName: "video"},
+				message.SubscribeUpdateMessage{TrackPriority: 2},
+			},
+			want: []string{"STREAM_TYPE", "SUBSCRIBE", "SUBSCRIBE_UPDATE"},
+		},
+		"subscribe response": {
+			stream: SubscribeResponse,
+			data:   []any{message.SubscribeOkMessage{}},
+			want:   []string{"SUBSCRIBE_OK"},
+		},
+		"group stream": {
+			stream: UniStream,
+			data: []any{
+				message.StreamTypeGroup,
+				message.GroupMessage{SubscribeID: 1, GroupSequence: 7},
+				[]byte{0x05, 'h', 'e', 'l', 'l', 'o'},
+				[]byte{0x00},
+			},
+			want: []string{"STREAM_TYPE", "GROUP", "FRAME", "FRAME"},
+		},
+		"empty group stream": {
+			stream: UniStream,
+			data:   []any{message.StreamTypeGroup, message.GroupMessage{SubscribeID: 1}},
+			want:   []string{"STREAM_TYPE", "GROUP"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := encode(t, tt.data...)
+			msgs, err := Dissect(b, tt.stream)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, types(msgs))
+
+			// Messages cover the whole stream
+			var off int
+			for _, m := range msgs {
+				assert.Equal(t, off, m.Offset)
+				assert.NoError(t, m.Err)
+				off += m.Length
+			}
+			assert.Equal(t, len(b), off)
+		})
+	}
+}
+
+func TestDissect_Fields(t *testing.T) {
+	b := encode(t,
+		message.StreamTypeSubscribe,
+		message.SubscribeMessage{SubscribeID: 3, BroadcastPath: "/live", TrackName: "video", TrackPriority: 1},
+	)
+	msgs, err := Dissect(b, BiStream)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+
+	assert.Equal(t, "subscribe", field(msgs[0], "name"))
+	assert.Equal(t, uint64(3), field(msgs[1], "subscribe_id"))
+	assert.Equal(t, "/live", field(msgs[1], "broadcast_path"))
+	assert.Equal(t, "video", field(msgs[1], "track_name"))
+
+	b = encode(t, message.StreamTypeGroup, message.GroupMessage{SubscribeID: 3, GroupSequence: 9}, []byte{0x02, 'h', 'i'})
+	msgs, err = Dissect(b, UniStream)
+	require.NoError(t, err)
+	require.Len(t, msgs, 3)
+	assert.Equal(t, uint64(9), field(msgs[1], "group_sequence"))
+	assert.Equal(t, 2, field(msgs[2], "payload_length"))
+}
+
+func TestDissect_Malformed(t *testing.T) {
+	subscribe := encode(t, message.SubscribeMessage{SubscribeID: 1, BroadcastPath: "/live", TrackName: "video"})
+
+	// A SUBSCRIBE with a byte appended after its optional fields, inside its declared length
+	padded := append([]byte{subscribe[0] + 3}, subscribe[1:]...)
+	padded = append(padded, 0, 0, 0)
+
+	tests := map[string]struct {
+		stream  Stream
+		data    []any
+		wantErr error
+		want    []string
+	}{
+		"unknown stream type": {
+			stream:  BiStream,
+			data:    []any{[]byte{0x09}},
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE"},
+		},
+		"missing stream type": {
+			stream:  UniStream,
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE"},
+		},
+		"length exceeds the stream": {
+			stream:  BiStream,
+			data:    []any{message.StreamTypeSubscribe, subscribe[:len(subscribe)-2]},
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE", "SUBSCRIBE"},
+		},
+		"truncated length prefix": {
+			stream:  UniStream,
+			data:    []any{message.StreamTypeGroup, message.GroupMessage{}, []byte{0x40}},
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE", "GROUP", "FRAME"},
+		},
+		"truncated frame": {
+			stream:  UniStream,
+			data:    []any{message.StreamTypeGroup, message.GroupMessage{}, []byte{0x05, 'h', 'i'}},
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE", "GROUP", "FRAME"},
+		},
+		"bytes after the fields": {
+			stream:  BiStream,
+			data:    []any{message.StreamTypeSubscribe, padded},
+			wantErr: ErrTrailingBytes,
+			want:    []string{"STREAM_TYPE", "SUBSCRIBE"},
+		},
+		"bytes after the last message": {
+			stream:  SubscribeResponse,
+			data:    []any{message.SubscribeOkMessage{}, []byte{0x01, 0x02}},
+			wantErr: ErrTrailingBytes,
+			want:    []string{"SUBSCRIBE_OK", "UNKNOWN"},
+		},
+		"malformed body": {
+			stream:  BiStream,
+			data:    []any{message.StreamTypeAnnounce, []byte{0x02, 0x05, 'a'}},
+			wantErr: ErrMalformed,
+			want:    []string{"STREAM_TYPE", "ANNOUNCE_PLEASE"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			msgs, err := Dissect(encode(t, tt.data...), tt.stream)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, types(msgs))
+
+			last := msgs[len(msgs)-1]
+			assert.ErrorIs(t, last.Err, tt.wantErr)
+			assert.Contains(t, last.String(), "ERROR: ")
+		})
+	}
+}
+
+func TestDissect_UnknownStream(t *testing.T) {
+	_, err := Dissect(nil, "datagram")
+	assert.ErrorIs(t, err, ErrUnknownStream)
+}
+
+func TestFprint(t *testing.T) {
+	b := encode(t, message.StreamTypeAnnounce, message.AnnouncePleaseMessage{TrackPrefix: "/room/"})
+	msgs, err := Dissect(b, BiStream)
+	require.NoError(t, err)
+
+	var out strings.Builder
+	require.NoError(t, Fprint(&out, msgs))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "STREAM_TYPE")
+	assert.Contains(t, lines[0], `name="announce"`)
+	assert.Contains(t, lines[1], "ANNOUNCE_PLEASE")
+	assert.Contains(t, lines[1], `track_prefix="/room/"`)
+}
@@ -0,0 +1,17 @@
+// Package dissect decodes the raw bytes of MOQ streams for offline diagnosis.
+//
+// Dissect takes the bytes captured from one direction of a stream and lists
+// every message with its decoded fields, using the same codecs as package
+// moqt. Malformed length prefixes, bytes a message does not account for and
+// bytes after the last message a stream may carry are reported on the
+// message where they are found.
+/*
+	msgs, err := dissect.Dissect(capture, dissect.BiStream)
+	_ = dissect.Fprint(os.Stdout, msgs)
+	if err != nil {
+	    log.Printf("malformed stream: %v", err)
+	}
+*/
+//
+// The moqt command exposes it as "moqt dissect".
+package dissect
@@ -15,6 +15,14 @@
 	sess, err := client.DialQUIC(ctx, "example.com:4433", "/path", mux)
 */
 //
+// DialReconnecting keeps a session alive across network failures.
+// Its subscriptions are re-established on every new session.
+/*
+	rs, err := client.DialReconnecting(ctx, "https://example.com:4433", mux, nil)
+	...
+	tr, err := rs.Subscribe("/broadcast", "video", nil)
+*/
+//
 // # Servers
 //
 // Server listens for incoming connections and handles subscriptions.
@@ -70,6 +78,9 @@
 // By default it uses github.com/quic-go/quic-go for QUIC and github.com/quic-go/webtransport for WebTransport.
 // Custom transports can be provided by setting the client's `DialAddrFunc` and `DialWebTransportFunc`,
 // and the server's `ListenFunc` and `NewWebtransportServerFunc`.
+// Where UDP is blocked, quic/quicmux carries sessions over TLS/TCP or WebSocket:
+// `Client.Dial` handles `ws://` and `wss://` URLs and `Server.HandleWebSocket` accepts them,
+// and `Client.DialTCP` dials TLS over TCP served by `Server.ServeQUICListener` with `quicmux.ListenAddr`.
 //
 // Client example:
 /*
@@ -103,6 +103,8 @@ const (
 	UnauthorizedSubscribeErrorCode SubscribeErrorCode = 0x04 // TODO: Is this necessary?
 	// Subscriber
 	SubscribeTimeoutErrorCode SubscribeErrorCode = 0x05
+	// Publisher, when the broadcast path or the track name exceeds Config.MaxPathLength
+	PathTooLongErrorCode SubscribeErrorCode = 0x06
 	// ClosedTrackErrorCode           SubscribeErrorCode = 0x07 // TODO: Is this necessary?
 
 	// Error code used by the application.
@@ -125,6 +127,8 @@ func SubscribeErrorText(code SubscribeErrorCode) string {
 		return "moqt: unauthorized"
 	case SubscribeTimeoutErrorCode:
 		return "moqt: timeout"
+	case PathTooLongErrorCode:
+		return "moqt: path too long"
 	default:
 		return ""
 	}
@@ -165,6 +169,10 @@ const (
 	UnsupportedVersionErrorCode      SessionErrorCode = 0x12
 
 	SetupFailedErrorCode SessionErrorCode = 0x13
+
+	// MessageTooLargeErrorCode closes a session whose peer sent a control
+	// message longer than Config.MaxMessageSize.
+	MessageTooLargeErrorCode SessionErrorCode = 0x14
 )
 
 // SessionErrorText returns a text for the session error code.
@@ -189,6 +197,8 @@ func SessionErrorText(code SessionErrorCode) string {
 		return "moqt: unsupported version"
 	case SetupFailedErrorCode:
 		return "moqt: setup failed"
+	case MessageTooLargeErrorCode:
+		return "moqt: message too large"
 	default:
 		return ""
 	}
@@ -231,6 +241,8 @@ const (
 	PublishAbortedErrorCode     GroupErrorCode = 0x05
 	ClosedSessionGroupErrorCode GroupErrorCode = 0x06
 	InvalidSubscribeIDErrorCode GroupErrorCode = 0x07 // TODO: Is this necessary?
+	FrameTooLargeErrorCode      GroupErrorCode = 0x08
+	DroppedGroupErrorCode       GroupErrorCode = 0x09
 )
 
 // GroupErrorText returns a text for the group error code.
@@ -251,6 +263,10 @@ func GroupErrorText(code GroupErrorCode) string {
 		return "moqt: session closed"
 	case InvalidSubscribeIDErrorCode:
 		return "moqt: invalid subscribe id"
+	case FrameTooLargeErrorCode:
+		return "moqt: frame too large"
+	case DroppedGroupErrorCode:
+		return "moqt: group dropped"
 	default:
 		return ""
 	}
@@ -117,6 +117,10 @@ func TestSubscribeErrorText(t *testing.T) {
 			code:   SubscribeTimeoutErrorCode,
 			expect: "moqt: timeout",
 		},
+		"path too long error code": {
+			code:   PathTooLongErrorCode,
+			expect: "moqt: path too long",
+		},
 		"unknown code": {
 			code:   SubscribeErrorCode(0xFF), // Some arbitrary value not defined
 			expect: "",
@@ -178,6 +182,10 @@ func TestSessionErrorText(t *testing.T) {
 			code:   SetupFailedErrorCode,
 			expect: "moqt: setup failed",
 		},
+		"message too large error code": {
+			code:   MessageTooLargeErrorCode,
+			expect: "moqt: message too large",
+		},
 		"unknown code": {
 			code:   SessionErrorCode(0xFF), // Some arbitrary value not defined
 			expect: "",
@@ -231,6 +239,14 @@ func TestGroupErrorText(t *testing.T) {
 			code:   InvalidSubscribeIDErrorCode,
 			expect: "moqt: invalid subscribe id",
 		},
+		"frame too large error code": {
+			code:   FrameTooLargeErrorCode,
+			expect: "moqt: frame too large",
+		},
+		"dropped group error code": {
+			code:   DroppedGroupErrorCode,
+			expect: "moqt: group dropped",
+		},
 		"unknown code": {
 			code:   GroupErrorCode(0xFF), // Some arbitrary value not defined
 			expect: "",
@@ -799,6 +815,7 @@ func TestErrorTextConsistency(t *testing.T) {
 			TrackNotFoundErrorCode,
 			UnauthorizedSubscribeErrorCode,
 			SubscribeTimeoutErrorCode,
+			PathTooLongErrorCode,
 		}
 
 		for _, code := range codes {
@@ -824,6 +841,7 @@ func TestErrorTextConsistency(t *testing.T) {
 			GoAwayTimeoutErrorCode,
 			UnsupportedVersionErrorCode,
 			SetupFailedErrorCode,
+			MessageTooLargeErrorCode,
 		}
 
 		for _, code := range codes {
@@ -859,6 +877,8 @@ func TestErrorTextConsistency(t *testing.T) {
 			PublishAbortedErrorCode,
 			ClosedSessionGroupErrorCode,
 			InvalidSubscribeIDErrorCode,
+			FrameTooLargeErrorCode,
+			DroppedGroupErrorCode,
 		}
 
 		for _, code := range codes {
@@ -1,6 +1,7 @@
 package moqt
 
 import (
+	"errors"
 	"io"
 
 	"github.com/okdaichi/gomoqt/moqt/internal/message"
@@ -67,37 +68,52 @@ func (f *Frame) Cap() int {
 }
 
 // encode writes the frame in MOQ format: varint length followed by payload.
-// The length is encoded into the header buffer to minimize allocations.
 func (f *Frame) encode(w io.Writer) error {
+	_, err := w.Write(f.wire())
+	return err
+}
+
+// wire returns the frame in MOQ format: varint length followed by payload.
+// The length is encoded in front of the payload inside buf to avoid copying it.
+func (f *Frame) wire() []byte {
 	l := uint64(len(f.body))
 	header, _ := message.WriteMessageLength(f.header[:0], l)
 	start := 8 - len(header)
 	copy(f.buf[start:], header)
 	end := 8 + len(f.body)
-	_, err := w.Write(f.buf[start:end])
-	return err
+	return f.buf[start:end]
 }
 
+// errFrameTooLarge is returned when the length of a frame exceeds
+// the frame size limit of the stream it is read from.
+var errFrameTooLarge = errors.New("moqt: frame too large")
+
 // decode reads a MOQ frame from the reader, updating the payload.
 // The payload buffer is reused or reallocated as needed.
+// If src limits the frame size, a longer frame is not read.
 func (f *Frame) decode(src io.Reader) error {
-	num, err := message.ReadMessageLength(src)
+	num, err := message.ReadVarintFromReader(src)
 	if err != nil {
 		return err
 	}
 
+	if l, ok := src.(interface{ maxFrameSize() uint64 }); ok && num > l.maxFrameSize() {
+		return errFrameTooLarge
+	}
+
 	// If payload length is zero, reset the slice to zero length
 	if num == 0 {
 		f.body = f.body[:0]
 		return nil
 	}
 
-	// Ensure the payload slice has enough capacity
+	// Ensure the payload slice has enough capacity.
+	// The payload must stay inside buf so that the frame can be encoded again.
 	if cap(f.body) < int(num) {
-		f.body = make([]byte, num)
-	} else {
-		f.body = f.body[:num]
+		f.body = f.body[:0]
+		f.init(int(num))
 	}
+	f.body = f.body[:num]
 
 	_, err = io.ReadFull(src, f.body)
 
@@ -0,0 +1,98 @@
+package moqt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// maxPooledFrameCap is the largest payload capacity kept in the frame pool,
+// so that a few large frames do not pin memory.
+const maxPooledFrameCap = 1 << 20
+
+var framePool = sync.Pool{
+	New: func() any { return NewFrame(0) },
+}
+
+// GetFrame returns an empty frame with at least cap bytes of payload capacity,
+// reusing a frame from a pool when possible.
+// Return the frame with PutFrame when it is no longer used.
+func GetFrame(cap int) *Frame {
+	f := framePool.Get().(*Frame)
+	f.Reset()
+	if f.Cap() < cap {
+		f.init(cap)
+	}
+	return f
+}
+
+// PutFrame returns f to the pool used by GetFrame.
+// f must not be used after the call.
+func PutFrame(f *Frame) {
+	if f == nil || f.Cap() > maxPooledFrameCap {
+		return
+	}
+	f.Reset()
+	framePool.Put(f)
+}
+
+var sharedFramePool = sync.Pool{
+	New: func() any { return &SharedFrame{} },
+}
+
+// SharedFrame is a read-only, reference-counted frame.
+// It is encoded once and can be written to many groups concurrently
+// with GroupWriter.WriteSharedFrame, as relays do when fanning out a group.
+//
+// A SharedFrame starts with one reference. Call Retain for every additional
+// owner and Release when an owner is done. The underlying frame returns to
+// the pool used by GetFrame when the last reference is released.
+type SharedFrame struct {
+	frame *Frame
+	wire  []byte
+	refs  atomic.Int32
+}
+
+// Share returns a SharedFrame with one reference that takes over f.
+// f must not be used or modified after the call.
+func (f *Frame) Share() *SharedFrame {
+	s := sharedFramePool.Get().(*SharedFrame)
+	s.frame = f
+	s.wire = f.wire()
+	s.refs.Store(1)
+	return s
+}
+
+// Body returns the frame payload bytes. They must not be modified.
+func (s *SharedFrame) Body() []byte {
+	return s.frame.Body()
+}
+
+// Len returns the length of the payload in bytes.
+func (s *SharedFrame) Len() int {
+	return s.frame.Len()
+}
+
+// Retain adds a reference to the frame and returns it.
+func (s *SharedFrame) Retain() *SharedFrame {
+	if s.refs.Add(1) <= 1 {
+		panic("moqt: retain of a released SharedFrame")
+	}
+	return s
+}
+
+// Release drops a reference to the frame.
+// The frame must not be used by the caller after the call.
+func (s *SharedFrame) Release() {
+	refs := s.refs.Add(-1)
+	if refs > 0 {
+		return
+	}
+	if refs < 0 {
+		panic("moqt: SharedFrame released too many times")
+	}
+
+	PutFrame(s.frame)
+	s.frame = nil
+	s.wire = nil
+	sharedFramePool.Put(s)
+}
@@ -0,0 +1,68 @@
+package moqt
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetFrame(t *testing.T) {
+	f := GetFrame(128)
+	assert.Equal(t, 0, f.Len())
+	assert.GreaterOrEqual(t, f.Cap(), 128)
+
+	_, _ = f.Write([]byte("payload"))
+	PutFrame(f)
+
+	f = GetFrame(0)
+	assert.Equal(t, 0, f.Len(), "a pooled frame must be empty")
+	PutFrame(f)
+
+	// Nil and oversized frames are ignored
+	PutFrame(nil)
+	PutFrame(NewFrame(maxPooledFrameCap + 1))
+}
+
+func TestSharedFrame(t *testing.T) {
+	f := GetFrame(0)
+	_, _ = f.Write([]byte("shared"))
+	shared := f.Share()
+
+	assert.Equal(t, []byte("shared"), shared.Body())
+	assert.Equal(t, 6, shared.Len())
+
+	src := NewFrame(0)
+	_, _ = src.Write([]byte("shared"))
+	var want bytes.Buffer
+	require.NoError(t, src.encode(&want))
+
+	// Write the frame to many groups concurrently
+	bufs := make([]bytes.Buffer, 8)
+	var wg sync.WaitGroup
+	for i := range bufs {
+		gw := newGroupWriter(&mockSendStream{Writer: &bufs[i]}, GroupSequence(1), func() {})
+		frame := shared.Retain()
+		wg.Go(func() {
+			defer frame.Release()
+			assert.NoError(t, gw.WriteSharedFrame(frame))
+		})
+	}
+	wg.Wait()
+
+	for i := range bufs {
+		assert.Equal(t, want.Bytes(), bufs[i].Bytes())
+	}
+
+	shared.Release()
+	assert.Panics(t, shared.Release, "releasing a released frame must panic")
+}
+
+func TestGroupWriter_WriteSharedFrame_Nil(t *testing.T) {
+	gw := newGroupWriter(&mockSendStream{Writer: io.Discard}, GroupSequence(1), func() {})
+	assert.NoError(t, gw.WriteSharedFrame(nil))
+	assert.Equal(t, uint64(0), gw.frameCount.Load())
+}
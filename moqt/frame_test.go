@@ -166,6 +166,74 @@ func TestFrame_EncodeDecode_RoundTrip(t *testing.T) {
 	}
 }
 
+func TestFrame_DecodeThenEncode(t *testing.T) {
+	// A decoded frame must be encodable as is, e.g. when relaying groups
+	payload := []byte("relayed payload that does not fit the initial capacity")
+
+	src := NewFrame(0)
+	_, _ = src.Write(payload)
+	var wire bytes.Buffer
+	require.NoError(t, src.encode(&wire))
+
+	frame := NewFrame(0)
+	require.NoError(t, frame.decode(bytes.NewReader(wire.Bytes())))
+	assert.Equal(t, payload, frame.Body())
+
+	var out bytes.Buffer
+	require.NoError(t, frame.encode(&out))
+	assert.Equal(t, wire.Bytes(), out.Bytes())
+}
+
+// frameLimitReader limits the frames decoded from it as group streams do.
+type frameLimitReader struct {
+	*bytes.Reader
+	limit uint64
+}
+
+func (r frameLimitReader) maxFrameSize() uint64 {
+	return r.limit
+}
+
+func TestFrame_DecodeMaxFrameSize(t *testing.T) {
+	src := NewFrame(0)
+	_, _ = src.Write(make([]byte, 32))
+	var wire bytes.Buffer
+	require.NoError(t, src.encode(&wire))
+
+	frame := NewFrame(0)
+	require.NoError(t, frame.decode(frameLimitReader{bytes.NewReader(wire.Bytes()), 32}))
+	assert.Equal(t, 32, frame.Len())
+
+	frame = NewFrame(0)
+	err := frame.decode(frameLimitReader{bytes.NewReader(wire.Bytes()), 31})
+	assert.ErrorIs(t, err, errFrameTooLarge)
+	assert.Zero(t, frame.Cap())
+}
+
+func FuzzFrameDecode(f *testing.F) {
+	for _, payload := range [][]byte{nil, []byte("hello"), make([]byte, 300)} {
+		src := NewFrame(0)
+		_, _ = src.Write(payload)
+		var wire bytes.Buffer
+		require.NoError(f, src.encode(&wire))
+		f.Add(wire.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frame := NewFrame(0)
+		if err := frame.decode(frameLimitReader{bytes.NewReader(data), 1 << 16}); err != nil {
+			return
+		}
+
+		var out bytes.Buffer
+		require.NoError(t, frame.encode(&out))
+
+		decoded := NewFrame(0)
+		require.NoError(t, decoded.decode(&out))
+		require.Equal(t, frame.Body(), decoded.Body())
+	})
+}
+
 func TestFrame_WriteTo(t *testing.T) {
 	// Test WriteTo writes the payload to a writer
 	tests := []struct {
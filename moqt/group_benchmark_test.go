@@ -303,6 +303,206 @@ func BenchmarkFrame_ReuseVsAllocate(b *testing.B) {
 	})
 }
 
+// encodedGroup returns the wire format of a group of frames of size bytes.
+func encodedGroup(frames, size int) []byte {
+	frame := NewFrame(size)
+	frame.Write(make([]byte, size))
+
+	var buf bytes.Buffer
+	for range frames {
+		_ = frame.encode(&buf)
+	}
+	return buf.Bytes()
+}
+
+// BenchmarkGroup_FanOut compares relaying a group to many destinations by
+// copying every frame for each destination with sharing pooled frames.
+func BenchmarkGroup_FanOut(b *testing.B) {
+	const frames, size = 64, 1024
+	data := encodedGroup(frames, size)
+
+	for _, dests := range []int{1, 10, 100} {
+		writers := make([]*GroupWriter, dests)
+		for i := range writers {
+			writers[i] = newGroupWriter(&mockSendStream{Writer: io.Discard}, GroupSequence(1), func() {})
+		}
+
+		b.Run(fmt.Sprintf("copy-dests-%d", dests), func(b *testing.B) {
+			b.SetBytes(frames * size)
+			b.ReportAllocs()
+
+			for b.Loop() {
+				gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(data)}, func() {})
+				for {
+					frame := NewFrame(0)
+					if err := gr.ReadFrame(frame); err != nil {
+						break
+					}
+					for _, gw := range writers {
+						_ = gw.WriteFrame(frame.Clone())
+					}
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("shared-dests-%d", dests), func(b *testing.B) {
+			b.SetBytes(frames * size)
+			b.ReportAllocs()
+
+			for b.Loop() {
+				gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(data)}, func() {})
+				for {
+					frame := GetFrame(0)
+					if err := gr.ReadFrame(frame); err != nil {
+						PutFrame(frame)
+						break
+					}
+					shared := frame.Share()
+					for _, gw := range writers {
+						_ = gw.WriteSharedFrame(shared.Retain())
+						shared.Release()
+					}
+					shared.Release()
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCopyGroup compares forwarding a group by decoding and encoding
+// every frame with the raw passthrough of CopyGroup.
+func BenchmarkCopyGroup(b *testing.B) {
+	const frames = 64
+
+	for _, size := range []int{64, 1024, 16384} {
+		data := encodedGroup(frames, size)
+		gw := newGroupWriter(&mockSendStream{Writer: io.Discard}, GroupSequence(1), func() {})
+
+		b.Run(fmt.Sprintf("decode-encode-size-%d", size), func(b *testing.B) {
+			b.SetBytes(int64(frames * size))
+			b.ReportAllocs()
+
+			for b.Loop() {
+				gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(data)}, func() {})
+				frame := NewFrame(0)
+				for gr.ReadFrame(frame) == nil {
+					_ = gw.WriteFrame(frame)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("passthrough-size-%d", size), func(b *testing.B) {
+			b.SetBytes(int64(frames * size))
+			b.ReportAllocs()
+
+			for b.Loop() {
+				gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(data)}, func() {})
+				if _, err := CopyGroup(gw, gr); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// countingReceiveStream counts the reads from the stream.
+// Like QUIC streams, it does not implement io.ByteReader.
+type countingReceiveStream struct {
+	r     io.Reader
+	reads int
+}
+
+func (s *countingReceiveStream) Read(p []byte) (int, error) {
+	s.reads++
+	return s.r.Read(p)
+}
+
+func (s *countingReceiveStream) CancelRead(quic.StreamErrorCode)   {}
+func (s *countingReceiveStream) SetReadDeadline(t time.Time) error { return nil }
+func (s *countingReceiveStream) StreamID() quic.StreamID           { return 0 }
+
+// BenchmarkGroupReader_BufferedStream compares reading small frames
+// from a stream directly with reading them through the buffer of the
+// streams of a session, reporting the reads from the stream per group.
+func BenchmarkGroupReader_BufferedStream(b *testing.B) {
+	const frames, size = 64, 64
+	data := encodedGroup(frames, size)
+	conn := &sessionConn{maxFrameSize: DefaultMaxFrameSize}
+
+	for _, buffered := range []bool{false, true} {
+		name := "direct"
+		if buffered {
+			name = "buffered"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(frames * size)
+			b.ReportAllocs()
+
+			var reads int
+			frame := NewFrame(size)
+			for b.Loop() {
+				counter := &countingReceiveStream{r: bytes.NewReader(data)}
+				var stream quic.ReceiveStream = counter
+				if buffered {
+					stream = &connReceiveStream{ReceiveStream: counter, conn: conn}
+				}
+
+				gr := newGroupReader(GroupSequence(1), stream, func() {})
+				for gr.ReadFrame(frame) == nil {
+				}
+				reads += counter.reads
+			}
+			b.ReportMetric(float64(reads)/float64(b.N), "reads/op")
+		})
+	}
+}
+
+// BenchmarkGroupWriter_SmallFrames compares writing many small frames one
+// by one with WriteFrames and a BufferedGroupWriter, reporting the stream
+// writes per group.
+func BenchmarkGroupWriter_SmallFrames(b *testing.B) {
+	const count, size = 64, 32
+	frames := make([]*Frame, count)
+	for i := range frames {
+		frames[i] = NewFrame(size)
+		frames[i].Write(make([]byte, size))
+	}
+
+	writers := map[string]func(gw *GroupWriter){
+		"WriteFrame": func(gw *GroupWriter) {
+			for _, f := range frames {
+				_ = gw.WriteFrame(f)
+			}
+		},
+		"WriteFrames": func(gw *GroupWriter) {
+			_ = gw.WriteFrames(frames)
+		},
+		"Buffered": func(gw *GroupWriter) {
+			bw := NewBufferedGroupWriter(gw, 0)
+			for _, f := range frames {
+				_ = bw.WriteFrame(f)
+			}
+			_ = bw.Close()
+		},
+	}
+
+	for _, name := range []string{"WriteFrame", "WriteFrames", "Buffered"} {
+		b.Run(name, func(b *testing.B) {
+			w := &countingWriter{}
+			gw := newGroupWriter(&mockSendStream{Writer: w}, GroupSequence(1), func() {})
+
+			b.SetBytes(count * size)
+			b.ReportAllocs()
+			for b.Loop() {
+				w.Reset()
+				writers[name](gw)
+			}
+			b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
+
 // Mock implementations for testing
 
 type mockReceiveStream struct {
@@ -0,0 +1,116 @@
+package moqt
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+)
+
+const copyBufferSize = 32 << 10
+
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, copyBufferSize)
+		return &b
+	},
+}
+
+// CopyGroup copies the frames of src to dst until src ends,
+// and returns the number of frames copied.
+// Frames are copied with their length prefix as read from the stream,
+// through a pooled buffer, without being decoded into a Frame.
+//
+// A successful CopyGroup returns err == nil, not io.EOF.
+// Neither group is closed; the caller closes dst when src ends.
+func CopyGroup(dst *GroupWriter, src *GroupReader) (int, error) {
+	if err := src.skipFrame(); err != nil {
+		return 0, err
+	}
+
+	bp := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(bp)
+	buf := *bp
+
+	var frames int
+	for {
+		header, size, err := src.readFrameHeader(buf)
+		if err == nil && src.frameTooLarge(size) {
+			err = errFrameTooLarge
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// The group ended at a frame boundary
+				src.reportClosed(nil)
+				return frames, nil
+			}
+			return frames, src.readError(err)
+		}
+
+		// Fill the rest of the buffer with the payload so that
+		// small frames are written with a single call
+		n := len(header)
+		remaining := size
+		for {
+			chunk := min(uint64(len(buf)-n), remaining)
+			if chunk > 0 {
+				if _, err := io.ReadFull(src.stream, buf[n:n+int(chunk)]); err != nil {
+					if errors.Is(err, io.EOF) {
+						err = io.ErrUnexpectedEOF
+					}
+					return frames, src.readError(err)
+				}
+			}
+			remaining -= chunk
+
+			if _, err := dst.stream.Write(buf[:n+int(chunk)]); err != nil {
+				return frames, err
+			}
+			n = 0
+
+			if remaining == 0 {
+				break
+			}
+		}
+
+		frames++
+		src.frameRead(int(size))
+		dst.frameWritten(int(size))
+	}
+}
+
+// readFrameHeader reads the length prefix of the next frame into buf
+// and returns it with the payload length.
+// It returns io.EOF if the group ends before the frame.
+func (s *GroupReader) readFrameHeader(buf []byte) ([]byte, uint64, error) {
+	_, err := io.ReadFull(s.stream, buf[:1])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	l := 1 << (buf[0] >> 6)
+	if l > 1 {
+		_, err = io.ReadFull(s.stream, buf[1:l])
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, 0, err
+		}
+	}
+
+	size, _, err := message.ReadVarint(buf[:l])
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return buf[:l], size, nil
+}
+
+// frameTooLarge reports whether a frame of size bytes exceeds
+// the frame size limit of the stream.
+func (s *GroupReader) frameTooLarge(size uint64) bool {
+	lim, ok := s.stream.(interface{ maxFrameSize() uint64 })
+	return ok && size > lim.maxFrameSize()
+}
@@ -0,0 +1,101 @@
+package moqt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// limitedReceiveStream limits the frames read from it as session streams do.
+type limitedReceiveStream struct {
+	mockReceiveStream
+	limit uint64
+}
+
+func (s *limitedReceiveStream) maxFrameSize() uint64 {
+	return s.limit
+}
+
+func TestCopyGroup(t *testing.T) {
+	var src bytes.Buffer
+	for _, payload := range []string{"", "a", string(make([]byte, 100)), string(make([]byte, copyBufferSize+10))} {
+		f := NewFrame(0)
+		_, _ = f.Write([]byte(payload))
+		require.NoError(t, f.encode(&src))
+	}
+	want := bytes.Clone(src.Bytes())
+
+	gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(src.Bytes())}, func() {})
+	var dst bytes.Buffer
+	gw := newGroupWriter(&mockSendStream{Writer: &dst}, GroupSequence(1), func() {})
+
+	n, err := CopyGroup(gw, gr)
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, want, dst.Bytes(), "frames must be copied as is")
+	assert.Equal(t, uint64(4), gw.frameCount.Load())
+}
+
+func TestCopyGroup_TruncatedFrame(t *testing.T) {
+	tests := map[string][]byte{
+		"truncated header":  {0x40},
+		"truncated payload": {0x05, 'a', 'b'},
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(data)}, func() {})
+			gw := newGroupWriter(&mockSendStream{Writer: io.Discard}, GroupSequence(1), func() {})
+
+			n, err := CopyGroup(gw, gr)
+			assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+			assert.Equal(t, 0, n)
+		})
+	}
+}
+
+func TestCopyGroup_FrameTooLarge(t *testing.T) {
+	f := NewFrame(0)
+	_, _ = f.Write(make([]byte, 32))
+	var src bytes.Buffer
+	require.NoError(t, f.encode(&src))
+
+	stream := &limitedReceiveStream{
+		mockReceiveStream: mockReceiveStream{Reader: bytes.NewReader(src.Bytes())},
+		limit:             16,
+	}
+	gr := newGroupReader(GroupSequence(1), stream, func() {})
+	var dst bytes.Buffer
+	gw := newGroupWriter(&mockSendStream{Writer: &dst}, GroupSequence(1), func() {})
+
+	_, err := CopyGroup(gw, gr)
+	var grpErr *GroupError
+	require.ErrorAs(t, err, &grpErr)
+	assert.Equal(t, FrameTooLargeErrorCode, grpErr.GroupErrorCode())
+	assert.Zero(t, dst.Len())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, &quic.StreamError{ErrorCode: quic.StreamErrorCode(SubscribeCanceledErrorCode)}
+}
+
+func TestCopyGroup_WriteError(t *testing.T) {
+	f := NewFrame(0)
+	_, _ = f.Write([]byte("payload"))
+	var src bytes.Buffer
+	require.NoError(t, f.encode(&src))
+
+	gr := newGroupReader(GroupSequence(1), &mockReceiveStream{Reader: bytes.NewReader(src.Bytes())}, func() {})
+	gw := newGroupWriter(&mockSendStream{Writer: failingWriter{}}, GroupSequence(1), func() {})
+
+	_, err := CopyGroup(gw, gr)
+	var strErr *quic.StreamError
+	assert.True(t, errors.As(err, &strErr))
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"iter"
+	"sync/atomic"
 	"time"
 
 	"github.com/okdaichi/gomoqt/quic"
@@ -27,7 +28,62 @@ type GroupReader struct {
 	stream     quic.ReceiveStream
 	frameCount int64
 
+	// frame is the last frame returned by NextFrame
+	frame  *FrameReader
+	header [8]byte
+
 	onClose func()
+
+	stats   *trackCounters
+	metrics Metrics
+	path    BroadcastPath
+	ended   atomic.Bool
+}
+
+// instrument reports the group and its frames to m.
+func (s *GroupReader) instrument(m Metrics, path BroadcastPath) {
+	s.metrics = m
+	s.path = path
+
+	m.GroupOpened(RoleSubscriber, path)
+}
+
+// reportClosed reports the end of the group once.
+func (s *GroupReader) reportClosed(err error) {
+	if !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+
+	traceGroupClosed(s.stream, ownerRemote, uint64(s.frameCount), err)
+
+	if err != nil {
+		s.stats.addCanceled()
+	}
+
+	if s.metrics != nil {
+		s.metrics.GroupClosed(RoleSubscriber, s.path, err)
+	}
+}
+
+// frameRead counts a frame of size bytes read from the group.
+func (s *GroupReader) frameRead(size int) {
+	s.frameCount++
+	s.stats.addFrame(size)
+
+	if s.metrics != nil {
+		s.metrics.FrameTransferred(RoleSubscriber, s.path, size)
+	}
+}
+
+// frameEnded counts a frame returned by NextFrame once its payload of size
+// bytes has been read or skipped. Its bytes are counted as they are read.
+func (s *GroupReader) frameEnded(size int64) {
+	s.frameCount++
+	s.stats.addFrame(0)
+
+	if s.metrics != nil {
+		s.metrics.FrameTransferred(RoleSubscriber, s.path, int(size))
+	}
 }
 
 // GroupSequence returns the GroupSequence this reader belongs to.
@@ -41,33 +97,102 @@ func (s *GroupReader) ReadFrame(frame *Frame) error {
 	if frame == nil {
 		panic("nil frame")
 	}
+	if err := s.skipFrame(); err != nil {
+		return err
+	}
+
 	err := frame.decode(s.stream)
 	if err != nil {
-		if errors.Is(err, io.EOF) {
-			return err
-		}
+		return s.readError(err)
+	}
 
-		var strErr *quic.StreamError
-		if errors.As(err, &strErr) {
-			grpErr := &GroupError{
-				StreamError: strErr,
-			}
+	s.frameRead(frame.Len())
 
-			return grpErr
+	return nil
+}
+
+// NextFrame returns a reader of the payload of the next frame, so that large
+// frames can be read without holding them in memory. The reader returns
+// io.EOF at the end of the payload. Calling NextFrame or ReadFrame again
+// discards the part of the payload that was not read. The payload bytes are
+// counted in the stats as they are read, and the frame once it is read or
+// discarded.
+//
+// As the payload is not allocated, frames longer than Config.MaxFrameSize
+// are not rejected. If io.EOF is returned, the group stream has been closed.
+func (s *GroupReader) NextFrame() (*FrameReader, error) {
+	if err := s.skipFrame(); err != nil {
+		return nil, err
+	}
+
+	_, size, err := s.readFrameHeader(s.header[:])
+	if err != nil {
+		return nil, s.readError(err)
+	}
+
+	if size == 0 {
+		s.frameEnded(0)
+	}
+
+	s.frame = &FrameReader{group: s, size: int64(size), remaining: int64(size)}
+	return s.frame, nil
+}
+
+// skipFrame discards the rest of the frame returned by NextFrame.
+func (s *GroupReader) skipFrame() error {
+	if s.frame == nil {
+		return nil
+	}
+	f := s.frame
+	s.frame = nil
+
+	if f.remaining == 0 {
+		return nil
+	}
+	_, err := io.Copy(io.Discard, f)
+	return err
+}
+
+// readError reports the end of the group for an error reading from its
+// stream and returns the error to give to the caller.
+func (s *GroupReader) readError(err error) error {
+	if errors.Is(err, errFrameTooLarge) {
+		s.CancelRead(FrameTooLargeErrorCode)
+		return &GroupError{
+			StreamError: &quic.StreamError{
+				StreamID:  s.stream.StreamID(),
+				ErrorCode: quic.StreamErrorCode(FrameTooLargeErrorCode),
+			},
 		}
+	}
 
+	if errors.Is(err, io.EOF) {
+		s.reportClosed(nil)
 		return err
 	}
 
-	s.frameCount++
+	var strErr *quic.StreamError
+	if errors.As(err, &strErr) {
+		grpErr := &GroupError{
+			StreamError: strErr,
+		}
 
-	return nil
+		s.reportClosed(grpErr)
+		return grpErr
+	}
+
+	s.reportClosed(err)
+	return err
 }
 
 // CancelRead cancels the group using the provided GroupErrorCode.
 func (s *GroupReader) CancelRead(code GroupErrorCode) {
 	strErrCode := quic.StreamErrorCode(code)
 	s.stream.CancelRead(strErrCode)
+
+	s.reportClosed(&GroupError{
+		StreamError: &quic.StreamError{StreamID: s.stream.StreamID(), ErrorCode: strErrCode},
+	})
 }
 
 // SetReadDeadline sets the read deadline for read operations.
@@ -94,3 +219,48 @@ func (s *GroupReader) Frames(buf *Frame) iter.Seq[*Frame] {
 		}
 	}
 }
+
+// FrameReader reads the payload of a frame returned by GroupReader.NextFrame.
+type FrameReader struct {
+	group     *GroupReader
+	size      int64
+	remaining int64
+}
+
+// Size returns the length of the payload in bytes.
+func (r *FrameReader) Size() int64 {
+	return r.size
+}
+
+// Read reads from the payload. It returns io.EOF at the end of the payload,
+// and io.ErrUnexpectedEOF if the group ends before it.
+func (r *FrameReader) Read(p []byte) (int, error) {
+	if r.remaining == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.group.stream.Read(p)
+	r.remaining -= int64(n)
+	if n > 0 {
+		r.group.stats.addBytes(n)
+		if r.remaining == 0 {
+			r.group.frameEnded(r.size)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			if r.remaining == 0 {
+				// The group ends with the frame
+				return n, nil
+			}
+			err = io.ErrUnexpectedEOF
+		}
+		return n, r.group.readError(err)
+	}
+
+	return n, nil
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/okdaichi/gomoqt/quic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewReceiveGroupStream(t *testing.T) {
@@ -363,3 +364,68 @@ func TestGroupReader_Frames(t *testing.T) {
 		assert.Equal(t, 0, frameCount)
 	})
 }
+
+func TestGroupReader_NextFrame(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), 10000)
+
+	var data bytes.Buffer
+	for _, payload := range [][]byte{large, []byte("skipped"), {}, []byte("last")} {
+		frame := NewFrame(0)
+		_, _ = frame.Write(payload)
+		require.NoError(t, frame.encode(&data))
+	}
+
+	// A frame size limit does not apply to streamed frames
+	stream := &limitedReceiveStream{
+		mockReceiveStream: mockReceiveStream{Reader: bytes.NewReader(data.Bytes())},
+		limit:             16,
+	}
+	gr := newGroupReader(1, stream, func() {})
+	gr.stats = &trackCounters{}
+
+	fr, err := gr.NextFrame()
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(large)), fr.Size())
+	assert.Zero(t, gr.stats.bytes.Load(), "bytes must be counted as they are read")
+	got, err := io.ReadAll(fr)
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+	assert.Equal(t, int64(1), gr.frameCount)
+
+	// The unread part of a frame is discarded
+	fr, err = gr.NextFrame()
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), fr.Size())
+	buf := make([]byte, 3)
+	_, err = io.ReadFull(fr, buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), gr.frameCount, "a partly read frame must not be counted")
+
+	fr, err = gr.NextFrame()
+	require.NoError(t, err)
+	assert.Zero(t, fr.Size())
+	n, err := fr.Read(buf)
+	assert.Zero(t, n)
+	assert.ErrorIs(t, err, io.EOF)
+
+	// ReadFrame and NextFrame can be mixed
+	frame := NewFrame(0)
+	require.NoError(t, gr.ReadFrame(frame))
+	assert.Equal(t, []byte("last"), frame.Body())
+
+	_, err = gr.NextFrame()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(4), gr.frameCount)
+	assert.Equal(t, uint64(4), gr.stats.frames.Load())
+	assert.Equal(t, uint64(len(large)+len("skipped")+len("last")), gr.stats.bytes.Load())
+}
+
+func TestGroupReader_NextFrame_Truncated(t *testing.T) {
+	gr := newGroupReader(1, &mockReceiveStream{Reader: bytes.NewReader([]byte{0x05, 'a', 'b'})}, func() {})
+
+	fr, err := gr.NextFrame()
+	require.NoError(t, err)
+	_, err = io.ReadAll(fr)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Zero(t, gr.frameCount, "a truncated frame must not be counted")
+}
@@ -2,6 +2,10 @@ package moqt
 
 import (
 	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/okdaichi/gomoqt/moqt/internal/message"
@@ -16,6 +20,7 @@ func newGroupWriter(stream quic.SendStream, sequence GroupSequence,
 		onClose:  onClose,
 		stream:   stream,
 		ctx:      context.WithValue(stream.Context(), &uniStreamTypeCtxKey, message.StreamTypeGroup),
+		opened:   time.Now(),
 	}
 }
 
@@ -27,9 +32,93 @@ type GroupWriter struct {
 	ctx    context.Context
 	stream quic.SendStream
 
-	frameCount uint64 // Number of frames sent on this stream
+	frameCount atomic.Uint64 // Number of frames sent on this stream
 
 	onClose func()
+
+	// expiry cancels the group when the delivery timeout has passed
+	expiryMu sync.Mutex
+	expiry   *time.Timer
+	opened   time.Time
+	ended    bool
+	expired  bool
+
+	stats   *trackCounters
+	metrics Metrics
+	path    BroadcastPath
+}
+
+// instrument reports the group and its frames to m.
+func (sgs *GroupWriter) instrument(m Metrics, path BroadcastPath) {
+	sgs.metrics = m
+	sgs.path = path
+
+	m.GroupOpened(RolePublisher, path)
+
+	ctx := sgs.ctx
+	context.AfterFunc(ctx, func() {
+		m.GroupClosed(RolePublisher, path, closeError(ctx))
+	})
+}
+
+// frameWritten counts a frame of size bytes written to the group.
+func (sgs *GroupWriter) frameWritten(size int) {
+	sgs.frameCount.Add(1)
+	sgs.stats.addFrame(size)
+
+	if sgs.metrics != nil {
+		sgs.metrics.FrameTransferred(RolePublisher, sgs.path, size)
+	}
+}
+
+// setDeliveryTimeout cancels the group with ExpiredGroupErrorCode once
+// timeout has passed since it was opened, replacing the previous timeout.
+// A timeout of zero or less does not cancel the group.
+func (sgs *GroupWriter) setDeliveryTimeout(timeout time.Duration) {
+	sgs.expiryMu.Lock()
+	defer sgs.expiryMu.Unlock()
+
+	if sgs.expiry != nil {
+		sgs.expiry.Stop()
+		sgs.expiry = nil
+	}
+
+	if timeout <= 0 || sgs.ended {
+		return
+	}
+
+	sgs.expiry = time.AfterFunc(timeout-time.Since(sgs.opened), sgs.expire)
+}
+
+func (sgs *GroupWriter) expire() {
+	// End the group in the same critical section so that a racing Close
+	// or CancelWrite does not end it a second time
+	sgs.expiryMu.Lock()
+	if sgs.ended {
+		sgs.expiryMu.Unlock()
+		return
+	}
+	sgs.ended = true
+	sgs.expired = true
+	sgs.expiry = nil
+	sgs.expiryMu.Unlock()
+
+	sgs.cancelWrite(ExpiredGroupErrorCode)
+}
+
+// end stops the delivery timeout of the group and reports whether the
+// group already expired.
+func (sgs *GroupWriter) end() bool {
+	sgs.expiryMu.Lock()
+	defer sgs.expiryMu.Unlock()
+
+	sgs.ended = true
+	if sgs.expiry != nil {
+		sgs.expiry.Stop()
+		sgs.expiry = nil
+	}
+
+	return sgs.expired
 }
 
 // GroupSequence returns the group sequence identifier associated with this writer.
@@ -48,7 +137,86 @@ func (sgs *GroupWriter) WriteFrame(frame *Frame) error {
 		return err
 	}
 
-	sgs.frameCount++
+	sgs.frameWritten(frame.Len())
+
+	return nil
+}
+
+// WriteFrames writes frames to the group stream, coalescing them into
+// as few stream writes as possible through a pooled buffer.
+// Frames larger than the buffer are written directly.
+func (sgs *GroupWriter) WriteFrames(frames []*Frame) error {
+	bp := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(bp)
+
+	w := BufferedGroupWriter{group: sgs, buf: (*bp)[:0]}
+	for _, frame := range frames {
+		if err := w.WriteFrame(frame); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// WriteFrameFrom writes a frame of n bytes read from r to the group stream,
+// without holding the whole payload in memory.
+// If r ends or fails before n bytes are read, the frame cannot be completed
+// and the group is canceled with InternalGroupErrorCode.
+func (sgs *GroupWriter) WriteFrameFrom(r io.Reader, n int64) error {
+	if n < 0 {
+		return errors.New("moqt: negative frame size")
+	}
+
+	bp := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(bp)
+	buf := *bp
+
+	// Write the length prefix with the beginning of the payload
+	header, _ := message.WriteMessageLength(buf[:0], uint64(n))
+	off := len(header)
+	remaining := n
+	for {
+		chunk := min(int64(len(buf)-off), remaining)
+		if chunk > 0 {
+			if _, err := io.ReadFull(r, buf[off:off+int(chunk)]); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = io.ErrUnexpectedEOF
+				}
+				sgs.CancelWrite(InternalGroupErrorCode)
+				return err
+			}
+		}
+		remaining -= chunk
+
+		if _, err := sgs.stream.Write(buf[:off+int(chunk)]); err != nil {
+			return err
+		}
+		off = 0
+
+		if remaining == 0 {
+			break
+		}
+	}
+
+	sgs.frameWritten(int(n))
+
+	return nil
+}
+
+// WriteSharedFrame writes a SharedFrame to the group stream.
+// The frame is written as encoded by Frame.Share, so the same frame can be
+// written to many groups concurrently. The caller keeps its reference.
+func (sgs *GroupWriter) WriteSharedFrame(frame *SharedFrame) error {
+	if frame == nil {
+		return nil
+	}
+
+	_, err := sgs.stream.Write(frame.wire)
+	if err != nil {
+		return err
+	}
+
+	sgs.frameWritten(frame.Len())
 
 	return nil
 }
@@ -60,18 +228,35 @@ func (sgs *GroupWriter) SetWriteDeadline(t time.Time) error {
 
 // CancelWrite cancels the group with the specified GroupErrorCode and triggers callbacks.
 func (sgs *GroupWriter) CancelWrite(code GroupErrorCode) {
+	if sgs.end() {
+		return
+	}
+	sgs.cancelWrite(code)
+}
+
+func (sgs *GroupWriter) cancelWrite(code GroupErrorCode) {
 	sgs.stream.CancelWrite(quic.StreamErrorCode(code))
 
+	traceGroupCanceled(sgs.stream, sgs.frameCount.Load(), code)
+
+	sgs.stats.addCanceled()
+
 	sgs.onClose()
 }
 
 // Close closes the group stream gracefully.
 func (sgs *GroupWriter) Close() error {
+	if sgs.end() {
+		return Cause(sgs.ctx)
+	}
+
 	err := sgs.stream.Close()
 	if err != nil {
 		return Cause(sgs.ctx)
 	}
 
+	traceGroupClosed(sgs.stream, ownerLocal, sgs.frameCount.Load(), nil)
+
 	sgs.onClose()
 
 	return nil
@@ -81,3 +266,35 @@ func (sgs *GroupWriter) Close() error {
 func (s *GroupWriter) Context() context.Context {
 	return s.ctx
 }
+
+// discardStream stands in for the stream of a group that is not sent.
+// Writes succeed without sending anything.
+type discardStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newDiscardStream(parent context.Context) *discardStream {
+	ctx, cancel := context.WithCancel(parent)
+	return &discardStream{ctx: ctx, cancel: cancel}
+}
+
+func (s *discardStream) Write(p []byte) (int, error) {
+	if s.ctx.Err() != nil {
+		return 0, context.Cause(s.ctx)
+	}
+	return len(p), nil
+}
+
+func (s *discardStream) Close() error {
+	s.cancel()
+	return nil
+}
+
+func (s *discardStream) StreamID() quic.StreamID { return 0 }
+
+func (s *discardStream) CancelWrite(quic.StreamErrorCode) { s.cancel() }
+
+func (s *discardStream) SetWriteDeadline(time.Time) error { return nil }
+
+func (s *discardStream) Context() context.Context { return s.ctx }
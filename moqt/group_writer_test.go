@@ -1,8 +1,10 @@
 package moqt
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/okdaichi/gomoqt/quic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewGroupWriter(t *testing.T) {
@@ -44,7 +47,7 @@ func TestNewGroupWriter(t *testing.T) {
 
 			assert.NotNil(t, sgs)
 			assert.Equal(t, tt.sequence, sgs.sequence)
-			assert.Equal(t, uint64(0), sgs.frameCount)
+			assert.Equal(t, uint64(0), sgs.frameCount.Load())
 			assert.NotNil(t, sgs.ctx)
 			assert.Equal(t, mockStream, sgs.stream)
 			assert.NotNil(t, sgs.onClose)
@@ -168,7 +171,7 @@ func TestGroupWriter_ContextCancellation(t *testing.T) {
 		_, _ = frameLocal.Write([]byte("test"))
 		err := sgs.WriteFrame(frameLocal)
 		assert.NoError(t, err)
-		assert.Equal(t, uint64(1), sgs.frameCount)
+		assert.Equal(t, uint64(1), sgs.frameCount.Load())
 		mockStream.AssertExpectations(t)
 	})
 }
@@ -252,3 +255,100 @@ func TestGroupWriter_CancelWrite(t *testing.T) {
 	assert.True(t, called)
 	mockStream.AssertExpectations(t)
 }
+
+func TestGroupWriter_DeliveryTimeout(t *testing.T) {
+	t.Run("expires", func(t *testing.T) {
+		canceled := make(chan struct{})
+		mockStream := &MockQUICSendStream{}
+		mockStream.On("Context").Return(context.Background())
+		mockStream.On("CancelWrite", quic.StreamErrorCode(ExpiredGroupErrorCode)).Return()
+
+		sgs := newGroupWriter(mockStream, GroupSequence(1), func() { close(canceled) })
+		sgs.setDeliveryTimeout(10 * time.Millisecond)
+
+		select {
+		case <-canceled:
+		case <-time.After(time.Second):
+			t.Fatal("the group did not expire")
+		}
+		mockStream.AssertExpectations(t)
+	})
+
+	t.Run("stopped by close", func(t *testing.T) {
+		mockStream := &MockQUICSendStream{}
+		mockStream.On("Context").Return(context.Background())
+		mockStream.On("Close").Return(nil)
+
+		sgs := newGroupWriter(mockStream, GroupSequence(1), func() {})
+		sgs.setDeliveryTimeout(10 * time.Millisecond)
+		require.NoError(t, sgs.Close())
+
+		time.Sleep(30 * time.Millisecond)
+		mockStream.AssertNotCalled(t, "CancelWrite", mock.Anything)
+	})
+
+	t.Run("close after expiry", func(t *testing.T) {
+		var closes int
+		mockStream := &MockQUICSendStream{}
+		mockStream.On("Context").Return(context.Background())
+		mockStream.On("CancelWrite", quic.StreamErrorCode(ExpiredGroupErrorCode)).Return()
+
+		sgs := newGroupWriter(mockStream, GroupSequence(1), func() { closes++ })
+		sgs.stats = &trackCounters{}
+		sgs.expire()
+		_ = sgs.Close()
+		sgs.CancelWrite(InternalGroupErrorCode)
+		sgs.expire()
+
+		assert.Equal(t, 1, closes)
+		assert.Equal(t, uint64(1), sgs.stats.canceled.Load())
+		mockStream.AssertNumberOfCalls(t, "CancelWrite", 1)
+		mockStream.AssertNotCalled(t, "Close")
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		mockStream := &MockQUICSendStream{}
+		mockStream.On("Context").Return(context.Background())
+
+		sgs := newGroupWriter(mockStream, GroupSequence(1), func() {})
+		sgs.setDeliveryTimeout(10 * time.Millisecond)
+		sgs.setDeliveryTimeout(0)
+
+		time.Sleep(30 * time.Millisecond)
+		mockStream.AssertNotCalled(t, "CancelWrite", mock.Anything)
+	})
+}
+
+func TestGroupWriter_WriteFrameFrom(t *testing.T) {
+	for _, size := range []int{0, 10, copyBufferSize - 1, 3*copyBufferSize + 5} {
+		payload := bytes.Repeat([]byte{0xab}, size)
+
+		var got bytes.Buffer
+		gw := newGroupWriter(&mockSendStream{Writer: &got}, GroupSequence(1), func() {})
+		require.NoError(t, gw.WriteFrameFrom(bytes.NewReader(payload), int64(size)))
+
+		want := NewFrame(0)
+		_, _ = want.Write(payload)
+		var wire bytes.Buffer
+		require.NoError(t, want.encode(&wire))
+		assert.Equal(t, wire.Bytes(), got.Bytes(), "size %d", size)
+		assert.Equal(t, uint64(1), gw.frameCount.Load())
+	}
+}
+
+func TestGroupWriter_WriteFrameFrom_ShortReader(t *testing.T) {
+	closed := false
+	mockStream := &MockQUICSendStream{}
+	mockStream.On("Context").Return(context.Background())
+	mockStream.On("Write", mock.Anything).Return(0, nil).Maybe()
+	mockStream.On("CancelWrite", quic.StreamErrorCode(InternalGroupErrorCode)).Return()
+
+	gw := newGroupWriter(mockStream, GroupSequence(1), func() { closed = true })
+
+	err := gw.WriteFrameFrom(bytes.NewReader([]byte("short")), 10)
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.True(t, closed)
+	mockStream.AssertExpectations(t)
+
+	assert.Error(t, gw.WriteFrameFrom(bytes.NewReader(nil), -1))
+}
@@ -29,7 +29,9 @@ func (am AnnounceMessage) Len() int {
 func (am AnnounceMessage) Encode(w io.Writer) error {
 	msgLen := am.Len()
 
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, uint64(am.AnnounceStatus))
@@ -46,7 +48,9 @@ func (am *AnnounceMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -19,7 +19,9 @@ func (aim AnnounceInitMessage) Len() int {
 
 func (aim AnnounceInitMessage) Encode(dst io.Writer) error {
 	msgLen := aim.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteStringArray(b, aim.Suffixes)
@@ -35,7 +37,9 @@ func (aim *AnnounceInitMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -48,6 +52,10 @@ func (aim *AnnounceInitMessage) Decode(src io.Reader) error {
 	}
 	b = b[n:]
 
+	if count > uint64(len(b)) {
+		return io.EOF
+	}
+
 	aim.Suffixes = make([]string, count)
 	var str string
 	for i := range aim.Suffixes {
@@ -19,7 +19,9 @@ func (aim AnnouncePleaseMessage) Len() int {
 
 func (aim AnnouncePleaseMessage) Encode(w io.Writer) error {
 	msgLen := aim.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteString(b, aim.TrackPrefix)
@@ -35,7 +37,9 @@ func (aim *AnnouncePleaseMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, num)
+	bp := getBuffer(int(num))
+	defer putBuffer(bp)
+	b := (*bp)[:num]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -0,0 +1,37 @@
+package message
+
+import "sync"
+
+// maxPooledBufferSize is the largest buffer kept in the pool,
+// so that a few large messages do not pin memory.
+const maxPooledBufferSize = 4 << 10
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer with at least n bytes of capacity
+// from a pool. Return it with putBuffer when it is no longer used.
+//
+// Encode methods build messages in these buffers. Decode methods read
+// the body into them only when no field refers to the body afterwards,
+// which rules out messages with parameters.
+func getBuffer(n int) *[]byte {
+	bp := bufferPool.Get().(*[]byte)
+	if cap(*bp) < n {
+		*bp = make([]byte, 0, n)
+	}
+	*bp = (*bp)[:0]
+	return bp
+}
+
+// putBuffer returns a buffer obtained with getBuffer to the pool.
+func putBuffer(bp *[]byte) {
+	if cap(*bp) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(bp)
+}
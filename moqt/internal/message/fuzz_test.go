@@ -0,0 +1,117 @@
+package message_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+	"github.com/stretchr/testify/require"
+)
+
+// fuzzMaxMessageSize bounds the messages decoded while fuzzing,
+// as sessions do with Config.MaxMessageSize.
+const fuzzMaxMessageSize = 1 << 16
+
+type limitedReader struct {
+	*bytes.Reader
+}
+
+func (limitedReader) MaxMessageSize() uint64 {
+	return fuzzMaxMessageSize
+}
+
+type decoder[T any] interface {
+	*T
+	Decode(src io.Reader) error
+}
+
+// fuzzMessage seeds f with the encoding of seeds and checks that decoding
+// arbitrary bytes does not panic and that well-formed messages survive
+// a round trip.
+func fuzzMessage[T interface{ Encode(io.Writer) error }, P decoder[T]](f *testing.F, seeds ...T) {
+	for _, seed := range seeds {
+		var buf bytes.Buffer
+		require.NoError(f, seed.Encode(&buf))
+		f.Add(buf.Bytes())
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var msg T
+		if err := P(&msg).Decode(limitedReader{bytes.NewReader(data)}); err != nil {
+			return
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, msg.Encode(&buf))
+
+		var decoded T
+		require.NoError(t, P(&decoded).Decode(&buf))
+		require.Equal(t, msg, decoded)
+	})
+}
+
+func FuzzSessionClientMessage(f *testing.F) {
+	fuzzMessage(f, message.SessionClientMessage{
+		SupportedVersions: []uint64{0xffffff01},
+		Parameters:        map[uint64][]byte{1: []byte("value")},
+	})
+}
+
+func FuzzSessionServerMessage(f *testing.F) {
+	fuzzMessage(f, message.SessionServerMessage{
+		SelectedVersion: 0xffffff01,
+		Parameters:      map[uint64][]byte{1: []byte("value")},
+	})
+}
+
+func FuzzSessionUpdateMessage(f *testing.F) {
+	fuzzMessage(f, message.SessionUpdateMessage{Bitrate: 1000000})
+}
+
+func FuzzAnnouncePleaseMessage(f *testing.F) {
+	fuzzMessage(f, message.AnnouncePleaseMessage{TrackPrefix: "/live/"})
+}
+
+func FuzzAnnounceInitMessage(f *testing.F) {
+	fuzzMessage(f, message.AnnounceInitMessage{Suffixes: []string{"a", "b/c"}})
+}
+
+func FuzzAnnounceMessage(f *testing.F) {
+	fuzzMessage(f, message.AnnounceMessage{
+		AnnounceStatus: message.ACTIVE,
+		TrackSuffix:    "room/1",
+	})
+}
+
+func FuzzSubscribeMessage(f *testing.F) {
+	fuzzMessage(f, message.SubscribeMessage{
+		SubscribeID:   1,
+		BroadcastPath: "/live/room",
+		TrackName:     "video",
+		TrackPriority: 5,
+	}, message.SubscribeMessage{
+		SubscribeID:     2,
+		BroadcastPath:   "/live/room",
+		TrackName:       "audio",
+		DeliveryTimeout: 250,
+	}, message.SubscribeMessage{
+		SubscribeID:   3,
+		BroadcastPath: "/live/room",
+		TrackName:     "video",
+		StartGroup:    7,
+	})
+}
+
+func FuzzSubscribeOkMessage(f *testing.F) {
+	fuzzMessage(f, message.SubscribeOkMessage{})
+}
+
+func FuzzSubscribeUpdateMessage(f *testing.F) {
+	fuzzMessage(f, message.SubscribeUpdateMessage{TrackPriority: 3},
+		message.SubscribeUpdateMessage{TrackPriority: 3, DeliveryTimeout: 250})
+}
+
+func FuzzGroupMessage(f *testing.F) {
+	fuzzMessage(f, message.GroupMessage{SubscribeID: 1, GroupSequence: 42})
+}
@@ -21,7 +21,9 @@ func (g GroupMessage) Len() int {
 
 func (g GroupMessage) Encode(w io.Writer) error {
 	msgLen := g.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, g.SubscribeID)
@@ -38,7 +40,9 @@ func (g *GroupMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -67,3 +71,7 @@ func (g *GroupMessage) Decode(src io.Reader) error {
 }
 
 var ErrMessageTooShort = errors.New("message too short")
+
+// ErrMessageTooLarge is returned when the length of a message exceeds
+// the limit of the reader it is decoded from.
+var ErrMessageTooLarge = errors.New("message too large")
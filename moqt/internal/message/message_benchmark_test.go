@@ -0,0 +1,90 @@
+package message_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+)
+
+// BenchmarkReadVarintFromReader compares reading varints from a plain
+// reader with reading them from a buffered one.
+func BenchmarkReadVarintFromReader(b *testing.B) {
+	var data []byte
+	for i := range 1024 {
+		data, _ = message.WriteVarint(data, uint64(i*i))
+	}
+
+	b.Run("reader", func(b *testing.B) {
+		b.ReportAllocs()
+		r := bytes.NewReader(data)
+		plain := struct{ io.Reader }{r} // Hide io.ByteReader
+		for b.Loop() {
+			if _, err := message.ReadVarintFromReader(plain); err != nil {
+				r.Reset(data)
+			}
+		}
+	})
+
+	b.Run("buffered", func(b *testing.B) {
+		b.ReportAllocs()
+		r := bytes.NewReader(data)
+		br := bufio.NewReader(r)
+		for b.Loop() {
+			if _, err := message.ReadVarintFromReader(br); err != nil {
+				r.Reset(data)
+				br.Reset(r)
+			}
+		}
+	})
+}
+
+func BenchmarkGroupMessage_Encode(b *testing.B) {
+	msg := message.GroupMessage{SubscribeID: 1, GroupSequence: 1 << 20}
+	b.ReportAllocs()
+	for b.Loop() {
+		_ = msg.Encode(io.Discard)
+	}
+}
+
+func BenchmarkGroupMessage_Decode(b *testing.B) {
+	var buf bytes.Buffer
+	_ = message.GroupMessage{SubscribeID: 1, GroupSequence: 1 << 20}.Encode(&buf)
+	data := buf.Bytes()
+
+	r := bytes.NewReader(data)
+	br := bufio.NewReader(r)
+	var msg message.GroupMessage
+	b.ReportAllocs()
+	for b.Loop() {
+		r.Reset(data)
+		br.Reset(r)
+		if err := msg.Decode(br); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSubscribeMessage_EncodeDecode(b *testing.B) {
+	msg := message.SubscribeMessage{
+		SubscribeID:   1,
+		BroadcastPath: "/live/room",
+		TrackName:     "video",
+		TrackPriority: 5,
+	}
+
+	var buf bytes.Buffer
+	br := bufio.NewReader(&buf)
+	var decoded message.SubscribeMessage
+	b.ReportAllocs()
+	for b.Loop() {
+		buf.Reset()
+		br.Reset(&buf)
+		_ = msg.Encode(&buf)
+		if err := decoded.Decode(br); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
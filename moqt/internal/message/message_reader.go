@@ -29,34 +29,79 @@ func ReadVarint(b []byte) (uint64, int, error) {
 }
 
 // ReadVarintFromReader reads a QUIC varint from an io.Reader
+// It reads byte by byte without allocating if r is an io.ByteReader,
+// such as a bufio.Reader.
 func ReadVarintFromReader(r io.Reader) (uint64, error) {
+	if br, ok := r.(io.ByteReader); ok {
+		return readVarintFromByteReader(br)
+	}
+
 	// Read first byte to determine length
-	firstByte := make([]byte, 1)
-	_, err := io.ReadFull(r, firstByte)
+	var buf [8]byte
+	_, err := io.ReadFull(r, buf[:1])
 	if err != nil {
 		return 0, err
 	}
 
 	// Determine the length from the first two bits
-	l := 1 << ((firstByte[0] & 0xc0) >> 6)
+	l := 1 << ((buf[0] & 0xc0) >> 6)
 
 	// Read remaining bytes if needed
-	buf := make([]byte, l)
-	buf[0] = firstByte[0]
 	if l > 1 {
-		_, err = io.ReadFull(r, buf[1:])
+		_, err = io.ReadFull(r, buf[1:l])
 		if err != nil {
 			return 0, err
 		}
 	}
 
 	// Parse the varint
-	val, _, err := ReadVarint(buf)
+	val, _, err := ReadVarint(buf[:l])
 	return val, err
 }
 
+func readVarintFromByteReader(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	l := 1 << ((first & 0xc0) >> 6)
+	val := uint64(first & 0x3f)
+	for i := 1; i < l; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		val = val<<8 | uint64(b)
+	}
+
+	return val, nil
+}
+
+// SizeLimiter is implemented by readers limiting the size of the messages
+// decoded from them.
+type SizeLimiter interface {
+	// MaxMessageSize returns the maximum length of a message, excluding its length prefix.
+	MaxMessageSize() uint64
+}
+
+// ReadMessageLength reads the length prefix of a message. It returns
+// ErrMessageTooLarge if r is a SizeLimiter and the length exceeds its limit,
+// so that the message is not allocated.
 func ReadMessageLength(r io.Reader) (uint64, error) {
-	return ReadVarintFromReader(r)
+	size, err := ReadVarintFromReader(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if l, ok := r.(SizeLimiter); ok && size > l.MaxMessageSize() {
+		return 0, ErrMessageTooLarge
+	}
+
+	return size, nil
 }
 
 func ReadBytes(b []byte) ([]byte, int, error) {
@@ -96,6 +141,12 @@ func ReadStringArray(b []byte) ([]string, int, error) {
 
 	b = b[total:]
 
+	// Every string takes at least one byte, so a count larger than
+	// the bytes left cannot be satisfied and must not be allocated
+	if count > uint64(len(b)) {
+		return nil, 0, io.EOF
+	}
+
 	arr := make([]string, 0, count)
 	for range count {
 		str, n, err := ReadString(b)
@@ -123,6 +174,11 @@ func ReadParameters(b []byte) (map[uint64][]byte, int, error) {
 
 	b = b[total:]
 
+	// Every parameter takes at least two bytes
+	if count > uint64(len(b))/2 {
+		return nil, 0, io.EOF
+	}
+
 	params := make(map[uint64][]byte, count)
 	for range count {
 		key, n, err := ReadVarint(b)
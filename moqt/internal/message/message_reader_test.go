@@ -151,6 +151,26 @@ func TestReadMessageLength(t *testing.T) {
 	}
 }
 
+type limitedReader struct {
+	*bytes.Reader
+	max uint64
+}
+
+func (r limitedReader) MaxMessageSize() uint64 {
+	return r.max
+}
+
+func TestReadMessageLength_SizeLimiter(t *testing.T) {
+	r := limitedReader{Reader: bytes.NewReader([]byte{0x40, 0x80}), max: 128}
+	size, err := ReadMessageLength(r)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(128), size)
+
+	r = limitedReader{Reader: bytes.NewReader([]byte{0x40, 0x81}), max: 128}
+	_, err = ReadMessageLength(r)
+	assert.ErrorIs(t, err, ErrMessageTooLarge)
+}
+
 func TestReadBytes(t *testing.T) {
 	tests := map[string]struct {
 		input    []byte
@@ -268,6 +288,10 @@ func TestReadStringArray(t *testing.T) {
 			input:   []byte{0x01, 0x05, 0x68, 0x65},
 			wantErr: true,
 		},
+		"count exceeds bytes": {
+			input:   []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00},
+			wantErr: true,
+		},
 		"invalid count": {
 			input:   []byte{},
 			wantErr: true,
@@ -323,6 +347,10 @@ func TestReadParameters(t *testing.T) {
 			input:   []byte{0x01, 0x01, 0x03, 0x61},
 			wantErr: true,
 		},
+		"count exceeds bytes": {
+			input:   []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01, 0x00},
+			wantErr: true,
+		},
 		"invalid count": {
 			input:   []byte{},
 			wantErr: true,
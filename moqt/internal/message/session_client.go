@@ -35,7 +35,9 @@ func (scm SessionClientMessage) Encode(w io.Writer) error {
 	msgLen := scm.Len()
 
 	// Allocate buffer for whole message
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, uint64(len(scm.SupportedVersions)))
@@ -73,6 +75,10 @@ func (scm *SessionClientMessage) Decode(src io.Reader) error {
 	}
 	b = b[n:]
 
+	if count > uint64(len(b)) {
+		return io.EOF
+	}
+
 	scm.SupportedVersions = make([]uint64, count)
 	for i := range count {
 		num, n, err := ReadVarint(b)
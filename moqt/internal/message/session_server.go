@@ -30,7 +30,9 @@ func (ssm SessionServerMessage) Encode(w io.Writer) error {
 	msgLen := ssm.Len()
 
 	// Allocate buffer for whole message
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, uint64(ssm.SelectedVersion))
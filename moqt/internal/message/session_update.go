@@ -17,7 +17,9 @@ func (sum SessionUpdateMessage) Len() int {
 
 func (sum SessionUpdateMessage) Encode(w io.Writer) error {
 	msgLen := sum.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, sum.Bitrate)
@@ -32,7 +34,9 @@ func (sum *SessionUpdateMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -36,6 +36,15 @@ func (stm StreamType) Encode(w io.Writer) error {
 
 func (stm *StreamType) Decode(r io.Reader) error {
 	// Read the Stream Type
+	if br, ok := r.(io.ByteReader); ok {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		*stm = StreamType(b)
+		return nil
+	}
+
 	buf := make([]byte, 1)
 	_, err := r.Read(buf)
 	if err != nil {
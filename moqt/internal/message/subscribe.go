@@ -10,6 +10,8 @@ import (
 *   Broadcast Path (string),
 *   Track Name (string),
 *   Track Priority (varint),
+*   [Start Group (varint),]
+*   [Delivery Timeout (varint),]
 * }
  */
 type SubscribeMessage struct {
@@ -17,6 +19,15 @@ type SubscribeMessage struct {
 	BroadcastPath string
 	TrackName     string
 	TrackPriority uint8
+
+	// StartGroup is the lowest group sequence requested.
+	// It is omitted from the message when zero.
+	StartGroup uint64
+
+	// DeliveryTimeout is in milliseconds. It is omitted from the message
+	// when zero, for peers that do not know the field; the start group is
+	// then written even when zero so that the field follows it.
+	DeliveryTimeout uint64
 }
 
 func (s SubscribeMessage) Len() int {
@@ -26,13 +37,21 @@ func (s SubscribeMessage) Len() int {
 	l += StringLen(s.BroadcastPath)
 	l += StringLen(s.TrackName)
 	l += VarintLen(uint64(s.TrackPriority))
+	if s.StartGroup != 0 || s.DeliveryTimeout != 0 {
+		l += VarintLen(s.StartGroup)
+	}
+	if s.DeliveryTimeout != 0 {
+		l += VarintLen(s.DeliveryTimeout)
+	}
 
 	return l
 }
 
 func (s SubscribeMessage) Encode(w io.Writer) error {
 	msgLen := s.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 	b, _ = WriteVarint(b, uint64(s.SubscribeID))
@@ -41,6 +60,12 @@ func (s SubscribeMessage) Encode(w io.Writer) error {
 	b, _ = WriteVarint(b, uint64(len(s.TrackName)))
 	b = append(b, s.TrackName...)
 	b, _ = WriteVarint(b, uint64(s.TrackPriority))
+	if s.StartGroup != 0 || s.DeliveryTimeout != 0 {
+		b, _ = WriteVarint(b, s.StartGroup)
+	}
+	if s.DeliveryTimeout != 0 {
+		b, _ = WriteVarint(b, s.DeliveryTimeout)
+	}
 
 	_, err := w.Write(b)
 	return err
@@ -52,7 +77,9 @@ func (s *SubscribeMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -87,6 +114,26 @@ func (s *SubscribeMessage) Decode(src io.Reader) error {
 	s.TrackPriority = uint8(num)
 	b = b[n:]
 
+	s.StartGroup = 0
+	if len(b) > 0 {
+		num, n, err = ReadVarint(b)
+		if err != nil {
+			return err
+		}
+		s.StartGroup = num
+		b = b[n:]
+	}
+
+	s.DeliveryTimeout = 0
+	if len(b) > 0 {
+		num, n, err = ReadVarint(b)
+		if err != nil {
+			return err
+		}
+		s.DeliveryTimeout = num
+		b = b[n:]
+	}
+
 	if len(b) != 0 {
 		return ErrMessageTooShort
 	}
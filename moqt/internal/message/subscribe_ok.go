@@ -18,7 +18,9 @@ func (som SubscribeOkMessage) Len() int {
 
 func (som SubscribeOkMessage) Encode(w io.Writer) error {
 	msgLen := som.Len()
-	b := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	b := *bp
 
 	b, _ = WriteMessageLength(b, uint64(msgLen))
 
@@ -33,7 +35,9 @@ func (som *SubscribeOkMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, num)
+	bp := getBuffer(int(num))
+	defer putBuffer(bp)
+	b := (*bp)[:num]
 	_, err = io.ReadFull(src, b)
 	if err != nil {
 		return err
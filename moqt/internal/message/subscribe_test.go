@@ -35,6 +35,31 @@ func TestSubscribeMessage_EncodeDecode(t *testing.T) {
 				TrackPriority: 1,
 			},
 		},
+		"delivery timeout": {
+			input: message.SubscribeMessage{
+				SubscribeID:     1,
+				BroadcastPath:   "path",
+				TrackPriority:   1,
+				DeliveryTimeout: 500,
+			},
+		},
+		"start group": {
+			input: message.SubscribeMessage{
+				SubscribeID:   1,
+				BroadcastPath: "path",
+				TrackPriority: 1,
+				StartGroup:    42,
+			},
+		},
+		"delivery timeout and start group": {
+			input: message.SubscribeMessage{
+				SubscribeID:     1,
+				BroadcastPath:   "path",
+				TrackPriority:   1,
+				DeliveryTimeout: 500,
+				StartGroup:      42,
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -7,26 +7,38 @@ import (
 /*
  * SUBSCRIBE_UPDATE Message {
  *   Track Priority (varint),
+ *   [Delivery Timeout (varint),]
  * }
  */
 type SubscribeUpdateMessage struct {
 	TrackPriority uint8
+
+	// DeliveryTimeout is in milliseconds, omitted when zero as in SUBSCRIBE.
+	DeliveryTimeout uint64
 }
 
 func (su SubscribeUpdateMessage) Len() int {
 	var l int
 
 	l += VarintLen(uint64(su.TrackPriority))
+	if su.DeliveryTimeout != 0 {
+		l += VarintLen(su.DeliveryTimeout)
+	}
 
 	return l
 }
 
 func (su SubscribeUpdateMessage) Encode(w io.Writer) error {
 	msgLen := su.Len()
-	p := make([]byte, 0, msgLen+VarintLen(uint64(msgLen)))
+	bp := getBuffer(msgLen + VarintLen(uint64(msgLen)))
+	defer putBuffer(bp)
+	p := *bp
 
 	p, _ = WriteMessageLength(p, uint64(msgLen))
 	p, _ = WriteVarint(p, uint64(su.TrackPriority))
+	if su.DeliveryTimeout != 0 {
+		p, _ = WriteVarint(p, su.DeliveryTimeout)
+	}
 
 	_, err := w.Write(p)
 
@@ -39,7 +51,9 @@ func (sum *SubscribeUpdateMessage) Decode(src io.Reader) error {
 		return err
 	}
 
-	b := make([]byte, size)
+	bp := getBuffer(int(size))
+	defer putBuffer(bp)
+	b := (*bp)[:size]
 
 	_, err = io.ReadFull(src, b)
 	if err != nil {
@@ -53,6 +67,16 @@ func (sum *SubscribeUpdateMessage) Decode(src io.Reader) error {
 	sum.TrackPriority = uint8(num)
 	b = b[n:]
 
+	sum.DeliveryTimeout = 0
+	if len(b) > 0 {
+		num, n, err = ReadVarint(b)
+		if err != nil {
+			return err
+		}
+		sum.DeliveryTimeout = num
+		b = b[n:]
+	}
+
 	if len(b) != 0 {
 		return ErrMessageTooShort
 	}
@@ -29,6 +29,12 @@ func TestSubscribeUpdateMessage_EncodeDecode(t *testing.T) {
 				TrackPriority: 255,
 			},
 		},
+		"delivery timeout": {
+			input: message.SubscribeUpdateMessage{
+				TrackPriority:   5,
+				DeliveryTimeout: 300,
+			},
+		},
 	}
 
 	for name, tc := range tests {
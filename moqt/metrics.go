@@ -0,0 +1,62 @@
+package moqt
+
+import (
+	"context"
+	"errors"
+)
+
+// Role is the side of a session or a track a metrics event is reported for.
+type Role string
+
+const (
+	RoleClient     Role = "client"
+	RoleServer     Role = "server"
+	RolePublisher  Role = "publisher"
+	RoleSubscriber Role = "subscriber"
+)
+
+// Metrics receives instrumentation events from sessions, tracks and groups.
+// It is set with Config.Metrics on a Server or a Client.
+//
+// Methods are called inline and concurrently, so implementations must be
+// safe for concurrent use and return quickly.
+//
+// The err passed when something closes is nil for a graceful close.
+// Otherwise it is usually a *SessionError, *SubscribeError or *GroupError
+// carrying the error code.
+type Metrics interface {
+	// SessionOpened is called when a session is set up.
+	// role is RoleClient or RoleServer.
+	SessionOpened(role Role)
+
+	// SessionClosed is called when a session ends.
+	SessionClosed(role Role, err error)
+
+	// SubscriptionOpened is called when a subscription starts.
+	// role is RolePublisher or RoleSubscriber.
+	SubscriptionOpened(role Role, path BroadcastPath)
+
+	// SubscriptionClosed is called when a subscription ends.
+	SubscriptionClosed(role Role, path BroadcastPath, err error)
+
+	// GroupOpened is called when a group is opened by a TrackWriter
+	// or accepted by a TrackReader.
+	GroupOpened(role Role, path BroadcastPath)
+
+	// GroupClosed is called when a group ends or is canceled.
+	GroupClosed(role Role, path BroadcastPath, err error)
+
+	// FrameTransferred is called for every frame written or read,
+	// with the size of its payload.
+	FrameTransferred(role Role, path BroadcastPath, size int)
+}
+
+// closeError returns the error ctx was canceled with,
+// or nil if it was canceled for a graceful close.
+func closeError(ctx context.Context) error {
+	err := Cause(ctx)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
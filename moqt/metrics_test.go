@@ -0,0 +1,120 @@
+package moqt
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics records the events as strings.
+type recordingMetrics struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (m *recordingMetrics) record(format string, args ...any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, fmt.Sprintf(format, args...))
+}
+
+func (m *recordingMetrics) has(event string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *recordingMetrics) SessionOpened(role Role) { m.record("session opened %s", role) }
+func (m *recordingMetrics) SessionClosed(role Role, err error) {
+	m.record("session closed %s %v", role, err != nil)
+}
+func (m *recordingMetrics) SubscriptionOpened(role Role, path BroadcastPath) {
+	m.record("subscription opened %s %s", role, path)
+}
+func (m *recordingMetrics) SubscriptionClosed(role Role, path BroadcastPath, err error) {
+	m.record("subscription closed %s %s", role, path)
+}
+func (m *recordingMetrics) GroupOpened(role Role, path BroadcastPath) {
+	m.record("group opened %s %s", role, path)
+}
+func (m *recordingMetrics) GroupClosed(role Role, path BroadcastPath, err error) {
+	m.record("group closed %s %s %v", role, path, err)
+}
+func (m *recordingMetrics) FrameTransferred(role Role, path BroadcastPath, size int) {
+	m.record("frame %s %s %d", role, path, size)
+}
+
+func TestMetrics(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		frame := NewFrame(5)
+		_, _ = frame.Write([]byte("hello"))
+		_ = gw.WriteFrame(frame)
+		_ = gw.Close()
+	})
+
+	serverMetrics := &recordingMetrics{}
+	clientMetrics := &recordingMetrics{}
+
+	d := newMemoryDialer(t, mux)
+	d.server.Config = &Config{Metrics: serverMetrics}
+	client := &Client{DialQUICFunc: d.dial, Config: &Config{Metrics: clientMetrics}}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+
+	tr, err := sess.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+
+	gr, err := tr.AcceptGroup(ctx)
+	require.NoError(t, err)
+	frame := NewFrame(0)
+	require.NoError(t, gr.ReadFrame(frame))
+	require.ErrorIs(t, gr.ReadFrame(frame), io.EOF)
+
+	require.NoError(t, tr.Close())
+	require.NoError(t, sess.CloseWithError(NoError, ""))
+
+	for m, events := range map[*recordingMetrics][]string{
+		clientMetrics: {
+			"session opened client",
+			"subscription opened subscriber /live",
+			"group opened subscriber /live",
+			"frame subscriber /live 5",
+			"group closed subscriber /live <nil>",
+			"subscription closed subscriber /live",
+			"session closed client true",
+		},
+		serverMetrics: {
+			"session opened server",
+			"subscription opened publisher /live",
+			"group opened publisher /live",
+			"frame publisher /live 5",
+			"group closed publisher /live <nil>",
+			"subscription closed publisher /live",
+			"session closed server true",
+		},
+	} {
+		for _, event := range events {
+			assert.Eventually(t, func() bool { return m.has(event) }, time.Second, 10*time.Millisecond, event)
+		}
+	}
+}
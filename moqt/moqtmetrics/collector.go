@@ -0,0 +1,267 @@
+package moqtmetrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// DefaultPrefixDepth is the number of broadcast path segments kept in
+// the prefix label if Collector.PrefixDepth is not set.
+const DefaultPrefixDepth = 1
+
+// codeNone is the code label of things that ended without an error code.
+const codeNone = "none"
+
+var _ moqt.Metrics = (*Collector)(nil)
+var _ http.Handler = (*Collector)(nil)
+
+// Collector is a moqt.Metrics aggregating the events into counters and
+// gauges. It serves them in the Prometheus text exposition format.
+//
+// Broadcast paths are reduced to the prefix label with PrefixDepth
+// segments, to keep the number of series bounded.
+type Collector struct {
+	// PrefixDepth is the number of leading path segments in the prefix label.
+	// With a depth of 1, "/room/alice/video" is reported as "/room/".
+	// Defaults to DefaultPrefixDepth; a negative depth keeps the whole path.
+	PrefixDepth int
+
+	initOnce sync.Once
+
+	sessionsOpened      *vec
+	sessionsActive      *vec
+	sessionsClosed      *vec
+	subscriptionsOpened *vec
+	subscriptionsActive *vec
+	subscriptionsClosed *vec
+	groupsOpened        *vec
+	groupsClosed        *vec
+	frames              *vec
+	bytes               *vec
+
+	all []*vec
+}
+
+func (c *Collector) init() {
+	c.initOnce.Do(func() {
+		c.sessionsOpened = newVec("moq_sessions_opened_total", "Sessions set up.", counter, "role")
+		c.sessionsActive = newVec("moq_sessions_active", "Sessions currently open.", gauge, "role")
+		c.sessionsClosed = newVec("moq_sessions_closed_total", "Sessions closed, by error code.", counter, "role", "code")
+		c.subscriptionsOpened = newVec("moq_subscriptions_opened_total", "Subscriptions started.", counter, "role", "prefix")
+		c.subscriptionsActive = newVec("moq_subscriptions_active", "Subscriptions currently active.", gauge, "role", "prefix")
+		c.subscriptionsClosed = newVec("moq_subscriptions_closed_total", "Subscriptions ended, by error code.", counter, "role", "prefix", "code")
+		c.groupsOpened = newVec("moq_groups_opened_total", "Groups opened or accepted.", counter, "role", "prefix")
+		c.groupsClosed = newVec("moq_groups_closed_total", "Groups ended, by error code.", counter, "role", "prefix", "code")
+		c.frames = newVec("moq_frames_total", "Frames written or read.", counter, "role", "prefix")
+		c.bytes = newVec("moq_frame_bytes_total", "Frame payload bytes written or read.", counter, "role", "prefix")
+
+		c.all = []*vec{
+			c.sessionsOpened, c.sessionsActive, c.sessionsClosed,
+			c.subscriptionsOpened, c.subscriptionsActive, c.subscriptionsClosed,
+			c.groupsOpened, c.groupsClosed,
+			c.frames, c.bytes,
+		}
+	})
+}
+
+// prefix returns the prefix label of path.
+func (c *Collector) prefix(path moqt.BroadcastPath) string {
+	depth := c.PrefixDepth
+	if depth == 0 {
+		depth = DefaultPrefixDepth
+	}
+	if depth < 0 {
+		return string(path)
+	}
+
+	s := string(path)
+	if s == "" {
+		return s
+	}
+	i := 0
+	for range depth {
+		j := strings.IndexByte(s[i+1:], '/')
+		if j < 0 {
+			return s
+		}
+		i += j + 1
+	}
+	return s[:i+1]
+}
+
+func (c *Collector) SessionOpened(role moqt.Role) {
+	c.init()
+	c.sessionsOpened.add(1, string(role))
+	c.sessionsActive.add(1, string(role))
+}
+
+func (c *Collector) SessionClosed(role moqt.Role, err error) {
+	c.init()
+	c.sessionsActive.add(-1, string(role))
+	c.sessionsClosed.add(1, string(role), ErrorCode(err))
+}
+
+func (c *Collector) SubscriptionOpened(role moqt.Role, path moqt.BroadcastPath) {
+	c.init()
+	prefix := c.prefix(path)
+	c.subscriptionsOpened.add(1, string(role), prefix)
+	c.subscriptionsActive.add(1, string(role), prefix)
+}
+
+func (c *Collector) SubscriptionClosed(role moqt.Role, path moqt.BroadcastPath, err error) {
+	c.init()
+	prefix := c.prefix(path)
+	c.subscriptionsActive.add(-1, string(role), prefix)
+	c.subscriptionsClosed.add(1, string(role), prefix, ErrorCode(err))
+}
+
+func (c *Collector) GroupOpened(role moqt.Role, path moqt.BroadcastPath) {
+	c.init()
+	c.groupsOpened.add(1, string(role), c.prefix(path))
+}
+
+func (c *Collector) GroupClosed(role moqt.Role, path moqt.BroadcastPath, err error) {
+	c.init()
+	c.groupsClosed.add(1, string(role), c.prefix(path), ErrorCode(err))
+}
+
+func (c *Collector) FrameTransferred(role moqt.Role, path moqt.BroadcastPath, size int) {
+	c.init()
+	prefix := c.prefix(path)
+	c.frames.add(1, string(role), prefix)
+	c.bytes.add(int64(size), string(role), prefix)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.init()
+
+	var b strings.Builder
+	for _, v := range c.all {
+		v.write(&b)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ErrorCode returns the error code label of err: the MOQ, QUIC stream or
+// QUIC application error code in hexadecimal, or "none" for nil and errors
+// without a code.
+func ErrorCode(err error) string {
+	if err == nil {
+		return codeNone
+	}
+
+	var sessErr *moqt.SessionError
+	var subErr *moqt.SubscribeError
+	var grpErr *moqt.GroupError
+	var annErr *moqt.AnnounceError
+	var strErr *quic.StreamError
+	var appErr *quic.ApplicationError
+	switch {
+	case errors.As(err, &sessErr) && sessErr.ApplicationError != nil:
+		return hex(uint64(sessErr.SessionErrorCode()))
+	case errors.As(err, &subErr) && subErr.StreamError != nil:
+		return hex(uint64(subErr.SubscribeErrorCode()))
+	case errors.As(err, &grpErr) && grpErr.StreamError != nil:
+		return hex(uint64(grpErr.GroupErrorCode()))
+	case errors.As(err, &annErr) && annErr.StreamError != nil:
+		return hex(uint64(annErr.ErrorCode))
+	case errors.As(err, &strErr):
+		return hex(uint64(strErr.ErrorCode))
+	case errors.As(err, &appErr):
+		return hex(uint64(appErr.ErrorCode))
+	default:
+		return codeNone
+	}
+}
+
+func hex(code uint64) string {
+	return fmt.Sprintf("0x%x", code)
+}
+
+type metricType string
+
+const (
+	counter metricType = "counter"
+	gauge   metricType = "gauge"
+)
+
+// vec is a metric family with one series per combination of label values.
+type vec struct {
+	name   string
+	help   string
+	typ    metricType
+	labels []string
+
+	series sync.Map // joined label values -> *series
+}
+
+type series struct {
+	values []string
+	value  atomic.Int64
+}
+
+func newVec(name, help string, typ metricType, labels ...string) *vec {
+	return &vec{name: name, help: help, typ: typ, labels: labels}
+}
+
+func (v *vec) add(delta int64, values ...string) {
+	key := strings.Join(values, "\xff")
+	s, ok := v.series.Load(key)
+	if !ok {
+		s, _ = v.series.LoadOrStore(key, &series{values: values})
+	}
+	s.(*series).value.Add(delta)
+}
+
+func (v *vec) write(b *strings.Builder) {
+	var keys []string
+	v.series.Range(func(key, _ any) bool {
+		keys = append(keys, key.(string))
+		return true
+	})
+	if len(keys) == 0 {
+		return
+	}
+	slices.Sort(keys)
+
+	fmt.Fprintf(b, "# HELP %s %s\n", v.name, v.help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", v.name, v.typ)
+
+	for _, key := range keys {
+		s, _ := v.series.Load(key)
+		b.WriteString(v.name)
+		b.WriteByte('{')
+		for i, label := range v.labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escape(s.(*series).values[i]))
+		}
+		fmt.Fprintf(b, "} %d\n", s.(*series).value.Load())
+	}
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape escapes a label value.
+func escape(s string) string {
+	return escaper.Replace(s)
+}
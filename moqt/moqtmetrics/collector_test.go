@@ -0,0 +1,110 @@
+package moqtmetrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector_Prefix(t *testing.T) {
+	tests := map[string]struct {
+		depth int
+		path  moqt.BroadcastPath
+		want  string
+	}{
+		"default depth":    {depth: 0, path: "/room/alice/video", want: "/room/"},
+		"depth 2":          {depth: 2, path: "/room/alice/video", want: "/room/alice/"},
+		"shallower path":   {depth: 2, path: "/live", want: "/live"},
+		"whole path":       {depth: -1, path: "/room/alice/video", want: "/room/alice/video"},
+		"root":             {depth: 1, path: "/", want: "/"},
+		"empty":            {depth: 1, path: "", want: ""},
+		"trailing segment": {depth: 1, path: "/room/", want: "/room/"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &Collector{PrefixDepth: tt.depth}
+			assert.Equal(t, tt.want, c.prefix(tt.path))
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want string
+	}{
+		"nil":     {err: nil, want: "none"},
+		"no code": {err: errors.New("boom"), want: "none"},
+		"session": {
+			err:  &moqt.SessionError{ApplicationError: &quic.ApplicationError{ErrorCode: quic.ApplicationErrorCode(moqt.ProtocolViolationErrorCode)}},
+			want: "0x3",
+		},
+		"subscribe": {
+			err:  &moqt.SubscribeError{StreamError: &quic.StreamError{ErrorCode: quic.StreamErrorCode(moqt.TrackNotFoundErrorCode)}},
+			want: "0x3",
+		},
+		"group": {
+			err:  &moqt.GroupError{StreamError: &quic.StreamError{ErrorCode: quic.StreamErrorCode(moqt.PublishAbortedErrorCode)}},
+			want: "0x5",
+		},
+		"stream": {
+			err:  &quic.StreamError{ErrorCode: 0x10},
+			want: "0x10",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ErrorCode(tt.err))
+		})
+	}
+}
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	c := &Collector{}
+
+	c.SessionOpened(moqt.RoleServer)
+	c.SessionOpened(moqt.RoleServer)
+	c.SessionClosed(moqt.RoleServer, nil)
+	c.SubscriptionOpened(moqt.RolePublisher, "/room/alice")
+	c.SubscriptionClosed(moqt.RolePublisher, "/room/alice",
+		&moqt.SubscribeError{StreamError: &quic.StreamError{ErrorCode: quic.StreamErrorCode(moqt.TrackNotFoundErrorCode)}})
+	c.GroupOpened(moqt.RolePublisher, "/room/alice")
+	c.GroupClosed(moqt.RolePublisher, "/room/alice", nil)
+	c.FrameTransferred(moqt.RolePublisher, "/room/alice", 100)
+	c.FrameTransferred(moqt.RolePublisher, "/room/bob", 50)
+	c.FrameTransferred(moqt.RolePublisher, `/quote"d`, 1)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE moq_sessions_opened_total counter",
+		`moq_sessions_opened_total{role="server"} 2`,
+		"# TYPE moq_sessions_active gauge",
+		`moq_sessions_active{role="server"} 1`,
+		`moq_sessions_closed_total{role="server",code="none"} 1`,
+		`moq_subscriptions_active{role="publisher",prefix="/room/"} 0`,
+		`moq_subscriptions_closed_total{role="publisher",prefix="/room/",code="0x3"} 1`,
+		`moq_groups_closed_total{role="publisher",prefix="/room/",code="none"} 1`,
+		`moq_frames_total{role="publisher",prefix="/room/"} 2`,
+		`moq_frame_bytes_total{role="publisher",prefix="/room/"} 150`,
+		`moq_frames_total{role="publisher",prefix="/quote\"d"} 1`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+
+	// Families without series are omitted
+	assert.NotContains(t, body, "moq_groups_opened_total{role=\"subscriber\"")
+}
@@ -0,0 +1,17 @@
+// Package moqtmetrics collects moqt metrics and exposes them
+// in the Prometheus text exposition format.
+//
+// A Collector implements moqt.Metrics and http.Handler. Subscription and
+// group metrics are labeled by the prefix of the broadcast path, cut to
+// PrefixDepth segments to keep the number of series bounded.
+/*
+	c := &moqtmetrics.Collector{}
+
+	server := &moqt.Server{
+	    Addr:   ":4433",
+	    Config: &moqt.Config{Metrics: c},
+	}
+
+	http.Handle("/metrics", c)
+*/
+package moqtmetrics
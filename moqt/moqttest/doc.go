@@ -0,0 +1,16 @@
+// Package moqttest provides utilities for testing MOQ applications.
+//
+// Connect sets up a client and a server Session connected in memory, without
+// sockets or TLS, so that TrackHandlers can be tested end to end.
+/*
+	func TestClock(t *testing.T) {
+	    mux := moqt.NewTrackMux()
+	    mux.Publish(t.Context(), "/clock", clockHandler)
+
+	    p := moqttest.Connect(t, nil, mux)
+
+	    tr, err := p.Client.Subscribe("/clock", "second", nil)
+	    ...
+	}
+*/
+package moqttest
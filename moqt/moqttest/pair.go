@@ -0,0 +1,100 @@
+package moqttest
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicmem"
+)
+
+// Pair is a connected client and server Session pair.
+type Pair struct {
+	// Client is the session of the client. Tracks published on its
+	// TrackMux can be subscribed to from Server.
+	Client *moqt.Session
+
+	// Server is the session accepted by the server. Tracks published on its
+	// TrackMux can be subscribed to from Client.
+	Server *moqt.Session
+
+	server *moqt.Server
+}
+
+// NewPair connects a client and a server over an in-memory connection.
+// The client serves clientMux and the server serves serverMux.
+// A nil TrackMux serves no tracks.
+func NewPair(ctx context.Context, clientMux, serverMux *moqt.TrackMux) (*Pair, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if clientMux == nil {
+		clientMux = moqt.NewTrackMux()
+	}
+	if serverMux == nil {
+		serverMux = moqt.NewTrackMux()
+	}
+
+	clientConn, serverConn := quicmem.Pipe(moqt.NextProtoMOQ)
+
+	accepted := make(chan *moqt.Session, 1)
+	server := &moqt.Server{
+		SetupHandler: moqt.SetupHandlerFunc(func(w moqt.SetupResponseWriter, r *moqt.SetupRequest) {
+			sess, err := moqt.Accept(w, r, serverMux)
+			if err != nil {
+				return
+			}
+			accepted <- sess
+		}),
+	}
+	go func() { _ = server.ServeQUICConn(serverConn) }()
+
+	client := &moqt.Client{
+		DialQUICFunc: func(context.Context, string, *tls.Config, *quic.Config) (quic.Connection, error) {
+			return clientConn, nil
+		},
+	}
+
+	clientSess, err := client.DialQUIC(ctx, clientConn.RemoteAddr().String(), "/", clientMux)
+	if err != nil {
+		_ = server.Close()
+		return nil, err
+	}
+
+	select {
+	case serverSess := <-accepted:
+		return &Pair{
+			Client: clientSess,
+			Server: serverSess,
+			server: server,
+		}, nil
+	case <-ctx.Done():
+		_ = clientSess.CloseWithError(moqt.InternalSessionErrorCode, moqt.SessionErrorText(moqt.InternalSessionErrorCode))
+		_ = server.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Connect is like NewPair but fails tb if the sessions cannot be set up.
+// The sessions are closed when tb and all its subtests complete.
+func Connect(tb testing.TB, clientMux, serverMux *moqt.TrackMux) *Pair {
+	tb.Helper()
+
+	p, err := NewPair(tb.Context(), clientMux, serverMux)
+	if err != nil {
+		tb.Fatalf("moqttest: failed to connect sessions: %v", err)
+	}
+	tb.Cleanup(func() { _ = p.Close() })
+
+	return p
+}
+
+// Close closes both sessions.
+func (p *Pair) Close() error {
+	err := p.Client.CloseWithError(moqt.NoError, moqt.SessionErrorText(moqt.NoError))
+	return errors.Join(err, p.server.Close())
+}
@@ -0,0 +1,81 @@
+package moqttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishHello(ctx context.Context, mux *moqt.TrackMux, path moqt.BroadcastPath) {
+	mux.PublishFunc(ctx, path, func(tw *moqt.TrackWriter) {
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		frame := moqt.NewFrame(5)
+		_, _ = frame.Write([]byte("hello"))
+		_ = gw.WriteFrame(frame)
+		_ = gw.Close()
+	})
+}
+
+func readHello(t *testing.T, sess *moqt.Session, path moqt.BroadcastPath) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+
+	tr, err := sess.Subscribe(path, "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	gr, err := tr.AcceptGroup(ctx)
+	require.NoError(t, err)
+
+	frame := moqt.NewFrame(0)
+	require.NoError(t, gr.ReadFrame(frame))
+	assert.Equal(t, []byte("hello"), frame.Body())
+}
+
+func TestConnect(t *testing.T) {
+	clientMux := moqt.NewTrackMux()
+	serverMux := moqt.NewTrackMux()
+	publishHello(t.Context(), clientMux, "/client")
+	publishHello(t.Context(), serverMux, "/server")
+
+	p := Connect(t, clientMux, serverMux)
+	require.NotNil(t, p.Client)
+	require.NotNil(t, p.Server)
+
+	t.Run("client subscribes", func(t *testing.T) {
+		readHello(t, p.Client, "/server")
+	})
+	t.Run("server subscribes", func(t *testing.T) {
+		readHello(t, p.Server, "/client")
+	})
+}
+
+func TestPair_Close(t *testing.T) {
+	p, err := NewPair(t.Context(), nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Close())
+
+	select {
+	case <-p.Server.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("server session was not closed")
+	}
+}
+
+func TestNewPair_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	_, err := NewPair(ctx, nil, nil)
+	assert.Error(t, err)
+}
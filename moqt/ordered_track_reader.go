@@ -0,0 +1,173 @@
+package moqt
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"time"
+)
+
+// NewOrderedTrackReader returns an OrderedTrackReader delivering the groups
+// of track in sequence order.
+//
+// Up to window groups are held while the next sequence is missing.
+// The missing groups are reported as a gap when the window overflows,
+// when no group fills it within gapTimeout, or when the track ends.
+// A window of zero or less reports gaps as soon as they are seen, and a
+// gapTimeout of zero or less waits for the window to overflow.
+func NewOrderedTrackReader(track *TrackReader, window int, gapTimeout time.Duration) *OrderedTrackReader {
+	return &OrderedTrackReader{
+		track:      track,
+		window:     window,
+		gapTimeout: gapTimeout,
+	}
+}
+
+// OrderedTrackReader accepts the groups of a TrackReader and delivers
+// them by GroupSequence rather than in arrival order.
+// The sequence of the first group accepted starts the order; groups that
+// arrive after their sequence was delivered or skipped are canceled with
+// DroppedGroupErrorCode.
+//
+// An OrderedTrackReader is not safe for concurrent use.
+type OrderedTrackReader struct {
+	track      *TrackReader
+	window     int
+	gapTimeout time.Duration
+
+	started bool
+	next    GroupSequence
+
+	// held is sorted by sequence
+	held []*GroupReader
+
+	// gapDeadline is when the gap before held[0] is reported
+	gapDeadline time.Time
+
+	// err ends the track once the held groups are delivered
+	err error
+}
+
+// GroupGap is a range of group sequences that were not delivered.
+type GroupGap struct {
+	// Start is the first missing sequence.
+	Start GroupSequence
+	// End is the last missing sequence.
+	End GroupSequence
+}
+
+// GroupEvent is either the next group in sequence order or a gap.
+type GroupEvent struct {
+	// Group is the next group, or nil for a gap.
+	Group *GroupReader
+	// Gap is the range of missing sequences when Group is nil.
+	Gap GroupGap
+}
+
+// Next blocks until the next group or gap is available, or ctx is canceled.
+// When the track ends, the held groups are delivered before its error.
+func (o *OrderedTrackReader) Next(ctx context.Context) (GroupEvent, error) {
+	for {
+		if len(o.held) > 0 {
+			lowest := o.held[0].GroupSequence()
+
+			if lowest == o.next {
+				group := o.held[0]
+				o.held = o.held[1:]
+				o.next = lowest.Next()
+				o.gapDeadline = time.Time{}
+
+				return GroupEvent{Group: group}, nil
+			}
+
+			if o.gapDeadline.IsZero() && o.gapTimeout > 0 {
+				o.gapDeadline = time.Now().Add(o.gapTimeout)
+			}
+
+			if len(o.held) > o.window || o.err != nil ||
+				(!o.gapDeadline.IsZero() && !time.Now().Before(o.gapDeadline)) {
+				gap := GroupGap{Start: o.next, End: lowest - 1}
+				o.next = lowest
+				o.gapDeadline = time.Time{}
+
+				return GroupEvent{Gap: gap}, nil
+			}
+		}
+
+		if o.err != nil {
+			return GroupEvent{}, o.err
+		}
+
+		group, err := o.accept(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return GroupEvent{}, ctx.Err()
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				o.err = err
+			}
+			continue
+		}
+
+		o.hold(group)
+	}
+}
+
+// accept accepts a group from the track until the gap deadline, if any.
+func (o *OrderedTrackReader) accept(ctx context.Context) (*GroupReader, error) {
+	if o.gapDeadline.IsZero() {
+		return o.track.AcceptGroup(ctx)
+	}
+
+	ctx, cancel := context.WithDeadline(ctx, o.gapDeadline)
+	defer cancel()
+
+	return o.track.AcceptGroup(ctx)
+}
+
+func (o *OrderedTrackReader) hold(group *GroupReader) {
+	seq := group.GroupSequence()
+
+	if !o.started {
+		o.started = true
+		o.next = seq
+	}
+
+	if seq < o.next {
+		group.CancelRead(DroppedGroupErrorCode)
+		return
+	}
+
+	i, found := slices.BinarySearchFunc(o.held, seq, func(g *GroupReader, seq GroupSequence) int {
+		return cmp.Compare(g.GroupSequence(), seq)
+	})
+	if found {
+		group.CancelRead(DroppedGroupErrorCode)
+		return
+	}
+
+	o.held = slices.Insert(o.held, i, group)
+}
+
+// Groups returns a sequence that yields the groups in sequence order,
+// skipping gaps, until ctx is canceled or the track ends.
+func (o *OrderedTrackReader) Groups(ctx context.Context) iter.Seq[*GroupReader] {
+	return func(yield func(*GroupReader) bool) {
+		for {
+			event, err := o.Next(ctx)
+			if err != nil {
+				return
+			}
+
+			if event.Group == nil {
+				continue
+			}
+
+			if !yield(event.Group) {
+				return
+			}
+		}
+	}
+}
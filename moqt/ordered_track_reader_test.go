@@ -0,0 +1,137 @@
+package moqt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrderedTestTrack(t *testing.T) *TrackReader {
+	t.Helper()
+
+	mockStream := &MockQUICStream{}
+	mockStream.On("Context").Return(context.Background())
+	mockStream.On("Close").Return(nil)
+	mockStream.On("CancelRead", mock.Anything).Return(nil)
+	substr := newSendSubscribeStream(SubscribeID(1), mockStream, &TrackConfig{}, Info{})
+	return newTrackReader("broadcastPath", "trackName", substr, func() {})
+}
+
+func enqueueOrderedTestGroups(track *TrackReader, sequences ...GroupSequence) {
+	for _, seq := range sequences {
+		stream := &MockQUICReceiveStream{}
+		stream.On("StreamID").Return(quic.StreamID(seq))
+		stream.On("CancelRead", mock.Anything).Return()
+		track.enqueueGroup(seq, stream)
+	}
+}
+
+// describe renders an event as the group sequence or the gap range.
+func describe(event GroupEvent) any {
+	if event.Group != nil {
+		return event.Group.GroupSequence()
+	}
+	return event.Gap
+}
+
+func TestOrderedTrackReader_Next(t *testing.T) {
+	tests := map[string]struct {
+		window    int
+		sequences []GroupSequence
+		want      []any
+	}{
+		"in order": {
+			window:    4,
+			sequences: []GroupSequence{1, 2, 3},
+			want:      []any{GroupSequence(1), GroupSequence(2), GroupSequence(3)},
+		},
+		"reordered": {
+			window:    4,
+			sequences: []GroupSequence{1, 3, 4, 2},
+			want:      []any{GroupSequence(1), GroupSequence(2), GroupSequence(3), GroupSequence(4)},
+		},
+		"window overflow": {
+			window:    1,
+			sequences: []GroupSequence{1, 4, 5},
+			want:      []any{GroupSequence(1), GroupGap{Start: 2, End: 3}, GroupSequence(4), GroupSequence(5)},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			track := newOrderedTestTrack(t)
+			enqueueOrderedTestGroups(track, tt.sequences...)
+			ordered := NewOrderedTrackReader(track, tt.window, 0)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			var got []any
+			for range tt.want {
+				event, err := ordered.Next(ctx)
+				require.NoError(t, err)
+				got = append(got, describe(event))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrderedTrackReader_GapTimeout(t *testing.T) {
+	track := newOrderedTestTrack(t)
+	enqueueOrderedTestGroups(track, 1, 3)
+	ordered := NewOrderedTrackReader(track, 8, 20*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	event, err := ordered.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(1), describe(event))
+
+	start := time.Now()
+	event, err = ordered.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupGap{Start: 2, End: 2}, describe(event))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	event, err = ordered.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(3), describe(event))
+
+	// The skipped group is canceled when it arrives late
+	late := &MockQUICReceiveStream{}
+	late.On("StreamID").Return(quic.StreamID(2))
+	late.On("CancelRead", quic.StreamErrorCode(DroppedGroupErrorCode)).Return()
+	track.enqueueGroup(2, late)
+	enqueueOrderedTestGroups(track, 4)
+
+	event, err = ordered.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(4), describe(event))
+	late.AssertExpectations(t)
+}
+
+func TestOrderedTrackReader_Groups(t *testing.T) {
+	track := newOrderedTestTrack(t)
+	enqueueOrderedTestGroups(track, 3, 7, 5)
+	ordered := NewOrderedTrackReader(track, 8, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// The held groups are delivered when the track ends
+	time.AfterFunc(20*time.Millisecond, func() { _ = track.Close() })
+
+	var got []GroupSequence
+	for group := range ordered.Groups(ctx) {
+		got = append(got, group.GroupSequence())
+	}
+	assert.Equal(t, []GroupSequence{3, 5, 7}, got)
+	assert.NoError(t, ctx.Err())
+}
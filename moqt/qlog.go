@@ -0,0 +1,321 @@
+package moqt
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/moqt/internal/message"
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// QlogDir returns a Config.Qlog function writing the trace of every
+// connection to a new file in dir, named after the time the connection
+// was set up and the role.
+func QlogDir(dir string) func(Role, quic.Connection) io.WriteCloser {
+	return func(role Role, _ quic.Connection) io.WriteCloser {
+		pattern := fmt.Sprintf("%s_%s_*.sqlog", time.Now().UTC().Format("20060102T150405"), role)
+		f, err := os.CreateTemp(dir, pattern)
+		if err != nil {
+			slog.Error("moq: failed to create qlog file",
+				"dir", filepath.Clean(dir),
+				"error", err,
+			)
+			return nil
+		}
+		return f
+	}
+}
+
+const (
+	qlogVersion = "0.3"
+
+	// recordSeparator starts every JSON-SEQ record (RFC 7464).
+	recordSeparator = 0x1e
+)
+
+const (
+	ownerLocal  = "local"
+	ownerRemote = "remote"
+)
+
+// newTracer returns the tracer of conn configured by config, or nil.
+// The tracer is closed when conn ends.
+func newTracer(config *Config, role Role, conn quic.Connection) *tracer {
+	if config == nil || config.Qlog == nil {
+		return nil
+	}
+
+	w := config.Qlog(role, conn)
+	if w == nil {
+		return nil
+	}
+
+	t := &tracer{
+		w:     w,
+		buf:   bufio.NewWriter(w),
+		start: time.Now(),
+	}
+	t.enc = json.NewEncoder(t.buf)
+
+	t.writeRecord(map[string]any{
+		"qlog_version": qlogVersion,
+		"qlog_format":  "JSON-SEQ",
+		"title":        "gomoqt",
+		"trace": map[string]any{
+			"vantage_point": map[string]any{
+				"type": string(role),
+			},
+			"common_fields": map[string]any{
+				"protocol_type":  []string{"MOQT"},
+				"time_format":    "relative",
+				"reference_time": float64(t.start.UnixNano()) / 1e6,
+			},
+		},
+	})
+
+	context.AfterFunc(conn.Context(), t.close)
+
+	return t
+}
+
+// tracer writes the qlog events of a connection as JSON-SEQ records.
+type tracer struct {
+	mu     sync.Mutex
+	w      io.WriteCloser
+	buf    *bufio.Writer
+	enc    *json.Encoder
+	start  time.Time
+	closed bool
+}
+
+func (t *tracer) event(name string, data map[string]any) {
+	t.writeRecord(map[string]any{
+		"time": float64(time.Since(t.start).Nanoseconds()) / 1e6,
+		"name": name,
+		"data": data,
+	})
+}
+
+func (t *tracer) writeRecord(record map[string]any) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	_ = t.buf.WriteByte(recordSeparator)
+	_ = t.enc.Encode(record)
+
+	// Flush every record so that the trace is complete
+	// even if the process does not end cleanly
+	_ = t.buf.Flush()
+}
+
+func (t *tracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return
+	}
+	t.closed = true
+
+	_ = t.w.Close()
+}
+
+// streamTracer returns the tracer of stream and the stream ID,
+// or nil if the stream is not traced.
+func streamTracer(stream any) (*tracer, quic.StreamID) {
+	conn := streamConn(stream)
+	if conn == nil || conn.tracer == nil {
+		return nil, 0
+	}
+	return conn.tracer, stream.(interface{ StreamID() quic.StreamID }).StreamID()
+}
+
+// traceStreamType traces the type of a bidirectional stream.
+func traceStreamType(stream any, owner string, typ message.StreamType) {
+	t, id := streamTracer(stream)
+	if t == nil {
+		return
+	}
+
+	var name string
+	switch typ {
+	case message.StreamTypeSession:
+		name = "session"
+	case message.StreamTypeAnnounce:
+		name = "announce"
+	case message.StreamTypeSubscribe:
+		name = "subscribe"
+	default:
+		name = "unknown"
+	}
+
+	t.event("moqt:stream_type_set", map[string]any{
+		"stream_id":   id,
+		"owner":       owner,
+		"stream_type": name,
+	})
+}
+
+// traceMessageCreated traces a control message written on stream.
+func traceMessageCreated(stream any, msg any) {
+	traceMessage(stream, "moqt:control_message_created", msg)
+}
+
+// traceMessageParsed traces a control message read from stream.
+func traceMessageParsed(stream any, msg any) {
+	traceMessage(stream, "moqt:control_message_parsed", msg)
+}
+
+func traceMessage(stream any, name string, msg any) {
+	t, id := streamTracer(stream)
+	if t == nil {
+		return
+	}
+
+	t.event(name, map[string]any{
+		"stream_id": id,
+		"message":   messageData(msg),
+	})
+}
+
+// traceGroupOpened traces the header of a group stream.
+func traceGroupOpened(stream any, owner string, gm message.GroupMessage) {
+	t, id := streamTracer(stream)
+	if t == nil {
+		return
+	}
+
+	t.event("moqt:group_stream_opened", map[string]any{
+		"stream_id":      id,
+		"owner":          owner,
+		"subscribe_id":   gm.SubscribeID,
+		"group_sequence": gm.GroupSequence,
+	})
+}
+
+// traceGroupClosed traces the end of a group stream and the number of
+// frames transferred on it. err is nil if the stream ended gracefully.
+func traceGroupClosed(stream any, owner string, frames uint64, err error) {
+	t, id := streamTracer(stream)
+	if t == nil {
+		return
+	}
+
+	data := map[string]any{
+		"stream_id": id,
+		"owner":     owner,
+		"frames":    frames,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+		var grpErr *GroupError
+		if errors.As(err, &grpErr) {
+			data["error_code"] = uint64(grpErr.GroupErrorCode())
+		}
+	}
+
+	t.event("moqt:group_stream_closed", data)
+}
+
+// traceGroupCanceled traces a group stream canceled locally with code.
+func traceGroupCanceled(stream any, frames uint64, code GroupErrorCode) {
+	t, id := streamTracer(stream)
+	if t == nil {
+		return
+	}
+	traceGroupClosed(stream, ownerLocal, frames, &GroupError{
+		StreamError: &quic.StreamError{StreamID: id, ErrorCode: quic.StreamErrorCode(code)},
+	})
+}
+
+// messageData returns the decoded fields of a control message.
+func messageData(msg any) map[string]any {
+	switch m := msg.(type) {
+	case message.SessionClientMessage:
+		return map[string]any{
+			"type":               "session_client",
+			"supported_versions": m.SupportedVersions,
+			"parameters":         parametersData(m.Parameters),
+		}
+	case message.SessionServerMessage:
+		return map[string]any{
+			"type":             "session_server",
+			"selected_version": m.SelectedVersion,
+			"parameters":       parametersData(m.Parameters),
+		}
+	case message.SessionUpdateMessage:
+		return map[string]any{
+			"type":    "session_update",
+			"bitrate": m.Bitrate,
+		}
+	case message.AnnouncePleaseMessage:
+		return map[string]any{
+			"type":         "announce_please",
+			"track_prefix": m.TrackPrefix,
+		}
+	case message.AnnounceInitMessage:
+		return map[string]any{
+			"type":     "announce_init",
+			"suffixes": m.Suffixes,
+		}
+	case message.AnnounceMessage:
+		status := "active"
+		if m.AnnounceStatus == message.ENDED {
+			status = "ended"
+		}
+		return map[string]any{
+			"type":            "announce",
+			"announce_status": status,
+			"track_suffix":    m.TrackSuffix,
+		}
+	case message.SubscribeMessage:
+		return map[string]any{
+			"type":           "subscribe",
+			"subscribe_id":   m.SubscribeID,
+			"broadcast_path": m.BroadcastPath,
+			"track_name":     m.TrackName,
+			"track_priority": m.TrackPriority,
+		}
+	case message.SubscribeOkMessage:
+		return map[string]any{
+			"type": "subscribe_ok",
+		}
+	case message.SubscribeUpdateMessage:
+		return map[string]any{
+			"type":           "subscribe_update",
+			"track_priority": m.TrackPriority,
+		}
+	default:
+		return map[string]any{
+			"type": fmt.Sprintf("%T", msg),
+		}
+	}
+}
+
+// parametersData returns the setup parameters with hex encoded values.
+func parametersData(params map[uint64][]byte) []map[string]any {
+	data := make([]map[string]any, 0, len(params))
+	for _, typ := range slices.Sorted(maps.Keys(params)) {
+		data = append(data, map[string]any{
+			"type":  typ,
+			"value": hex.EncodeToString(params[typ]),
+		})
+	}
+	return data
+}
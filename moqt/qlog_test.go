@@ -0,0 +1,222 @@
+package moqt
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// qlogBuffer collects a qlog trace and reports when it is closed.
+type qlogBuffer struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed chan struct{}
+}
+
+func newQlogBuffer() *qlogBuffer {
+	return &qlogBuffer{closed: make(chan struct{})}
+}
+
+func (b *qlogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *qlogBuffer) Close() error {
+	close(b.closed)
+	return nil
+}
+
+// records decodes the JSON-SEQ records of the trace.
+func (b *qlogBuffer) records(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]any
+	for _, rec := range bytes.Split(b.buf.Bytes(), []byte{recordSeparator}) {
+		if len(rec) == 0 {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(rec, &record), "record %q", rec)
+		records = append(records, record)
+	}
+	return records
+}
+
+// messages returns the types of the control messages of the named events.
+func messages(records []map[string]any, name string) []string {
+	var types []string
+	for _, r := range records {
+		if r["name"] != name {
+			continue
+		}
+		msg := r["data"].(map[string]any)["message"].(map[string]any)
+		types = append(types, msg["type"].(string))
+	}
+	return types
+}
+
+func events(records []map[string]any, name string) []map[string]any {
+	var data []map[string]any
+	for _, r := range records {
+		if r["name"] == name {
+			data = append(data, r["data"].(map[string]any))
+		}
+	}
+	return data
+}
+
+func TestQlog(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		trackCtx := tw.Context()
+		defer func() { <-trackCtx.Done() }()
+
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		for range 3 {
+			frame := NewFrame(5)
+			_, _ = frame.Write([]byte("hello"))
+			_ = gw.WriteFrame(frame)
+		}
+		_ = gw.Close()
+	})
+
+	serverTrace := newQlogBuffer()
+	clientTrace := newQlogBuffer()
+
+	d := newMemoryDialer(t, mux)
+	d.server.Config = &Config{
+		Qlog: func(role Role, _ quic.Connection) io.WriteCloser {
+			assert.Equal(t, RoleServer, role)
+			return serverTrace
+		},
+	}
+	client := &Client{
+		DialQUICFunc: d.dial,
+		Config: &Config{
+			Qlog: func(role Role, _ quic.Connection) io.WriteCloser {
+				assert.Equal(t, RoleClient, role)
+				return clientTrace
+			},
+		},
+	}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+
+	tr, err := sess.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+	require.NoError(t, tr.Update(&TrackConfig{TrackPriority: 2}))
+	assert.Eventually(t, func() bool {
+		return slices.Contains(messages(serverTrace.records(t), "moqt:control_message_parsed"), "subscribe_update")
+	}, time.Second, time.Millisecond)
+
+	gr, err := tr.AcceptGroup(ctx)
+	require.NoError(t, err)
+	for range gr.Frames(nil) {
+	}
+
+	ar, err := sess.AcceptAnnounce("/")
+	require.NoError(t, err)
+	_, err = ar.ReceiveAnnouncement(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, sess.CloseWithError(NoError, ""))
+
+	for _, trace := range []*qlogBuffer{clientTrace, serverTrace} {
+		select {
+		case <-trace.closed:
+		case <-ctx.Done():
+			t.Fatal("trace was not closed with the connection")
+		}
+	}
+
+	clientRecords, serverRecords := clientTrace.records(t), serverTrace.records(t)
+	require.NotEmpty(t, clientRecords)
+	require.NotEmpty(t, serverRecords)
+
+	// Header
+	assert.Equal(t, "0.3", clientRecords[0]["qlog_version"])
+	assert.Equal(t, "JSON-SEQ", clientRecords[0]["qlog_format"])
+	vantage := serverRecords[0]["trace"].(map[string]any)["vantage_point"].(map[string]any)
+	assert.Equal(t, "server", vantage["type"])
+
+	assert.Equal(t,
+		[]string{"session_client", "subscribe", "subscribe_update", "announce_please"},
+		messages(clientRecords, "moqt:control_message_created"))
+	assert.Subset(t, messages(clientRecords, "moqt:control_message_parsed"),
+		[]string{"session_server", "subscribe_ok", "announce_init"})
+	// Streams are read concurrently by the server
+	assert.ElementsMatch(t,
+		[]string{"session_client", "subscribe", "subscribe_update", "announce_please"},
+		messages(serverRecords, "moqt:control_message_parsed"))
+	assert.Subset(t, messages(serverRecords, "moqt:control_message_created"),
+		[]string{"session_server", "subscribe_ok", "announce_init"})
+
+	// Decoded fields
+	for _, data := range events(serverRecords, "moqt:control_message_parsed") {
+		msg := data["message"].(map[string]any)
+		if msg["type"] == "subscribe" {
+			assert.Equal(t, "/live", msg["broadcast_path"])
+			assert.Equal(t, "video", msg["track_name"])
+		}
+	}
+
+	assert.Len(t, events(clientRecords, "moqt:stream_type_set"), 3)
+	assert.Len(t, events(serverRecords, "moqt:stream_type_set"), 3)
+
+	// Group streams
+	for _, records := range [][]map[string]any{clientRecords, serverRecords} {
+		opened := events(records, "moqt:group_stream_opened")
+		require.Len(t, opened, 1)
+		assert.Equal(t, float64(0), opened[0]["group_sequence"])
+
+		closed := events(records, "moqt:group_stream_closed")
+		require.Len(t, closed, 1)
+		assert.Equal(t, float64(3), closed[0]["frames"])
+		assert.NotContains(t, closed[0], "error")
+	}
+}
+
+func TestQlogDir(t *testing.T) {
+	dir := t.TempDir()
+
+	conn := &MockQUICConnection{}
+	w := QlogDir(dir)(RoleClient, conn)
+	require.NotNil(t, w)
+	_, err := w.Write([]byte("trace"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*_client_*.sqlog"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "trace", string(data))
+}
+
+func TestQlogDir_InvalidDir(t *testing.T) {
+	w := QlogDir(filepath.Join(t.TempDir(), "missing"))(RoleServer, &MockQUICConnection{})
+	assert.Nil(t, w)
+}
@@ -39,19 +39,29 @@ func newReceiveSubscribeStream(id SubscribeID, stream quic.Stream, config *Track
 
 			err = sum.Decode(rss.stream)
 			if err != nil {
+				_ = checkMessageSize(rss.stream, err)
 				break
 			}
+			traceMessageParsed(rss.stream, sum)
 
 			rss.configMu.Lock()
-			rss.config = &TrackConfig{
-				TrackPriority: TrackPriority(sum.TrackPriority),
+			config := &TrackConfig{
+				TrackPriority:   TrackPriority(sum.TrackPriority),
+				DeliveryTimeout: deliveryTimeoutFromMillis(sum.DeliveryTimeout),
+				StartGroup:      rss.config.StartGroup,
 			}
+			rss.config = config
 
 			select {
 			case rss.updatedCh <- struct{}{}:
 			default:
 			}
+			onUpdate := rss.onUpdate
 			rss.configMu.Unlock()
+
+			if onUpdate != nil {
+				onUpdate(config)
+			}
 		}
 
 		// Cleanup after loop ends
@@ -83,6 +93,10 @@ type receiveSubscribeStream struct {
 	config    *TrackConfig
 	updatedCh chan struct{}
 
+	// onUpdate is called with the config of each SUBSCRIBE_UPDATE,
+	// without consuming updatedCh
+	onUpdate func(*TrackConfig)
+
 	closeOnce chan struct{}
 
 	ctx context.Context
@@ -120,6 +134,7 @@ func (rss *receiveSubscribeStream) WriteInfo(info Info) error {
 			_ = rss.closeWithError(InternalSubscribeErrorCode)
 			return
 		}
+		traceMessageCreated(rss.stream, sum)
 	})
 
 	return err
@@ -137,6 +152,13 @@ func (rss *receiveSubscribeStream) TrackConfig() *TrackConfig {
 	return rss.config
 }
 
+func (rss *receiveSubscribeStream) setUpdateFunc(f func(*TrackConfig)) {
+	rss.configMu.Lock()
+	defer rss.configMu.Unlock()
+
+	rss.onUpdate = f
+}
+
 func (rss *receiveSubscribeStream) Updated() <-chan struct{} {
 	return rss.updatedCh
 }
@@ -0,0 +1,555 @@
+package moqt
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinReconnectBackoff = 100 * time.Millisecond
+	defaultMaxReconnectBackoff = 10 * time.Second
+)
+
+// ErrClosedReconnectingSession is returned by a ReconnectingSession and its readers after it was closed.
+var ErrClosedReconnectingSession = errors.New("moq: reconnecting session closed")
+
+// ReconnectConfig configures how a ReconnectingSession redials.
+type ReconnectConfig struct {
+	// MinBackoff is the delay before the first redial after a session ends.
+	// It doubles after every failed attempt. Defaults to 100ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. Defaults to 10s.
+	MaxBackoff time.Duration
+
+	// OnConnect, if set, is called with every new session,
+	// including the first one.
+	OnConnect func(*Session)
+
+	// OnDisconnect, if set, is called with the cause when a session ends.
+	OnDisconnect func(error)
+}
+
+func (c *ReconnectConfig) minBackoff() time.Duration {
+	if c == nil || c.MinBackoff <= 0 {
+		return defaultMinReconnectBackoff
+	}
+	return c.MinBackoff
+}
+
+func (c *ReconnectConfig) maxBackoff() time.Duration {
+	if c == nil || c.MaxBackoff <= 0 {
+		return defaultMaxReconnectBackoff
+	}
+	return max(c.MaxBackoff, c.minBackoff())
+}
+
+// backoff returns the delay following d.
+func (c *ReconnectConfig) backoff(d time.Duration) time.Duration {
+	if d == 0 {
+		return c.minBackoff()
+	}
+	return min(2*d, c.maxBackoff())
+}
+
+// DialReconnecting dials urlStr like Dial and keeps a session to it alive.
+// When the session ends, it is redialed with exponential backoff, and the
+// subscriptions and announcement readers obtained from the returned
+// ReconnectingSession are re-established on the new session.
+// It fails if the first dial fails.
+func (c *Client) DialReconnecting(ctx context.Context, urlStr string, mux *TrackMux, config *ReconnectConfig) (*ReconnectingSession, error) {
+	sess, err := c.Dial(ctx, urlStr, mux)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := &ReconnectingSession{
+		client:  c,
+		url:     urlStr,
+		mux:     mux,
+		config:  config,
+		changed: make(chan struct{}),
+	}
+	rs.ctx, rs.cancel = context.WithCancelCause(context.Background())
+
+	rs.setSession(sess)
+
+	go rs.run(sess)
+
+	return rs, nil
+}
+
+// ReconnectingSession is a client session that survives reconnects.
+// Its TrackReaders and AnnouncementReaders are long-lived and resume on
+// every new session.
+type ReconnectingSession struct {
+	client *Client
+	url    string
+	mux    *TrackMux
+	config *ReconnectConfig
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu   sync.Mutex
+	sess *Session
+	// changed is closed and replaced whenever sess changes.
+	changed chan struct{}
+}
+
+// Session returns the current session, or nil while reconnecting.
+func (rs *ReconnectingSession) Session() *Session {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	return rs.sess
+}
+
+// Context returns a context canceled when the ReconnectingSession is closed
+// or the client shuts down.
+func (rs *ReconnectingSession) Context() context.Context {
+	return rs.ctx
+}
+
+// Close stops reconnecting and closes the current session.
+func (rs *ReconnectingSession) Close() error {
+	rs.cancel(ErrClosedReconnectingSession)
+
+	sess := rs.Session()
+	if sess == nil {
+		return nil
+	}
+	return sess.CloseWithError(NoError, SessionErrorText(NoError))
+}
+
+// setSession replaces the current session and reports whether sess was
+// stored. A session set after Close is closed instead.
+func (rs *ReconnectingSession) setSession(sess *Session) bool {
+	rs.mu.Lock()
+	if sess != nil && rs.ctx.Err() != nil {
+		rs.mu.Unlock()
+		_ = sess.CloseWithError(NoError, SessionErrorText(NoError))
+		return false
+	}
+
+	rs.sess = sess
+	close(rs.changed)
+	rs.changed = make(chan struct{})
+
+	var onConnect func(*Session)
+	if rs.config != nil {
+		onConnect = rs.config.OnConnect
+	}
+	rs.mu.Unlock()
+
+	if sess != nil && onConnect != nil {
+		onConnect(sess)
+	}
+
+	return true
+}
+
+// waitSession returns the current session, waiting while reconnecting.
+func (rs *ReconnectingSession) waitSession(ctx context.Context) (*Session, error) {
+	for {
+		rs.mu.Lock()
+		sess, changed := rs.sess, rs.changed
+		rs.mu.Unlock()
+
+		if sess != nil && sess.Context().Err() == nil {
+			return sess, nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		}
+	}
+}
+
+// run redials whenever the session ends until rs is closed.
+func (rs *ReconnectingSession) run(sess *Session) {
+	for {
+		select {
+		case <-sess.Context().Done():
+		case <-rs.ctx.Done():
+			return
+		}
+
+		rs.setSession(nil)
+		if rs.config != nil && rs.config.OnDisconnect != nil {
+			rs.config.OnDisconnect(Cause(sess.Context()))
+		}
+
+		var backoff time.Duration
+		for {
+			backoff = rs.config.backoff(backoff)
+			if err := sleep(rs.ctx, backoff); err != nil {
+				return
+			}
+
+			var err error
+			sess, err = rs.client.Dial(rs.ctx, rs.url, rs.mux)
+			if err == nil {
+				break
+			}
+			if errors.Is(err, ErrClientClosed) {
+				rs.cancel(err)
+				return
+			}
+		}
+
+		// Closed while dialing
+		if !rs.setSession(sess) {
+			return
+		}
+	}
+}
+
+// Subscribe subscribes to a track on the current and every later session.
+// After a reconnect, the track resumes from the group following the last
+// group accepted, which is sent as the StartGroup of the new subscription.
+// If the subscription is rejected or ends while the session is alive, it
+// is retried with backoff.
+func (rs *ReconnectingSession) Subscribe(path BroadcastPath, name TrackName, config *TrackConfig) (*ReconnectingTrackReader, error) {
+	if rs.ctx.Err() != nil {
+		return nil, context.Cause(rs.ctx)
+	}
+
+	if config == nil {
+		config = &TrackConfig{}
+	}
+
+	r := &ReconnectingTrackReader{
+		BroadcastPath: path,
+		TrackName:     name,
+		rs:            rs,
+		config:        *config,
+		groups:        make(chan *GroupReader),
+		done:          make(chan struct{}),
+	}
+	r.ctx, r.cancel = context.WithCancelCause(rs.ctx)
+
+	go r.run()
+
+	return r, nil
+}
+
+// ReconnectingTrackReader receives the groups of a track across reconnects.
+//
+// Every subscription after the first asks the publisher to start at the
+// group following the last group accepted, so that a TrackWriter does not
+// send the groups received before the reconnect again. Groups not newer
+// than the last group accepted are still discarded, for publishers that
+// ignore the StartGroup.
+type ReconnectingTrackReader struct {
+	BroadcastPath BroadcastPath
+	TrackName     TrackName
+
+	rs *ReconnectingSession
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	groups chan *GroupReader
+	done   chan struct{}
+
+	mu      sync.Mutex
+	config  TrackConfig
+	current *TrackReader
+
+	// last is the sequence of the last group accepted.
+	last     GroupSequence
+	accepted bool
+}
+
+// AcceptGroup blocks until the next group is available, ctx is canceled
+// or the reader is closed. It keeps waiting while reconnecting.
+func (r *ReconnectingTrackReader) AcceptGroup(ctx context.Context) (*GroupReader, error) {
+	select {
+	case group := <-r.groups:
+		return group, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.ctx.Done():
+		return nil, context.Cause(r.ctx)
+	}
+}
+
+// Update updates the subscription configuration.
+// It is also applied to the subscriptions made on later sessions.
+func (r *ReconnectingTrackReader) Update(config *TrackConfig) error {
+	if config == nil {
+		return errors.New("subscribe config cannot be nil")
+	}
+
+	r.mu.Lock()
+	r.config = *config
+	current := r.current
+	r.mu.Unlock()
+
+	if current == nil {
+		return nil
+	}
+	return current.Update(config)
+}
+
+// TrackConfig returns the subscription configuration.
+func (r *ReconnectingTrackReader) TrackConfig() *TrackConfig {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	config := r.config
+	return &config
+}
+
+// Context returns a context canceled when the reader is closed.
+func (r *ReconnectingTrackReader) Context() context.Context {
+	return r.ctx
+}
+
+// Close ends the subscription.
+func (r *ReconnectingTrackReader) Close() error {
+	r.cancel(ErrClosedReconnectingSession)
+	<-r.done
+
+	return nil
+}
+
+func (r *ReconnectingTrackReader) run() {
+	defer close(r.done)
+
+	var backoff time.Duration
+	for {
+		sess, err := r.rs.waitSession(r.ctx)
+		if err != nil {
+			return
+		}
+
+		r.mu.Lock()
+		config := r.config
+		r.mu.Unlock()
+
+		if r.accepted {
+			config.StartGroup = r.last.Next()
+		}
+
+		tr, err := sess.Subscribe(r.BroadcastPath, r.TrackName, &config)
+		if err != nil {
+			if sess.Context().Err() != nil {
+				continue
+			}
+			// Rejected by the publisher
+			backoff = r.rs.config.backoff(backoff)
+			if err := sleepUntilChanged(r.ctx, sess.Context(), backoff); err != nil {
+				return
+			}
+			continue
+		}
+		backoff = 0
+
+		r.mu.Lock()
+		if r.ctx.Err() != nil {
+			r.mu.Unlock()
+			_ = tr.Close()
+			return
+		}
+		r.current = tr
+		r.mu.Unlock()
+
+		r.receive(tr)
+
+		r.mu.Lock()
+		if r.current == tr {
+			r.current = nil
+		}
+		r.mu.Unlock()
+		_ = tr.Close()
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		// The track ended while the session is alive
+		if sess.Context().Err() == nil {
+			backoff = r.rs.config.backoff(backoff)
+			if err := sleepUntilChanged(r.ctx, sess.Context(), backoff); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// receive hands the groups of tr to AcceptGroup until tr ends,
+// discarding groups not newer than the last group accepted.
+func (r *ReconnectingTrackReader) receive(tr *TrackReader) {
+	for {
+		group, err := tr.AcceptGroup(r.ctx)
+		if err != nil {
+			return
+		}
+
+		seq := group.GroupSequence()
+		if r.accepted && seq <= r.last {
+			group.CancelRead(OutOfRangeErrorCode)
+			continue
+		}
+
+		select {
+		case r.groups <- group:
+			r.last = seq
+			r.accepted = true
+		case <-r.ctx.Done():
+			group.CancelRead(SubscribeCanceledErrorCode)
+			return
+		case <-tr.Context().Done():
+			group.CancelRead(SubscribeCanceledErrorCode)
+			return
+		}
+	}
+}
+
+// AcceptAnnounce receives the announcements for prefix on the current and
+// every later session. Announcements end with the session they were
+// received on and are announced again on the next session.
+func (rs *ReconnectingSession) AcceptAnnounce(prefix string) (*ReconnectingAnnouncementReader, error) {
+	if rs.ctx.Err() != nil {
+		return nil, context.Cause(rs.ctx)
+	}
+	if !isValidPrefix(prefix) {
+		return nil, errors.New("invalid prefix")
+	}
+
+	r := &ReconnectingAnnouncementReader{
+		rs:            rs,
+		prefix:        prefix,
+		announcements: make(chan *Announcement),
+		done:          make(chan struct{}),
+	}
+	r.ctx, r.cancel = context.WithCancelCause(rs.ctx)
+
+	go r.run()
+
+	return r, nil
+}
+
+// ReconnectingAnnouncementReader receives announcements across reconnects.
+type ReconnectingAnnouncementReader struct {
+	rs     *ReconnectingSession
+	prefix string
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	announcements chan *Announcement
+	done          chan struct{}
+}
+
+// ReceiveAnnouncement blocks until the next announcement is available,
+// ctx is canceled or the reader is closed. It keeps waiting while reconnecting.
+func (r *ReconnectingAnnouncementReader) ReceiveAnnouncement(ctx context.Context) (*Announcement, error) {
+	select {
+	case ann := <-r.announcements:
+		return ann, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-r.ctx.Done():
+		return nil, context.Cause(r.ctx)
+	}
+}
+
+// Context returns a context canceled when the reader is closed.
+func (r *ReconnectingAnnouncementReader) Context() context.Context {
+	return r.ctx
+}
+
+// Close stops receiving announcements.
+func (r *ReconnectingAnnouncementReader) Close() error {
+	r.cancel(ErrClosedReconnectingSession)
+	<-r.done
+
+	return nil
+}
+
+func (r *ReconnectingAnnouncementReader) run() {
+	defer close(r.done)
+
+	var backoff time.Duration
+	for {
+		sess, err := r.rs.waitSession(r.ctx)
+		if err != nil {
+			return
+		}
+
+		ar, err := sess.AcceptAnnounce(r.prefix)
+		if err != nil {
+			if sess.Context().Err() != nil {
+				continue
+			}
+			backoff = r.rs.config.backoff(backoff)
+			if err := sleepUntilChanged(r.ctx, sess.Context(), backoff); err != nil {
+				return
+			}
+			continue
+		}
+		backoff = 0
+
+		for {
+			ann, err := ar.ReceiveAnnouncement(r.ctx)
+			if err != nil {
+				break
+			}
+
+			select {
+			case r.announcements <- ann:
+				continue
+			case <-r.ctx.Done():
+			}
+			break
+		}
+
+		_ = ar.Close()
+
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		if sess.Context().Err() == nil {
+			backoff = r.rs.config.backoff(backoff)
+			if err := sleepUntilChanged(r.ctx, sess.Context(), backoff); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// sleepUntilChanged waits for d, returning early when the session ends.
+func sleepUntilChanged(ctx, sessCtx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-sessCtx.Done():
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
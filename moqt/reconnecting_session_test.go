@@ -0,0 +1,297 @@
+package moqt
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryDialer serves every dialed connection in memory with a Server
+// and can drop the connections it served.
+type memoryDialer struct {
+	server *Server
+
+	mu    sync.Mutex
+	conns []quic.Connection
+	fail  bool
+}
+
+func newMemoryDialer(t *testing.T, mux *TrackMux) *memoryDialer {
+	d := &memoryDialer{
+		server: &Server{
+			SetupHandler: SetupHandlerFunc(func(w SetupResponseWriter, r *SetupRequest) {
+				_, _ = Accept(w, r, mux)
+			}),
+		},
+	}
+	t.Cleanup(func() { _ = d.server.Close() })
+	return d
+}
+
+func (d *memoryDialer) dial(context.Context, string, *tls.Config, *quic.Config) (quic.Connection, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fail {
+		return nil, context.DeadlineExceeded
+	}
+
+	client, server := quicmem.Pipe(NextProtoMOQ)
+	d.conns = append(d.conns, server)
+	go func() { _ = d.server.ServeQUICConn(server) }()
+
+	return client, nil
+}
+
+// drop closes the connections served so far and makes dials fail if fail is set.
+func (d *memoryDialer) drop(fail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, conn := range d.conns {
+		_ = conn.CloseWithError(quic.ApplicationErrorCode(InternalSessionErrorCode), "dropped")
+	}
+	d.conns = nil
+	d.fail = fail
+}
+
+func (d *memoryDialer) setFail(fail bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.fail = fail
+}
+
+func TestReconnectingSession_Subscribe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The publisher shares group sequences between subscriptions as a relay does
+	var seq atomic.Uint64
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		trackCtx := tw.Context()
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-trackCtx.Done():
+				return
+			}
+			gw, err := tw.OpenGroupAt(GroupSequence(seq.Add(1)))
+			if err != nil {
+				return
+			}
+			_ = gw.Close()
+		}
+	})
+
+	d := newMemoryDialer(t, mux)
+
+	var connects atomic.Int32
+	disconnected := make(chan error, 1)
+	client := &Client{DialQUICFunc: d.dial}
+	rs, err := client.DialReconnecting(ctx, "moqt://memory:0/", nil, &ReconnectConfig{
+		MinBackoff:   10 * time.Millisecond,
+		OnConnect:    func(*Session) { connects.Add(1) },
+		OnDisconnect: func(err error) { disconnected <- err },
+	})
+	require.NoError(t, err)
+	defer rs.Close()
+
+	tr, err := rs.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	var last GroupSequence
+	accept := func(n int) {
+		for range n {
+			gr, err := tr.AcceptGroup(ctx)
+			require.NoError(t, err)
+			assert.Greater(t, gr.GroupSequence(), last)
+			last = gr.GroupSequence()
+		}
+	}
+
+	accept(3)
+
+	d.drop(true)
+	select {
+	case err := <-disconnected:
+		assert.Error(t, err)
+	case <-ctx.Done():
+		t.Fatal("disconnect was not reported")
+	}
+	assert.Nil(t, rs.Session())
+
+	// Redials fail until the network is back
+	time.Sleep(30 * time.Millisecond)
+	d.setFail(false)
+
+	accept(3)
+	assert.Equal(t, int32(2), connects.Load())
+	assert.NotNil(t, rs.Session())
+}
+
+func TestReconnectingSession_ResumesAfterLastGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Every subscription numbers its groups from 0, as a restarted publisher would
+	firsts := make(chan GroupSequence, 2)
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/replay", func(tw *TrackWriter) {
+		trackCtx := tw.Context()
+		firsts <- tw.TrackConfig().StartGroup
+		for range 10 {
+			gw, err := tw.OpenGroup()
+			if err != nil {
+				return
+			}
+			_ = gw.Close()
+			// Group streams may be accepted out of order
+			time.Sleep(5 * time.Millisecond)
+		}
+		<-trackCtx.Done()
+	})
+
+	d := newMemoryDialer(t, mux)
+	client := &Client{DialQUICFunc: d.dial}
+	rs, err := client.DialReconnecting(ctx, "moqt://memory:0/", nil, &ReconnectConfig{MinBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer rs.Close()
+
+	tr, err := rs.Subscribe("/replay", "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	for want := range GroupSequence(4) {
+		gr, err := tr.AcceptGroup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, gr.GroupSequence())
+	}
+
+	d.drop(false)
+
+	// The new subscription starts after the last group accepted
+	for want := GroupSequence(4); want < 10; want++ {
+		gr, err := tr.AcceptGroup(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, gr.GroupSequence())
+	}
+
+	assert.Equal(t, GroupSequence(0), <-firsts)
+	assert.Equal(t, GroupSequence(4), <-firsts)
+}
+
+func TestReconnectingSession_AcceptAnnounce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/room/alice", func(tw *TrackWriter) {})
+
+	d := newMemoryDialer(t, mux)
+	client := &Client{DialQUICFunc: d.dial}
+	rs, err := client.DialReconnecting(ctx, "moqt://memory:0/", nil, &ReconnectConfig{MinBackoff: 10 * time.Millisecond})
+	require.NoError(t, err)
+	defer rs.Close()
+
+	ar, err := rs.AcceptAnnounce("/room/")
+	require.NoError(t, err)
+	defer ar.Close()
+
+	ann, err := ar.ReceiveAnnouncement(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, BroadcastPath("/room/alice"), ann.BroadcastPath())
+
+	d.drop(false)
+
+	select {
+	case <-ann.Done():
+	case <-ctx.Done():
+		t.Fatal("announcement did not end with its session")
+	}
+
+	ann, err = ar.ReceiveAnnouncement(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, BroadcastPath("/room/alice"), ann.BroadcastPath())
+	assert.True(t, ann.IsActive())
+}
+
+func TestReconnectingSession_OnConnectUsesSession(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+
+	var current atomic.Pointer[ReconnectingSession]
+	connected := make(chan *Session, 1)
+	client := &Client{DialQUICFunc: d.dial}
+	rs, err := client.DialReconnecting(ctx, "moqt://memory:0/", nil, &ReconnectConfig{
+		MinBackoff: 10 * time.Millisecond,
+		OnConnect: func(sess *Session) {
+			if rs := current.Load(); rs != nil {
+				connected <- rs.Session()
+			}
+		},
+	})
+	require.NoError(t, err)
+	defer rs.Close()
+	current.Store(rs)
+
+	d.drop(false)
+
+	select {
+	case sess := <-connected:
+		assert.NotNil(t, sess)
+	case <-ctx.Done():
+		t.Fatal("OnConnect did not return")
+	}
+}
+
+func TestReconnectingSession_Close(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	d := newMemoryDialer(t, NewTrackMux())
+	client := &Client{DialQUICFunc: d.dial}
+	rs, err := client.DialReconnecting(ctx, "moqt://memory:0/", nil, nil)
+	require.NoError(t, err)
+
+	tr, err := rs.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+
+	sess := rs.Session()
+	require.NotNil(t, sess)
+	require.NoError(t, rs.Close())
+
+	_, err = tr.AcceptGroup(ctx)
+	assert.ErrorIs(t, err, ErrClosedReconnectingSession)
+
+	_, err = rs.Subscribe("/live", "video", nil)
+	assert.ErrorIs(t, err, ErrClosedReconnectingSession)
+
+	select {
+	case <-sess.Context().Done():
+	case <-ctx.Done():
+		t.Fatal("session was not closed")
+	}
+}
+
+func TestClient_DialReconnecting_DialFails(t *testing.T) {
+	d := newMemoryDialer(t, NewTrackMux())
+	d.setFail(true)
+
+	client := &Client{DialQUICFunc: d.dial}
+	_, err := client.DialReconnecting(context.Background(), "moqt://memory:0/", nil, nil)
+	assert.Error(t, err)
+}
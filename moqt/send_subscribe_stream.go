@@ -60,7 +60,8 @@ func (sss *sendSubscribeStream) updateSubscribe(newConfig *TrackConfig) error {
 
 	// Send the message first before updating config
 	sum := message.SubscribeUpdateMessage{
-		TrackPriority: uint8(newConfig.TrackPriority),
+		TrackPriority:   uint8(newConfig.TrackPriority),
+		DeliveryTimeout: newConfig.deliveryTimeoutMillis(),
 	}
 	err := sum.Encode(sss.stream)
 	if err != nil {
@@ -71,8 +72,15 @@ func (sss *sendSubscribeStream) updateSubscribe(newConfig *TrackConfig) error {
 		return err
 	}
 
-	// Only update config after successful message sending
-	sss.config = newConfig
+	traceMessageCreated(sss.stream, sum)
+
+	// Only update config after successful message sending.
+	// StartGroup is only sent with the subscription.
+	config := *newConfig
+	if sss.config != nil {
+		config.StartGroup = sss.config.StartGroup
+	}
+	sss.config = &config
 
 	return nil
 }
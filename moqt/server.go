@@ -14,6 +14,7 @@ import (
 	"github.com/okdaichi/gomoqt/moqt/internal/message"
 	"github.com/okdaichi/gomoqt/quic"
 	"github.com/okdaichi/gomoqt/quic/quicgo"
+	"github.com/okdaichi/gomoqt/quic/quicmux"
 	"github.com/okdaichi/gomoqt/webtransport"
 	"github.com/okdaichi/gomoqt/webtransport/webtransportgo"
 )
@@ -232,6 +233,8 @@ func (s *Server) HandleWebTransport(w http.ResponseWriter, r *http.Request) erro
 
 	connLogger.Debug("establishing a WebTransport session")
 
+	conn = newSessionConn(conn, s.Config, RoleServer)
+
 	acceptCtx, cancelAccept := context.WithTimeout(r.Context(), s.Config.setupTimeout())
 	defer cancelAccept()
 	sessStr, err := acceptSessionStream(acceptCtx, conn, connLogger, s.Config)
@@ -261,6 +264,74 @@ func (s *Server) HandleWebTransport(w http.ResponseWriter, r *http.Request) erro
 	return nil
 }
 
+// HandleWebSocket upgrades an incoming HTTP/1.1 request to a connection
+// multiplexed over a WebSocket and handles session handshake and setup using
+// the Server's SetupHandler. It serves clients on networks where UDP is
+// blocked. The request path is used as the session path.
+func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) error {
+	if s.shuttingDown() {
+		return fmt.Errorf("server is shutting down")
+	}
+
+	s.init()
+
+	upgrader := &quicmux.Upgrader{
+		Protocols:   []string{NextProtoMOQ},
+		CheckOrigin: s.CheckHTTPOrigin,
+		Config:      s.QUICConfig,
+	}
+	conn, err := upgrader.Upgrade(w, r)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade connection: %w", err)
+	}
+
+	var connLogger *slog.Logger
+	if s.Logger != nil {
+		connLogger = s.Logger.With(
+			"transport", "websocket",
+			"local_address", conn.LocalAddr(),
+			"remote_address", conn.RemoteAddr(),
+			"alpn", conn.ConnectionState().TLS.NegotiatedProtocol,
+		)
+	} else {
+		connLogger = slog.New(slog.DiscardHandler)
+	}
+
+	connLogger.Debug("establishing a WebSocket session")
+
+	conn = newSessionConn(conn, s.Config, RoleServer)
+
+	// The request context ends with the handler, so the connection's is used
+	acceptCtx, cancelAccept := context.WithTimeout(conn.Context(), s.Config.setupTimeout())
+	defer cancelAccept()
+	sessStr, err := acceptSessionStream(acceptCtx, conn, connLogger, s.Config)
+	if err != nil {
+		connLogger.Error("failed to accept session stream",
+			"error", err,
+		)
+		_ = conn.CloseWithError(quic.ApplicationErrorCode(ProtocolViolationErrorCode), "moq: failed to accept session stream")
+		return fmt.Errorf("failed to accept session stream: %w", err)
+	}
+
+	connLogger.Debug("accepted a session stream")
+
+	// Set the path for the session
+	sessStr.Path = r.URL.Path
+
+	rsp := newResponseWriter(conn, sessStr, connLogger, s)
+	req := sessStr.SetupRequest
+
+	if s.SetupHandler != nil {
+		connLogger.Debug("using custom setup handler")
+		s.SetupHandler.ServeMOQ(rsp, req)
+	} else {
+		connLogger.Debug("no setup handler provided, using default router")
+		DefaultRouter.ServeMOQ(rsp, req)
+	}
+
+	return nil
+}
+
 func (s *Server) handleNativeQUIC(conn quic.Connection) error {
 	if s.shuttingDown() {
 		return nil
@@ -283,6 +354,8 @@ func (s *Server) handleNativeQUIC(conn quic.Connection) error {
 
 	connLogger.Debug("moq: establishing a QUIC session")
 
+	conn = newSessionConn(conn, s.Config, RoleServer)
+
 	acceptCtx, cancelAccept := context.WithTimeout(conn.Context(), s.Config.setupTimeout())
 	defer cancelAccept()
 	sessStr, err := acceptSessionStream(acceptCtx, conn, connLogger, s.Config)
@@ -344,11 +417,14 @@ func acceptSessionStream(acceptCtx context.Context, conn quic.Connection, connLo
 		"stream_id", stream.StreamID(),
 	)
 
+	traceStreamType(stream, ownerRemote, streamType)
+
 	streamLogger.Debug("accepted a session stream")
 
 	var scm message.SessionClientMessage
 	err = scm.Decode(stream)
 	if err != nil {
+		err = checkMessageSize(stream, err)
 		streamLogger.Error("failed to receive SESSION_CLIENT message",
 			"error", err,
 		)
@@ -360,6 +436,7 @@ func acceptSessionStream(acceptCtx context.Context, conn quic.Connection, connLo
 
 		return nil, err
 	}
+	traceMessageParsed(stream, scm)
 
 	// Get the client parameters
 	clientParams := &Extension{scm.Parameters}
@@ -379,7 +456,10 @@ func acceptSessionStream(acceptCtx context.Context, conn quic.Connection, connLo
 		ClientExtensions: clientParams,
 	}
 
-	return newSessionStream(stream, req), nil
+	sessStr := newSessionStream(stream, req)
+	sessStr.metrics, sessStr.role = config.metrics(), RoleServer
+
+	return sessStr, nil
 } // ListenAndServe starts the server by listening on the server's Address and serving QUIC connections.
 // TLS configuration must be provided on the Server for ListenAndServe to function properly.
 func (s *Server) ListenAndServe() error {
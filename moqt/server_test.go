@@ -9,7 +9,9 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -1976,3 +1978,66 @@ func TestServer_GoAway(t *testing.T) {
 		server.goAway()
 	})
 }
+
+func TestServer_HandleWebSocket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/broadcast", func(tw *TrackWriter) {
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		frame := NewFrame(0)
+		_, _ = frame.Write([]byte("hello"))
+		_ = gw.WriteFrame(frame)
+		_ = gw.Close()
+	})
+
+	paths := make(chan string, 1)
+	server := &Server{
+		SetupHandler: SetupHandlerFunc(func(w SetupResponseWriter, r *SetupRequest) {
+			paths <- r.Path
+			_, _ = Accept(w, r, mux)
+		}),
+	}
+	defer server.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = server.HandleWebSocket(w, r)
+	}))
+	defer srv.Close()
+
+	client := &Client{}
+
+	sess, err := client.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/room", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	assert.Equal(t, "/room", <-paths)
+
+	tr, err := sess.Subscribe("/broadcast", "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	acceptCtx, cancelAccept := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelAccept()
+
+	gr, err := tr.AcceptGroup(acceptCtx)
+	require.NoError(t, err)
+
+	frame := NewFrame(0)
+	require.NoError(t, gr.ReadFrame(frame))
+	assert.Equal(t, []byte("hello"), frame.Body())
+}
+
+func TestServer_HandleWebSocket_NotUpgrade(t *testing.T) {
+	server := &Server{}
+	defer server.Close()
+
+	rec := httptest.NewRecorder()
+	err := server.HandleWebSocket(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
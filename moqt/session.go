@@ -44,6 +44,14 @@ func newSession(
 		onClose:       onClose,
 	}
 
+	if m := sessStream.metrics; m != nil {
+		role := sessStream.role
+		m.SessionOpened(role)
+		context.AfterFunc(sess.ctx, func() {
+			m.SessionClosed(role, closeError(sess.ctx))
+		})
+	}
+
 	// Supervise the session stream closure
 	sessStreamCtx := sessStream.Context()
 	context.AfterFunc(sessStreamCtx, func() {
@@ -101,9 +109,52 @@ type Session struct {
 	isTerminating atomic.Bool
 	sessErr       error
 
+	received trackCounters
+	sent     trackCounters
+
 	onClose func() // Function to call when the session is closed
 }
 
+// metrics returns the Metrics of the session or nil.
+func (s *Session) metrics() Metrics {
+	if s.sessionStream == nil {
+		return nil
+	}
+	return s.sessionStream.metrics
+}
+
+// Stats returns the statistics of the session's subscriptions and
+// publications, and of its connection where available.
+func (s *Session) Stats() SessionStats {
+	stats := SessionStats{
+		Received:   s.received.snapshot(),
+		Sent:       s.sent.snapshot(),
+		Connection: connectionStats(s.conn),
+	}
+	stats.Received.LatestGroup = 0
+	stats.Sent.LatestGroup = 0
+
+	// Closing readers remove themselves from the map while locked,
+	// so they are queried after the map is unlocked
+	s.trackReaderMapLocker.RLock()
+	readers := make([]*TrackReader, 0, len(s.trackReaders))
+	for _, r := range s.trackReaders {
+		readers = append(readers, r)
+	}
+	s.trackReaderMapLocker.RUnlock()
+
+	stats.Subscriptions = len(readers)
+	for _, r := range readers {
+		stats.Received.QueuedGroups += r.Stats().QueuedGroups
+	}
+
+	s.trackWriterMapLocker.RLock()
+	stats.Publications = len(s.trackWriters)
+	s.trackWriterMapLocker.RUnlock()
+
+	return stats
+}
+
 func (s *Session) terminating() bool {
 	return s.isTerminating.Load()
 }
@@ -215,16 +266,20 @@ func (s *Session) Subscribe(path BroadcastPath, name TrackName, config *TrackCon
 		)
 		return nil, err
 	}
+	traceStreamType(stream, ownerLocal, message.StreamTypeSubscribe)
 
 	// Send a SUBSCRIBE message
 	sm := message.SubscribeMessage{
-		SubscribeID:   uint64(id),
-		BroadcastPath: string(path),
-		TrackName:     string(name),
-		TrackPriority: uint8(config.TrackPriority),
+		SubscribeID:     uint64(id),
+		BroadcastPath:   string(path),
+		TrackName:       string(name),
+		TrackPriority:   uint8(config.TrackPriority),
+		DeliveryTimeout: config.deliveryTimeoutMillis(),
+		StartGroup:      uint64(config.StartGroup),
 	}
 	err = sm.Encode(stream)
 	if err == nil {
+		traceMessageCreated(stream, sm)
 		streamLogger.Debug("sent SUBSCRIBE message",
 			"subscribe_id", id,
 			"path", path,
@@ -267,6 +322,7 @@ func (s *Session) Subscribe(path BroadcastPath, name TrackName, config *TrackCon
 	trackReceiver := newTrackReader(path, name, substr, func() {
 		s.removeTrackReader(id)
 	})
+	trackReceiver.stats.session = &s.received
 	s.addTrackReader(id, trackReceiver)
 
 	cleanup := func() {
@@ -276,6 +332,7 @@ func (s *Session) Subscribe(path BroadcastPath, name TrackName, config *TrackCon
 	var subok message.SubscribeOkMessage
 	err = subok.Decode(stream)
 	if err != nil {
+		err = checkMessageSize(stream, err)
 		cleanup()
 		var strErr *quic.StreamError
 		if errors.As(err, &strErr) {
@@ -301,6 +358,8 @@ func (s *Session) Subscribe(path BroadcastPath, name TrackName, config *TrackCon
 		// the normal lifecycle and lead to spurious EOFs on the server side.
 		return nil, err
 	} else {
+		traceMessageParsed(stream, subok)
+
 		// Successful receipt of SUBSCRIBE_OK. Log at Info level to aid debugging.
 		streamLogger.Debug("received SUBSCRIBE_OK for subscription",
 			"subscribe_id", sm.SubscribeID,
@@ -309,6 +368,10 @@ func (s *Session) Subscribe(path BroadcastPath, name TrackName, config *TrackCon
 		)
 	}
 
+	if m := s.metrics(); m != nil {
+		trackReceiver.instrument(m)
+	}
+
 	return trackReceiver, nil
 }
 
@@ -369,10 +432,12 @@ func (sess *Session) AcceptAnnounce(prefix string) (*AnnouncementReader, error)
 		)
 		return nil, err
 	}
+	traceStreamType(stream, ownerLocal, message.StreamTypeAnnounce)
 
-	err = message.AnnouncePleaseMessage{
+	apm := message.AnnouncePleaseMessage{
 		TrackPrefix: prefix,
-	}.Encode(stream)
+	}
+	err = apm.Encode(stream)
 	if err != nil {
 		streamLogger.Error("failed to send ANNOUNCE_PLEASE message",
 			"error", err,
@@ -400,10 +465,12 @@ func (sess *Session) AcceptAnnounce(prefix string) (*AnnouncementReader, error)
 
 		return nil, err
 	}
+	traceMessageCreated(stream, apm)
 
 	var aim message.AnnounceInitMessage
 	err = aim.Decode(stream)
 	if err != nil {
+		err = checkMessageSize(stream, err)
 		streamLogger.Error("failed to read ANNOUNCE_INIT message",
 			"error", err,
 		)
@@ -427,6 +494,7 @@ func (sess *Session) AcceptAnnounce(prefix string) (*AnnouncementReader, error)
 
 		return nil, err
 	}
+	traceMessageParsed(stream, aim)
 
 	return newAnnouncementReader(stream, prefix, aim.Suffixes), nil
 }
@@ -475,18 +543,29 @@ func (sess *Session) processBiStream(stream quic.Stream, streamLogger *slog.Logg
 		}
 		return
 	}
+	traceStreamType(stream, ownerRemote, streamType)
 
 	switch streamType {
 	case message.StreamTypeAnnounce:
 		var apm message.AnnouncePleaseMessage
 		err := apm.Decode(stream)
 		if err != nil {
+			err = checkMessageSize(stream, err)
 			streamLogger.Error("failed to decode ANNOUNCE_PLEASE message",
 				"error", err,
 			)
 			cancelStreamWithError(stream, quic.StreamErrorCode(InternalAnnounceErrorCode))
 			return
 		}
+		traceMessageParsed(stream, apm)
+
+		if len(apm.TrackPrefix) > maxPathLength(stream) {
+			streamLogger.Error("track prefix too long",
+				"length", len(apm.TrackPrefix),
+			)
+			cancelStreamWithError(stream, quic.StreamErrorCode(InvalidPrefixErrorCode))
+			return
+		}
 
 		prefix := apm.TrackPrefix
 
@@ -506,16 +585,30 @@ func (sess *Session) processBiStream(stream quic.Stream, streamLogger *slog.Logg
 		var sm message.SubscribeMessage
 		err := sm.Decode(stream)
 		if err != nil {
+			err = checkMessageSize(stream, err)
 			streamLogger.Error("failed to decode SUBSCRIBE message",
 				"error", err,
 			)
 			cancelStreamWithError(stream, quic.StreamErrorCode(InternalSubscribeErrorCode))
 			return
 		}
+		traceMessageParsed(stream, sm)
+
+		if limit := maxPathLength(stream); len(sm.BroadcastPath) > limit || len(sm.TrackName) > limit {
+			streamLogger.Error("broadcast path or track name too long",
+				"subscribe_id", sm.SubscribeID,
+				"path_length", len(sm.BroadcastPath),
+				"name_length", len(sm.TrackName),
+			)
+			cancelStreamWithError(stream, quic.StreamErrorCode(PathTooLongErrorCode))
+			return
+		}
 
 		// Create a receiveSubscribeStream
 		config := &TrackConfig{
-			TrackPriority: TrackPriority(sm.TrackPriority),
+			TrackPriority:   TrackPriority(sm.TrackPriority),
+			DeliveryTimeout: deliveryTimeoutFromMillis(sm.DeliveryTimeout),
+			StartGroup:      GroupSequence(sm.StartGroup),
 		}
 		// Create a subscription-specific logger
 		subLogger := streamLogger.With(
@@ -533,8 +626,13 @@ func (sess *Session) processBiStream(stream quic.Stream, streamLogger *slog.Logg
 			BroadcastPath(sm.BroadcastPath), TrackName(sm.TrackName),
 			substr, sess.conn.OpenUniStream, func() { sess.removeTrackWriter(SubscribeID(sm.SubscribeID)) },
 		)
+		track.stats.session = &sess.sent
 		sess.addTrackWriter(SubscribeID(sm.SubscribeID), track)
 
+		if m := sess.metrics(); m != nil {
+			track.instrument(m)
+		}
+
 		sess.mux.serveTrack(track)
 
 		// Ensure the track writer is closed when done
@@ -589,11 +687,13 @@ func (sess *Session) processUniStream(stream quic.ReceiveStream, streamLogger *s
 		var gm message.GroupMessage
 		err := gm.Decode(stream)
 		if err != nil {
+			err = checkMessageSize(stream, err)
 			streamLogger.Error("failed to decode group message",
 				"error", err,
 			)
 			return
 		}
+		traceGroupOpened(stream, ownerRemote, gm)
 
 		// Create a group-specific logger
 		groupLogger := streamLogger.With(
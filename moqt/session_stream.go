@@ -42,6 +42,10 @@ type sessionStream struct {
 	// Parameters specified by the server
 	ServerExtensions *Extension
 
+	// metrics receives the events of the session for the local role.
+	metrics Metrics
+	role    Role
+
 	listenOnce sync.Once
 }
 
@@ -62,8 +66,11 @@ func (r *response) AwaitAccepted() error {
 		var sum message.SessionServerMessage
 		err = sum.Decode(r.stream)
 		if err != nil {
+			err = checkMessageSize(r.stream, err)
 			return
 		}
+		traceMessageParsed(r.stream, sum)
+
 		r.Version = Version(sum.SelectedVersion)
 		r.ServerExtensions = &Extension{sum.Parameters}
 
@@ -112,13 +119,15 @@ func (w *responseWriter) accept(mux *TrackMux) (*Session, error) {
 		if w.ServerExtensions != nil {
 			params = w.ServerExtensions.parameters
 		}
-		err = message.SessionServerMessage{
+		ssm := message.SessionServerMessage{
 			SelectedVersion: uint64(w.Version),
 			Parameters:      params,
-		}.Encode(w.stream)
+		}
+		err = ssm.Encode(w.stream)
 		if err != nil {
 			return
 		}
+		traceMessageCreated(w.stream, ssm)
 
 		// Start listening for updates
 		w.handleUpdates()
@@ -145,12 +154,14 @@ func (ss *sessionStream) updateSession(bitrate uint64) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
-	err := message.SessionUpdateMessage{
+	sum := message.SessionUpdateMessage{
 		Bitrate: bitrate,
-	}.Encode(ss.stream)
+	}
+	err := sum.Encode(ss.stream)
 	if err != nil {
 		return Cause(ss.ctx)
 	}
+	traceMessageCreated(ss.stream, sum)
 
 	ss.localBitrate = bitrate
 
@@ -168,8 +179,10 @@ func (ss *sessionStream) handleUpdates() {
 			for {
 				err = sum.Decode(ss.stream)
 				if err != nil {
+					_ = checkMessageSize(ss.stream, err)
 					break
 				}
+				traceMessageParsed(ss.stream, sum)
 
 				ss.mu.Lock()
 				ss.remoteBitrate = sum.Bitrate
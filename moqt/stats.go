@@ -0,0 +1,116 @@
+package moqt
+
+import (
+	"sync/atomic"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// TrackStats is a snapshot of the groups and frames of a track.
+// Session.Stats reports the sum over the tracks of a session.
+type TrackStats struct {
+	// Groups is the number of groups received or opened.
+	Groups uint64
+
+	// Frames is the number of frames read or written.
+	Frames uint64
+
+	// Bytes is the number of frame payload bytes read or written.
+	Bytes uint64
+
+	// CanceledGroups is the number of groups that ended with an error,
+	// including the groups dropped from the queue of a TrackReader.
+	CanceledGroups uint64
+
+	// QueuedGroups is the number of groups received but not accepted yet.
+	// It is always zero for a TrackWriter.
+	QueuedGroups int
+
+	// LatestGroup is the latest group sequence received or opened,
+	// if Groups is not zero. It is not set for a session.
+	LatestGroup GroupSequence
+}
+
+// SessionStats is a snapshot of the activity of a session.
+type SessionStats struct {
+	// Subscriptions is the number of open TrackReaders.
+	Subscriptions int
+
+	// Publications is the number of open TrackWriters.
+	Publications int
+
+	// Received sums the groups and frames of the session's subscriptions,
+	// including the closed ones.
+	Received TrackStats
+
+	// Sent sums the groups and frames of the session's publications,
+	// including the closed ones.
+	Sent TrackStats
+
+	// Connection holds the RTT and loss of the connection, or nil if the
+	// QUIC implementation does not report them.
+	Connection *quic.ConnectionStats
+}
+
+// trackCounters counts the groups and frames of a track.
+// The counts are added to those of the session, if set.
+// A nil *trackCounters counts nothing.
+type trackCounters struct {
+	groups   atomic.Uint64
+	frames   atomic.Uint64
+	bytes    atomic.Uint64
+	canceled atomic.Uint64
+	latest   atomic.Uint64
+
+	session *trackCounters
+}
+
+func (c *trackCounters) addGroup(seq GroupSequence) {
+	if c == nil {
+		return
+	}
+	c.groups.Add(1)
+	for {
+		latest := c.latest.Load()
+		if uint64(seq) <= latest || c.latest.CompareAndSwap(latest, uint64(seq)) {
+			break
+		}
+	}
+	c.session.addGroup(seq)
+}
+
+func (c *trackCounters) addFrame(size int) {
+	if c == nil {
+		return
+	}
+	c.frames.Add(1)
+	c.bytes.Add(uint64(size))
+	c.session.addFrame(size)
+}
+
+// addBytes counts n payload bytes of a frame that is still being read.
+func (c *trackCounters) addBytes(n int) {
+	if c == nil {
+		return
+	}
+	c.bytes.Add(uint64(n))
+	c.session.addBytes(n)
+}
+
+func (c *trackCounters) addCanceled() {
+	if c == nil {
+		return
+	}
+	c.canceled.Add(1)
+	c.session.addCanceled()
+}
+
+func (c *trackCounters) snapshot() TrackStats {
+	return TrackStats{
+		Groups:         c.groups.Load(),
+		Frames:         c.frames.Load(),
+		Bytes:          c.bytes.Load(),
+		CanceledGroups: c.canceled.Load(),
+		LatestGroup:    GroupSequence(c.latest.Load()),
+	}
+}
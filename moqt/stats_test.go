@@ -0,0 +1,126 @@
+package moqt
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackCounters(t *testing.T) {
+	var session trackCounters
+	track := trackCounters{session: &session}
+
+	track.addGroup(3)
+	track.addGroup(2)
+	track.addFrame(10)
+	track.addFrame(5)
+	track.addCanceled()
+
+	assert.Equal(t, TrackStats{
+		Groups:         2,
+		Frames:         2,
+		Bytes:          15,
+		CanceledGroups: 1,
+		LatestGroup:    3,
+	}, track.snapshot())
+
+	stats := session.snapshot()
+	assert.Equal(t, uint64(2), stats.Groups)
+	assert.Equal(t, uint64(15), stats.Bytes)
+	assert.Equal(t, uint64(1), stats.CanceledGroups)
+
+	// A nil *trackCounters counts nothing
+	var nilCounters *trackCounters
+	nilCounters.addGroup(1)
+	nilCounters.addFrame(1)
+	nilCounters.addCanceled()
+}
+
+func TestStats(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		writerStats TrackStats
+		published   sync.WaitGroup
+	)
+	published.Add(1)
+
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		defer published.Done()
+
+		for range 2 {
+			gw, err := tw.OpenGroup()
+			if err != nil {
+				return
+			}
+			for range 3 {
+				frame := NewFrame(4)
+				_, _ = frame.Write([]byte("data"))
+				_ = gw.WriteFrame(frame)
+			}
+			_ = gw.Close()
+		}
+		writerStats = tw.Stats()
+	})
+
+	d := newMemoryDialer(t, mux)
+	client := &Client{DialQUICFunc: d.dial}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	tr, err := sess.Subscribe("/live", "video", nil)
+	require.NoError(t, err)
+	defer tr.Close()
+
+	frame := NewFrame(0)
+	for range 2 {
+		gr, err := tr.AcceptGroup(ctx)
+		require.NoError(t, err)
+		for {
+			err := gr.ReadFrame(frame)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+		}
+	}
+
+	published.Wait()
+	want := TrackStats{Groups: 2, Frames: 6, Bytes: 24, LatestGroup: 1}
+	assert.Equal(t, want, writerStats)
+	assert.Equal(t, want, tr.Stats())
+
+	stats := sess.Stats()
+	assert.Equal(t, 1, stats.Subscriptions)
+	assert.Zero(t, stats.Publications)
+	assert.Equal(t, TrackStats{Groups: 2, Frames: 6, Bytes: 24}, stats.Received)
+	assert.Zero(t, stats.Sent)
+	assert.Nil(t, stats.Connection, "in-memory connections have no RTT")
+}
+
+type statsConn struct {
+	quic.Connection
+}
+
+func (statsConn) ConnectionStats() quic.ConnectionStats {
+	return quic.ConnectionStats{SmoothedRTT: 20 * time.Millisecond, PacketsLost: 3}
+}
+
+func TestConnectionStats(t *testing.T) {
+	conn := newSessionConn(statsConn{}, nil, RoleClient)
+
+	stats := connectionStats(conn)
+	require.NotNil(t, stats)
+	assert.Equal(t, 20*time.Millisecond, stats.SmoothedRTT)
+	assert.Equal(t, uint64(3), stats.PacketsLost)
+}
@@ -2,14 +2,48 @@ package moqt
 
 import (
 	"fmt"
+	"math"
+	"time"
 )
 
 // TrackConfig holds subscription parameters for a track. It is used to
-// specify the delivery priority for the track.
+// specify the delivery priority, the delivery timeout and the first group
+// of the track.
 type TrackConfig struct {
 	TrackPriority TrackPriority
+
+	// DeliveryTimeout is how long the publisher delivers a group after
+	// opening it. Groups still open after it are canceled with
+	// ExpiredGroupErrorCode. It is sent with millisecond precision, and
+	// zero means no timeout.
+	DeliveryTimeout time.Duration
+
+	// StartGroup is the lowest group sequence the subscriber wants.
+	// The publisher's TrackWriter does not send the groups below it. It is
+	// only sent with the subscription, and updates do not change it.
+	StartGroup GroupSequence
 }
 
 func (sc TrackConfig) String() string {
-	return fmt.Sprintf("{ track_priority: %d }", sc.TrackPriority)
+	s := fmt.Sprintf("{ track_priority: %d", sc.TrackPriority)
+	if sc.DeliveryTimeout > 0 {
+		s += fmt.Sprintf(", delivery_timeout: %s", sc.DeliveryTimeout)
+	}
+	if sc.StartGroup > 0 {
+		s += fmt.Sprintf(", start_group: %d", sc.StartGroup)
+	}
+	return s + " }"
+}
+
+// deliveryTimeoutMillis returns the delivery timeout as sent on the wire.
+func (sc TrackConfig) deliveryTimeoutMillis() uint64 {
+	if sc.DeliveryTimeout <= 0 {
+		return 0
+	}
+	return uint64(sc.DeliveryTimeout / time.Millisecond)
+}
+
+// deliveryTimeoutFromMillis returns the delivery timeout received on the wire.
+func deliveryTimeoutFromMillis(ms uint64) time.Duration {
+	return time.Duration(min(ms, math.MaxInt64/uint64(time.Millisecond))) * time.Millisecond
 }
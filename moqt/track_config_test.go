@@ -1,7 +1,9 @@
 package moqt
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -65,6 +67,21 @@ func TestTrackConfig_String(t *testing.T) {
 	}
 }
 
+func TestTrackConfig_DeliveryTimeout(t *testing.T) {
+	config := TrackConfig{TrackPriority: 1, DeliveryTimeout: 1500 * time.Microsecond}
+	assert.Equal(t, "{ track_priority: 1, delivery_timeout: 1.5ms }", config.String())
+	assert.Equal(t, uint64(1), config.deliveryTimeoutMillis())
+
+	assert.Zero(t, TrackConfig{DeliveryTimeout: -time.Second}.deliveryTimeoutMillis())
+	assert.Equal(t, 250*time.Millisecond, deliveryTimeoutFromMillis(250))
+	assert.Positive(t, deliveryTimeoutFromMillis(math.MaxUint64), "large timeouts must not overflow")
+}
+
+func TestTrackConfig_StartGroup(t *testing.T) {
+	config := TrackConfig{TrackPriority: 1, DeliveryTimeout: time.Second, StartGroup: 42}
+	assert.Equal(t, "{ track_priority: 1, delivery_timeout: 1s, start_group: 42 }", config.String())
+}
+
 func TestTrackConfig_ZeroValue(t *testing.T) {
 	var config TrackConfig
 
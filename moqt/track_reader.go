@@ -14,12 +14,9 @@ func newTrackReader(broadcastPath BroadcastPath, trackName TrackName, subscribeS
 		TrackName:           trackName,
 		sendSubscribeStream: subscribeStream,
 		queuedCh:            make(chan struct{}, 1),
-		queueing: make([]struct {
-			sequence GroupSequence
-			stream   quic.ReceiveStream
-		}, 0, 1<<3),
-		dequeued:         make(map[*GroupReader]struct{}),
-		onCloseTrackFunc: onCloseTrackFunc,
+		queueing:            make([]queuedGroup, 0, 1<<3),
+		dequeued:            make(map[*GroupReader]struct{}),
+		onCloseTrackFunc:    onCloseTrackFunc,
 	}
 
 	return track
@@ -34,25 +31,150 @@ type TrackReader struct {
 
 	*sendSubscribeStream
 
-	queueing []struct {
-		sequence GroupSequence
-		stream   quic.ReceiveStream
-	}
-	queuedCh chan struct{}
-	trackMu  sync.Mutex
+	queueing    []queuedGroup
+	queuedCh    chan struct{}
+	trackMu     sync.Mutex
+	queuePolicy QueuePolicy
+	queueLimit  int
 
 	dequeued map[*GroupReader]struct{}
 
 	onCloseTrackFunc func()
+
+	stats   trackCounters
+	metrics Metrics
+}
+
+// queuedGroup is a group stream waiting to be accepted.
+type queuedGroup struct {
+	sequence GroupSequence
+	stream   quic.ReceiveStream
+}
+
+// QueuePolicy decides which groups a TrackReader keeps queued
+// when the application does not accept them fast enough.
+type QueuePolicy int
+
+const (
+	// QueueFIFO queues groups in the order they arrive.
+	// When the queue limit is reached, new groups are canceled.
+	// It is the default.
+	QueueFIFO QueuePolicy = iota
+
+	// QueueDropOldest queues groups in the order they arrive.
+	// When the queue limit is reached, the group queued first is canceled.
+	QueueDropOldest
+
+	// QueueSkipToLatest keeps only the group with the latest sequence.
+	// Queued groups are canceled when a later group arrives, and groups
+	// older than the queued one are canceled on arrival.
+	// The queue limit is ignored.
+	QueueSkipToLatest
+)
+
+// SetQueuePolicy sets how groups are queued until they are accepted,
+// and the maximum number of queued groups. A limit of zero or less
+// leaves the queue unbounded. Groups dropped from the queue are canceled
+// with DroppedGroupErrorCode, which also applies to the groups already
+// queued when the policy is set.
+func (r *TrackReader) SetQueuePolicy(policy QueuePolicy, limit int) {
+	r.trackMu.Lock()
+	defer r.trackMu.Unlock()
+
+	r.queuePolicy = policy
+	r.queueLimit = limit
+
+	if r.queueing == nil {
+		return
+	}
+
+	switch policy {
+	case QueueSkipToLatest:
+		if len(r.queueing) > 1 {
+			r.keepLatest()
+		}
+	case QueueDropOldest:
+		if limit > 0 && len(r.queueing) > limit {
+			r.cancelQueued(r.queueing[:len(r.queueing)-limit])
+			r.queueing = r.queueing[len(r.queueing)-limit:]
+		}
+	default:
+		if limit > 0 && len(r.queueing) > limit {
+			r.cancelQueued(r.queueing[limit:])
+			r.queueing = r.queueing[:limit]
+		}
+	}
+}
+
+// keepLatest cancels the queued groups except the one with the latest sequence.
+func (r *TrackReader) keepLatest() {
+	latest := 0
+	for i, entry := range r.queueing {
+		if entry.sequence > r.queueing[latest].sequence {
+			latest = i
+		}
+	}
+
+	entry := r.queueing[latest]
+	r.cancelQueued(r.queueing[:latest])
+	r.cancelQueued(r.queueing[latest+1:])
+	r.queueing = append(r.queueing[:0], entry)
+}
+
+func (r *TrackReader) cancelQueued(entries []queuedGroup) {
+	for _, entry := range entries {
+		r.dropGroup(entry.stream)
+	}
+}
+
+func (r *TrackReader) dropGroup(stream quic.ReceiveStream) {
+	stream.CancelRead(quic.StreamErrorCode(DroppedGroupErrorCode))
+	r.stats.addCanceled()
+}
+
+// Stats returns the statistics of the groups received on the track.
+func (r *TrackReader) Stats() TrackStats {
+	stats := r.stats.snapshot()
+
+	r.trackMu.Lock()
+	stats.QueuedGroups = len(r.queueing)
+	r.trackMu.Unlock()
+
+	return stats
+}
+
+// instrument reports the subscription and its groups to m.
+func (r *TrackReader) instrument(m Metrics) {
+	r.metrics = m
+
+	path := r.BroadcastPath
+	m.SubscriptionOpened(RoleSubscriber, path)
+
+	ctx := r.Context()
+	context.AfterFunc(ctx, func() {
+		m.SubscriptionClosed(RoleSubscriber, path, closeError(ctx))
+	})
 }
 
 // AcceptGroup blocks until the next group is available or context is
 // canceled. It returns a GroupReader tied to the accepted group stream.
 func (r *TrackReader) AcceptGroup(ctx context.Context) (*GroupReader, error) {
+	return r.acceptGroup(ctx, false)
+}
+
+// AcceptLatestGroup is like AcceptGroup, but returns the queued group with
+// the latest sequence and cancels the older queued groups with
+// DroppedGroupErrorCode. Live viewers use it to skip the groups they fell
+// behind on.
+func (r *TrackReader) AcceptLatestGroup(ctx context.Context) (*GroupReader, error) {
+	return r.acceptGroup(ctx, true)
+}
+
+func (r *TrackReader) acceptGroup(ctx context.Context, latest bool) (*GroupReader, error) {
 	trackCtx := r.Context()
 
 	for {
-		group := r.dequeueGroup()
+		group := r.dequeue(latest)
 		if group != nil {
 			r.addGroup(group)
 
@@ -63,20 +185,33 @@ func (r *TrackReader) AcceptGroup(ctx context.Context) (*GroupReader, error) {
 			return nil, Cause(trackCtx)
 		}
 
+		r.trackMu.Lock()
+		queuedCh := r.queuedCh
+		r.trackMu.Unlock()
+
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
 		case <-trackCtx.Done():
 			return nil, Cause(trackCtx)
-		case <-r.queuedCh:
+		case <-queuedCh:
 		}
 	}
 }
 
 func (r *TrackReader) dequeueGroup() *GroupReader {
+	return r.dequeue(false)
+}
+
+// dequeue dequeues the next group, or the latest one if latest is set.
+func (r *TrackReader) dequeue(latest bool) *GroupReader {
 	r.trackMu.Lock()
 	defer r.trackMu.Unlock()
 
+	if latest && len(r.queueing) > 1 {
+		r.keepLatest()
+	}
+
 	if len(r.queueing) > 0 {
 		next := r.queueing[0]
 
@@ -85,6 +220,10 @@ func (r *TrackReader) dequeueGroup() *GroupReader {
 		var group *GroupReader
 		group = newGroupReader(next.sequence, next.stream,
 			func() { r.removeGroup(group) })
+		group.stats = &r.stats
+		if r.metrics != nil {
+			group.instrument(r.metrics, r.BroadcastPath)
+		}
 
 		return group
 	}
@@ -176,14 +315,35 @@ func (r *TrackReader) enqueueGroup(sequence GroupSequence, stream quic.ReceiveSt
 		return
 	}
 
-	entry := struct {
-		sequence GroupSequence
-		stream   quic.ReceiveStream
-	}{
+	r.stats.addGroup(sequence)
+
+	switch r.queuePolicy {
+	case QueueSkipToLatest:
+		if len(r.queueing) > 0 {
+			if sequence <= r.queueing[len(r.queueing)-1].sequence {
+				r.dropGroup(stream)
+				return
+			}
+			r.cancelQueued(r.queueing)
+			r.queueing = r.queueing[:0]
+		}
+	case QueueDropOldest:
+		if r.queueLimit > 0 && len(r.queueing) >= r.queueLimit {
+			drop := len(r.queueing) - r.queueLimit + 1
+			r.cancelQueued(r.queueing[:drop])
+			r.queueing = r.queueing[drop:]
+		}
+	default:
+		if r.queueLimit > 0 && len(r.queueing) >= r.queueLimit {
+			r.dropGroup(stream)
+			return
+		}
+	}
+
+	r.queueing = append(r.queueing, queuedGroup{
 		sequence: sequence,
 		stream:   stream,
-	}
-	r.queueing = append(r.queueing, entry)
+	})
 
 	select {
 	case r.queuedCh <- struct{}{}:
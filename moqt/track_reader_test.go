@@ -2,11 +2,14 @@ package moqt
 
 import (
 	"context"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/okdaichi/gomoqt/quic"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewTrackReceiver(t *testing.T) {
@@ -157,3 +160,131 @@ func TestTrackReader_RemoveGroup(t *testing.T) {
 	receiver.removeGroup(group)
 	assert.NotContains(t, receiver.dequeued, group)
 }
+
+func TestTrackReader_QueuePolicy(t *testing.T) {
+	dropped := quic.StreamErrorCode(DroppedGroupErrorCode)
+
+	tests := map[string]struct {
+		policy    QueuePolicy
+		limit     int
+		sequences []GroupSequence
+		dropped   []GroupSequence
+		accepted  []GroupSequence
+	}{
+		"fifo unbounded": {
+			policy:    QueueFIFO,
+			sequences: []GroupSequence{1, 2, 3},
+			accepted:  []GroupSequence{1, 2, 3},
+		},
+		"fifo cancels new groups": {
+			policy:    QueueFIFO,
+			limit:     2,
+			sequences: []GroupSequence{1, 2, 3},
+			dropped:   []GroupSequence{3},
+			accepted:  []GroupSequence{1, 2},
+		},
+		"drop oldest": {
+			policy:    QueueDropOldest,
+			limit:     2,
+			sequences: []GroupSequence{1, 2, 3, 4},
+			dropped:   []GroupSequence{1, 2},
+			accepted:  []GroupSequence{3, 4},
+		},
+		"skip to latest": {
+			policy:    QueueSkipToLatest,
+			sequences: []GroupSequence{1, 3, 2},
+			dropped:   []GroupSequence{1, 2},
+			accepted:  []GroupSequence{3},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockStream := &MockQUICStream{}
+			mockStream.On("Context").Return(context.Background())
+			substr := newSendSubscribeStream(SubscribeID(1), mockStream, &TrackConfig{}, Info{})
+			receiver := newTrackReader("broadcastPath", "trackName", substr, func() {})
+			receiver.SetQueuePolicy(tt.policy, tt.limit)
+
+			streams := make(map[GroupSequence]*MockQUICReceiveStream)
+			for _, seq := range tt.sequences {
+				stream := &MockQUICReceiveStream{}
+				if slices.Contains(tt.dropped, seq) {
+					stream.On("CancelRead", dropped).Return()
+				}
+				streams[seq] = stream
+				receiver.enqueueGroup(seq, stream)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			for _, seq := range tt.accepted {
+				group, err := receiver.AcceptGroup(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, seq, group.GroupSequence())
+			}
+
+			_, err := receiver.AcceptGroup(ctx)
+			assert.ErrorIs(t, err, context.DeadlineExceeded, "no more groups should be queued")
+
+			for _, stream := range streams {
+				stream.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestTrackReader_SetQueuePolicy_TrimsQueue(t *testing.T) {
+	mockStream := &MockQUICStream{}
+	mockStream.On("Context").Return(context.Background())
+	substr := newSendSubscribeStream(SubscribeID(1), mockStream, &TrackConfig{}, Info{})
+	receiver := newTrackReader("broadcastPath", "trackName", substr, func() {})
+
+	oldest := &MockQUICReceiveStream{}
+	oldest.On("CancelRead", quic.StreamErrorCode(DroppedGroupErrorCode)).Return()
+	receiver.enqueueGroup(1, oldest)
+	receiver.enqueueGroup(2, &MockQUICReceiveStream{})
+
+	receiver.SetQueuePolicy(QueueDropOldest, 1)
+	oldest.AssertExpectations(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	group, err := receiver.AcceptGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(2), group.GroupSequence())
+}
+
+func TestTrackReader_AcceptLatestGroup(t *testing.T) {
+	mockStream := &MockQUICStream{}
+	mockStream.On("Context").Return(context.Background())
+	substr := newSendSubscribeStream(SubscribeID(1), mockStream, &TrackConfig{}, Info{})
+	receiver := newTrackReader("broadcastPath", "trackName", substr, func() {})
+
+	stale := make([]*MockQUICReceiveStream, 0, 2)
+	for _, seq := range []GroupSequence{1, 3} {
+		stream := &MockQUICReceiveStream{}
+		stream.On("CancelRead", quic.StreamErrorCode(DroppedGroupErrorCode)).Return()
+		stale = append(stale, stream)
+		receiver.enqueueGroup(seq, stream)
+	}
+	receiver.enqueueGroup(5, &MockQUICReceiveStream{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	group, err := receiver.AcceptLatestGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(5), group.GroupSequence())
+	for _, stream := range stale {
+		stream.AssertExpectations(t)
+	}
+
+	// It waits for the next group like AcceptGroup
+	go receiver.enqueueGroup(6, &MockQUICReceiveStream{})
+	group, err = receiver.AcceptLatestGroup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, GroupSequence(6), group.GroupSequence())
+}
@@ -1,6 +1,7 @@
 package moqt
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"sync"
@@ -24,6 +25,10 @@ func newTrackWriter(path BroadcastPath, name TrackName,
 		onCloseTrackFunc:       onCloseTrackFunc,
 	}
 
+	if subscribeStream != nil {
+		subscribeStream.setUpdateFunc(track.updateDeliveryTimeout)
+	}
+
 	return track
 }
 
@@ -52,6 +57,22 @@ type TrackWriter struct {
 	openUniStreamFunc func() (quic.SendStream, error)
 
 	onCloseTrackFunc func()
+
+	stats   trackCounters
+	metrics Metrics
+}
+
+// instrument reports the subscription and its groups to m.
+func (s *TrackWriter) instrument(m Metrics) {
+	s.metrics = m
+
+	path := s.BroadcastPath
+	m.SubscriptionOpened(RolePublisher, path)
+
+	ctx := s.receiveSubscribeStream.Context()
+	context.AfterFunc(ctx, func() {
+		m.SubscriptionClosed(RolePublisher, path, closeError(ctx))
+	})
 }
 
 // Close stops publishing and cancels active groups.
@@ -128,7 +149,7 @@ func (s *TrackWriter) CloseWithError(code SubscribeErrorCode) {
 
 // OpenGroup opens a new group with an automatically incremented sequence number.
 // It delegates to OpenGroupAt for the actual group creation.
-// The sequence now starts at 0 and increments by 1 for each call.
+// The sequence starts at 0 and increments by 1 for each call.
 //
 // This is a convenience method for callers that want to append groups at the
 // next available sequence without managing sequences themselves.
@@ -140,6 +161,9 @@ func (s *TrackWriter) OpenGroup() (*GroupWriter, error) {
 }
 
 // OpenGroupAt is the implementation for opening a group with a specific sequence.
+// A group below the StartGroup of the subscription is not sent: its frames
+// are discarded, so publishers numbering their own groups need not know
+// where each subscriber starts.
 func (s *TrackWriter) OpenGroupAt(seq GroupSequence) (*GroupWriter, error) {
 	// First, ensure the internal groupSequence is updated to avoid collisions.
 	// We advance the internal *next* counter to at least seq+1 so that
@@ -174,6 +198,10 @@ func (s *TrackWriter) OpenGroupAt(seq GroupSequence) (*GroupWriter, error) {
 		return nil, Cause(s.Context())
 	}
 
+	if seq < s.TrackConfig().StartGroup {
+		return newGroupWriter(newDiscardStream(s.Context()), seq, func() {}), nil
+	}
+
 	// Write the INFO message to the receive subscribe stream.
 	err := s.WriteInfo(Info{})
 	if err != nil {
@@ -205,10 +233,11 @@ func (s *TrackWriter) OpenGroupAt(seq GroupSequence) (*GroupWriter, error) {
 		return nil, err
 	}
 
-	err = message.GroupMessage{
+	gm := message.GroupMessage{
 		SubscribeID:   uint64(s.subscribeID),
 		GroupSequence: uint64(seq),
-	}.Encode(stream)
+	}
+	err = gm.Encode(stream)
 	if err != nil {
 		var strErr *quic.StreamError
 		if errors.As(err, &strErr) {
@@ -221,13 +250,38 @@ func (s *TrackWriter) OpenGroupAt(seq GroupSequence) (*GroupWriter, error) {
 		return nil, err
 	}
 
+	traceGroupOpened(stream, ownerLocal, gm)
+
 	var group *GroupWriter
 	group = newGroupWriter(stream, seq, func() { s.removeGroup(group) })
+	group.stats = &s.stats
+	s.stats.addGroup(seq)
 	s.addGroup(group)
+	group.setDeliveryTimeout(s.TrackConfig().DeliveryTimeout)
+
+	if s.metrics != nil {
+		group.instrument(s.metrics, s.BroadcastPath)
+	}
 
 	return group, nil
 }
 
+// Stats returns the statistics of the groups written to the track.
+func (s *TrackWriter) Stats() TrackStats {
+	return s.stats.snapshot()
+}
+
+// updateDeliveryTimeout applies the delivery timeout of config
+// to the active groups.
+func (s *TrackWriter) updateDeliveryTimeout(config *TrackConfig) {
+	s.groupMapMu.Lock()
+	defer s.groupMapMu.Unlock()
+
+	for group := range s.activeGroups {
+		group.setDeliveryTimeout(config.DeliveryTimeout)
+	}
+}
+
 func (s *TrackWriter) addGroup(group *GroupWriter) {
 	s.groupMapMu.Lock()
 	defer s.groupMapMu.Unlock()
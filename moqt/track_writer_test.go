@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"io"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/okdaichi/gomoqt/quic"
 	"github.com/stretchr/testify/assert"
@@ -594,3 +596,137 @@ func TestTrackWriter_OpenGroup_AutoIncrement(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, GroupSequence(2), group3.GroupSequence())
 }
+
+func TestTrackWriter_DeliveryTimeout(t *testing.T) {
+	tests := map[string]struct {
+		subscribe *TrackConfig
+		update    *TrackConfig
+	}{
+		"subscribe": {
+			subscribe: &TrackConfig{DeliveryTimeout: 50 * time.Millisecond},
+		},
+		"subscribe update": {
+			update: &TrackConfig{DeliveryTimeout: 20 * time.Millisecond},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			mux := NewTrackMux()
+			mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+				gw, err := tw.OpenGroup()
+				if err != nil {
+					return
+				}
+				frame := NewFrame(4)
+				_, _ = frame.Write([]byte("data"))
+				_ = gw.WriteFrame(frame)
+
+				// Keep the group open until the subscription ends
+				<-tw.Context().Done()
+			})
+
+			d := newMemoryDialer(t, mux)
+			client := &Client{DialQUICFunc: d.dial}
+
+			sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+			require.NoError(t, err)
+			defer sess.CloseWithError(NoError, "")
+
+			tr, err := sess.Subscribe("/live", "video", tt.subscribe)
+			require.NoError(t, err)
+			defer tr.Close()
+
+			gr, err := tr.AcceptGroup(ctx)
+			require.NoError(t, err)
+
+			frame := NewFrame(0)
+			require.NoError(t, gr.ReadFrame(frame))
+
+			if tt.update != nil {
+				require.NoError(t, tr.Update(tt.update))
+			}
+
+			err = gr.ReadFrame(frame)
+			var grpErr *GroupError
+			require.ErrorAs(t, err, &grpErr)
+			assert.Equal(t, ExpiredGroupErrorCode, grpErr.GroupErrorCode())
+		})
+	}
+}
+
+func TestTrackWriter_StartGroup(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The publisher numbers its groups without knowing the start group
+	opened := make(chan []GroupSequence, 1)
+	mux := NewTrackMux()
+	mux.PublishFunc(ctx, "/live", func(tw *TrackWriter) {
+		var seqs []GroupSequence
+		open := func(seq GroupSequence) (*GroupWriter, error) {
+			if seq == 0 {
+				return tw.OpenGroup()
+			}
+			return tw.OpenGroupAt(seq)
+		}
+		for _, seq := range []GroupSequence{0, 41, 42, 43} {
+			gw, err := open(seq)
+			if err != nil {
+				return
+			}
+			frame := NewFrame(0)
+			_, _ = frame.Write([]byte("data"))
+			if err := gw.WriteFrame(frame); err != nil {
+				return
+			}
+			_ = gw.Close()
+			seqs = append(seqs, gw.GroupSequence())
+		}
+
+		gw, err := tw.OpenGroup()
+		if err != nil {
+			return
+		}
+		_ = gw.Close()
+		opened <- append(seqs, gw.GroupSequence())
+
+		<-tw.Context().Done()
+	})
+
+	d := newMemoryDialer(t, mux)
+	client := &Client{DialQUICFunc: d.dial}
+
+	sess, err := client.Dial(ctx, "moqt://memory:0/", nil)
+	require.NoError(t, err)
+	defer sess.CloseWithError(NoError, "")
+
+	tr, err := sess.Subscribe("/live", "video", &TrackConfig{StartGroup: 42})
+	require.NoError(t, err)
+	defer tr.Close()
+
+	// Updates keep the start group
+	require.NoError(t, tr.Update(&TrackConfig{TrackPriority: 1}))
+	assert.Equal(t, GroupSequence(42), tr.TrackConfig().StartGroup)
+
+	select {
+	case seqs := <-opened:
+		assert.Equal(t, []GroupSequence{0, 41, 42, 43, 44}, seqs)
+	case <-ctx.Done():
+		t.Fatal("no group was opened")
+	}
+
+	// Only the groups from the start group are sent
+	var received []GroupSequence
+	for range 3 {
+		gr, err := tr.AcceptGroup(ctx)
+		require.NoError(t, err)
+		received = append(received, gr.GroupSequence())
+	}
+	slices.Sort(received)
+	assert.Equal(t, []GroupSequence{42, 43, 44}, received)
+	assert.Equal(t, uint64(3), tr.Stats().Groups)
+}
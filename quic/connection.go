@@ -47,3 +47,7 @@ type Connection interface {
 
 // ConnectionState holds information about the QUIC connection state.
 type ConnectionState = quic.ConnectionState
+
+// ConnectionStats holds the RTT, byte and packet loss statistics of a QUIC connection.
+// Connections that report them implement a ConnectionStats() ConnectionStats method.
+type ConnectionStats = quic.ConnectionStats
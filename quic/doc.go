@@ -16,8 +16,11 @@
 //
 // # Implementations
 //
-// The package includes a concrete implementation using quic-go:
+// The package includes the following implementations:
 //   - quicgo subpackage: Wraps github.com/quic-go/quic-go types
+//   - quicmem subpackage: In-memory connection pairs without network or TLS
+//   - quicmux subpackage: Streams multiplexed over TLS/TCP or WebSocket
+//   - quicimpair subpackage: Wraps a connection to simulate an impaired network
 //
 // # Basic Usage
 //
@@ -48,6 +48,11 @@ func (wrapper *connWrapper) ConnectionState() quic.ConnectionState {
 	}
 }
 
+// ConnectionStats returns the RTT and loss statistics of the connection.
+func (wrapper *connWrapper) ConnectionStats() quic.ConnectionStats {
+	return wrapper.conn.ConnectionStats()
+}
+
 func (wrapper *connWrapper) Context() context.Context {
 	return wrapper.conn.Context()
 }
@@ -0,0 +1,41 @@
+package quicimpair
+
+import (
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// Config describes the impairments applied to the data sent on a connection.
+// The zero value applies no impairment.
+type Config struct {
+	// Latency delays the delivery of every write.
+	Latency time.Duration
+
+	// Jitter adds a random delay between 0 and Jitter to the latency.
+	// Data on a stream is never reordered, so jitter delays later writes
+	// on the same stream as well.
+	Jitter time.Duration
+
+	// Bandwidth caps the rate of the data sent on the connection in bytes
+	// per second. Writes block while the link is busy. 0 means no cap.
+	Bandwidth int64
+
+	// ResetProbability is the probability that a write resets its stream
+	// with ResetErrorCode instead of sending data.
+	ResetProbability float64
+
+	// ResetErrorCode is the error code of the injected stream resets.
+	ResetErrorCode quic.StreamErrorCode
+
+	// DropAfter drops the connection after the duration. 0 means never.
+	DropAfter time.Duration
+
+	// DropErrorCode is the error code the connection is closed with when dropped.
+	DropErrorCode quic.ApplicationErrorCode
+
+	// Seed seeds the random jitter and resets so that runs are reproducible.
+	// Connections using the same seed make the same random choices
+	// for the same sequence of writes.
+	Seed uint64
+}
@@ -0,0 +1,201 @@
+package quicimpair
+
+import (
+	"context"
+	"crypto/tls"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// dropMessage is the reason a dropped connection is closed with.
+const dropMessage = "connection dropped"
+
+var _ quic.Connection = (*Conn)(nil)
+
+// Conn is a quic.Connection impairing the data sent on its streams.
+type Conn struct {
+	quic.Connection
+
+	config Config
+
+	mu  sync.Mutex
+	rng *rand.Rand
+
+	// linkFree is the time the link finishes sending the data written so far.
+	linkFree time.Time
+
+	dropTimer *time.Timer
+}
+
+// Wrap returns conn with the impairments in config applied to the data it sends.
+// Wrap both ends of a connection to impair both directions.
+func Wrap(conn quic.Connection, config Config) *Conn {
+	c := &Conn{
+		Connection: conn,
+		config:     config,
+		rng:        rand.New(rand.NewPCG(config.Seed, config.Seed)),
+	}
+
+	if config.DropAfter > 0 {
+		c.dropTimer = time.AfterFunc(config.DropAfter, c.Drop)
+		context.AfterFunc(conn.Context(), func() { c.dropTimer.Stop() })
+	}
+
+	return c
+}
+
+// WrapDialAddr returns a quic.DialAddrFunc wrapping the connections dialed by dial.
+func WrapDialAddr(dial quic.DialAddrFunc, config Config) quic.DialAddrFunc {
+	return func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+		conn, err := dial(ctx, addr, tlsConfig, quicConfig)
+		if err != nil {
+			return nil, err
+		}
+		return Wrap(conn, config), nil
+	}
+}
+
+// WrapListener returns a quic.Listener wrapping the connections accepted by ln.
+func WrapListener(ln quic.Listener, config Config) quic.Listener {
+	return &listener{Listener: ln, config: config}
+}
+
+// Drop closes the connection with Config.DropErrorCode as if it was lost.
+func (c *Conn) Drop() {
+	_ = c.Connection.CloseWithError(c.config.DropErrorCode, dropMessage)
+}
+
+// shouldReset reports whether the next write resets its stream.
+func (c *Conn) shouldReset() bool {
+	if c.config.ResetProbability <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.rng.Float64() < c.config.ResetProbability
+}
+
+// reserve waits until the link can send n bytes and returns the time
+// the bytes are delivered to the peer.
+func (c *Conn) reserve(ctx context.Context, n int, deadline time.Time) (time.Time, error) {
+	c.mu.Lock()
+
+	now := time.Now()
+	start := now
+	if c.linkFree.After(start) {
+		start = c.linkFree
+	}
+
+	if !deadline.IsZero() && start.After(deadline) {
+		c.mu.Unlock()
+		if err := sleepUntil(ctx, deadline); err != nil {
+			return time.Time{}, err
+		}
+		return time.Time{}, os.ErrDeadlineExceeded
+	}
+
+	var transmission time.Duration
+	if c.config.Bandwidth > 0 {
+		transmission = time.Duration(int64(n) * int64(time.Second) / c.config.Bandwidth)
+	}
+	c.linkFree = start.Add(transmission)
+
+	delay := c.config.Latency
+	if c.config.Jitter > 0 {
+		delay += time.Duration(c.rng.Int64N(int64(c.config.Jitter)))
+	}
+
+	c.mu.Unlock()
+
+	if err := sleepUntil(ctx, start); err != nil {
+		return time.Time{}, err
+	}
+
+	return start.Add(transmission + delay), nil
+}
+
+func (c *Conn) wrapStream(str quic.Stream) quic.Stream {
+	return &stream{
+		sendStream: newSendStream(c, str),
+		recv:       str,
+	}
+}
+
+func (c *Conn) OpenStream() (quic.Stream, error) {
+	str, err := c.Connection.OpenStream()
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapStream(str), nil
+}
+
+func (c *Conn) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	str, err := c.Connection.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapStream(str), nil
+}
+
+func (c *Conn) OpenUniStream() (quic.SendStream, error) {
+	str, err := c.Connection.OpenUniStream()
+	if err != nil {
+		return nil, err
+	}
+	return newSendStream(c, str), nil
+}
+
+func (c *Conn) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	str, err := c.Connection.OpenUniStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newSendStream(c, str), nil
+}
+
+func (c *Conn) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	str, err := c.Connection.AcceptStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.wrapStream(str), nil
+}
+
+// sleepUntil waits until t or until ctx is canceled.
+func sleepUntil(ctx context.Context, t time.Time) error {
+	d := time.Until(t)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+var _ quic.Listener = (*listener)(nil)
+
+type listener struct {
+	quic.Listener
+	config Config
+}
+
+func (l *listener) Accept(ctx context.Context) (quic.Connection, error) {
+	conn, err := l.Listener.Accept(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(conn, l.config), nil
+}
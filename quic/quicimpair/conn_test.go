@@ -0,0 +1,189 @@
+package quicimpair
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/okdaichi/gomoqt/quic/quicmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pipe(t *testing.T, config Config) (client *Conn, server quic.Connection) {
+	c, server := quicmem.Pipe("moq-00")
+	client = Wrap(c, config)
+	t.Cleanup(func() {
+		_ = client.CloseWithError(0, "")
+	})
+	return client, server
+}
+
+// send writes data on a new unidirectional stream and returns the data
+// received by the peer with the time it took.
+func send(t *testing.T, client *Conn, server quic.Connection, data []byte) ([]byte, time.Duration) {
+	t.Helper()
+
+	start := time.Now()
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+	go func() {
+		_, _ = cs.Write(data)
+		_ = cs.Close()
+	}()
+
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+	b, err := io.ReadAll(ss)
+	require.NoError(t, err)
+
+	return b, time.Since(start)
+}
+
+func TestConn_NoImpairment(t *testing.T) {
+	client, server := pipe(t, Config{})
+
+	data := bytes.Repeat([]byte("x"), 100<<10)
+	b, _ := send(t, client, server, data)
+	assert.Equal(t, data, b)
+}
+
+func TestConn_Latency(t *testing.T) {
+	client, server := pipe(t, Config{Latency: 100 * time.Millisecond, Jitter: 20 * time.Millisecond})
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+
+	start := time.Now()
+	for _, s := range []string{"a", "b", "c"} {
+		_, err := cs.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	// Writes do not wait for the delivery
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	require.NoError(t, cs.Close())
+
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+	b, err := io.ReadAll(ss)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("abc"), b)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestConn_Bandwidth(t *testing.T) {
+	client, server := pipe(t, Config{Bandwidth: 1 << 20})
+
+	data := bytes.Repeat([]byte("x"), 256<<10)
+	b, elapsed := send(t, client, server, data)
+
+	assert.Equal(t, data, b)
+	assert.GreaterOrEqual(t, elapsed, 240*time.Millisecond)
+}
+
+func TestConn_WriteDeadline(t *testing.T) {
+	client, _ := pipe(t, Config{Bandwidth: 64 << 10})
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+
+	require.NoError(t, cs.SetWriteDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = cs.Write(make([]byte, 64<<10))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func TestConn_Reset(t *testing.T) {
+	client, server := pipe(t, Config{ResetProbability: 1, ResetErrorCode: 5})
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+
+	var strErr *quic.StreamError
+	_, err = cs.Write([]byte("data"))
+	require.ErrorAs(t, err, &strErr)
+	assert.Equal(t, quic.StreamErrorCode(5), strErr.ErrorCode)
+	assert.False(t, strErr.Remote)
+
+	ss, err := server.AcceptStream(context.Background())
+	require.NoError(t, err)
+	_, err = ss.Read(make([]byte, 1))
+	require.ErrorAs(t, err, &strErr)
+	assert.True(t, strErr.Remote)
+	assert.Equal(t, quic.StreamErrorCode(5), strErr.ErrorCode)
+
+	assert.Error(t, cs.Close())
+}
+
+func TestConn_ResetIsReproducible(t *testing.T) {
+	resets := func(seed uint64) []bool {
+		client, _ := pipe(t, Config{ResetProbability: 0.5, Seed: seed})
+
+		var results []bool
+		for range 32 {
+			cs, err := client.OpenUniStream()
+			require.NoError(t, err)
+			_, err = cs.Write([]byte("x"))
+			results = append(results, err != nil)
+		}
+		return results
+	}
+
+	first := resets(1)
+	assert.Equal(t, first, resets(1))
+	assert.NotEqual(t, first, resets(2))
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+}
+
+func TestConn_CancelWriteDiscardsQueuedData(t *testing.T) {
+	client, server := pipe(t, Config{Latency: 50 * time.Millisecond})
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+	_, err = cs.Write([]byte("data"))
+	require.NoError(t, err)
+
+	cs.CancelWrite(7)
+
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	var strErr *quic.StreamError
+	_, err = ss.Read(make([]byte, 4))
+	require.ErrorAs(t, err, &strErr)
+	assert.Equal(t, quic.StreamErrorCode(7), strErr.ErrorCode)
+}
+
+func TestConn_DropAfter(t *testing.T) {
+	client, server := pipe(t, Config{DropAfter: 50 * time.Millisecond, DropErrorCode: 9})
+
+	select {
+	case <-server.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection was not dropped")
+	}
+
+	var appErr *quic.ApplicationError
+	require.ErrorAs(t, context.Cause(server.Context()), &appErr)
+	assert.Equal(t, quic.ApplicationErrorCode(9), appErr.ErrorCode)
+	require.ErrorAs(t, context.Cause(client.Context()), &appErr)
+	assert.False(t, appErr.Remote)
+}
+
+func TestWrapDialAddr(t *testing.T) {
+	c, _ := quicmem.Pipe("moq-00")
+	dial := WrapDialAddr(func(context.Context, string, *tls.Config, *quic.Config) (quic.Connection, error) {
+		return c, nil
+	}, Config{})
+
+	conn, err := dial(context.Background(), "memory", nil, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &Conn{}, conn)
+}
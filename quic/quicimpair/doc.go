@@ -0,0 +1,18 @@
+// Package quicimpair simulates an impaired network on top of any quic.Connection.
+//
+// Wrap injects latency, jitter, a bandwidth cap, stream resets and connection
+// drops into the data a connection sends, so that adaptive logic and timeouts
+// can be tested against the in-memory and the quic-go backends alike.
+// Random choices are seeded with Config.Seed to make test runs reproducible.
+/*
+	client, server := quicmem.Pipe(moqt.NextProtoMOQ)
+	impaired := quicimpair.Wrap(client, quicimpair.Config{
+	    Latency:   50 * time.Millisecond,
+	    Jitter:    10 * time.Millisecond,
+	    Bandwidth: 1 << 20,
+	    Seed:      1,
+	})
+*/
+// WrapDialAddr and WrapListener impair the connections of a moqt.Client or
+// a moqt.Server.
+package quicimpair
@@ -0,0 +1,245 @@
+package quicimpair
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// chunkSize is the largest amount of data sent at once,
+// so that the bandwidth is shared fairly between streams.
+const chunkSize = 16 << 10
+
+func errWriteOnClosedStream(id quic.StreamID) error {
+	return fmt.Errorf("write on closed stream %d", id)
+}
+
+func errCloseCanceledStream(id quic.StreamID) error {
+	return fmt.Errorf("close called for canceled stream %d", id)
+}
+
+// chunk is data waiting to be delivered. A chunk without data ends the stream.
+type chunk struct {
+	data []byte
+	fin  bool
+	at   time.Time
+}
+
+var _ quic.SendStream = (*sendStream)(nil)
+
+// sendStream delays the data written to a stream. Written data is queued
+// and a goroutine writes it to the underlying stream once it is due.
+type sendStream struct {
+	quic.SendStream
+	conn *Conn
+
+	mu     sync.Mutex
+	notify chan struct{}
+	queue  []chunk
+
+	// last is the delivery time of the last queued chunk.
+	last time.Time
+
+	closed    bool
+	resetCode *quic.StreamErrorCode
+	err       error
+
+	deadline time.Time
+
+	start sync.Once
+}
+
+func newSendStream(c *Conn, str quic.SendStream) *sendStream {
+	return &sendStream{
+		SendStream: str,
+		conn:       c,
+		notify:     make(chan struct{}, 1),
+	}
+}
+
+// writeErr returns the error a write fails with. The caller must hold s.mu.
+func (s *sendStream) writeErr() error {
+	if s.resetCode != nil {
+		return &quic.StreamError{StreamID: s.StreamID(), ErrorCode: *s.resetCode, Remote: false}
+	}
+	if s.closed {
+		return errWriteOnClosedStream(s.StreamID())
+	}
+	return s.err
+}
+
+func (s *sendStream) Write(b []byte) (int, error) {
+	var n int
+	for len(b) > 0 {
+		s.mu.Lock()
+		err := s.writeErr()
+		deadline := s.deadline
+		s.mu.Unlock()
+		if err != nil {
+			return n, err
+		}
+
+		if s.conn.shouldReset() {
+			code := s.conn.config.ResetErrorCode
+			s.CancelWrite(code)
+			return n, &quic.StreamError{StreamID: s.StreamID(), ErrorCode: code, Remote: false}
+		}
+
+		m := min(len(b), chunkSize)
+		at, err := s.conn.reserve(s.Context(), m, deadline)
+		if err != nil {
+			return n, err
+		}
+
+		s.enqueue(chunk{data: append([]byte(nil), b[:m]...), at: at})
+
+		b = b[m:]
+		n += m
+	}
+
+	return n, nil
+}
+
+// enqueue queues c after the chunks queued before, so that data is never reordered.
+func (s *sendStream) enqueue(c chunk) {
+	s.mu.Lock()
+	if c.at.Before(s.last) {
+		c.at = s.last
+	}
+	s.last = c.at
+	s.queue = append(s.queue, c)
+	s.mu.Unlock()
+
+	s.start.Do(func() { go s.deliver() })
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// deliver writes the queued chunks to the underlying stream once they are due.
+func (s *sendStream) deliver() {
+	ctx := s.SendStream.Context()
+
+	for {
+		s.mu.Lock()
+		if s.resetCode != nil {
+			s.mu.Unlock()
+			return
+		}
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-s.notify:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		c := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if err := sleepUntil(ctx, c.at); err != nil {
+			return
+		}
+
+		// The stream may have been reset while waiting
+		s.mu.Lock()
+		reset := s.resetCode != nil
+		s.mu.Unlock()
+		if reset {
+			return
+		}
+
+		if c.fin {
+			_ = s.SendStream.Close()
+			return
+		}
+
+		if _, err := s.SendStream.Write(c.data); err != nil {
+			s.mu.Lock()
+			s.err = err
+			s.queue = nil
+			s.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Close ends the stream once the queued data is delivered.
+func (s *sendStream) Close() error {
+	s.mu.Lock()
+	if s.resetCode != nil {
+		s.mu.Unlock()
+		return errCloseCanceledStream(s.StreamID())
+	}
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	s.enqueue(chunk{fin: true, at: time.Now()})
+
+	return nil
+}
+
+// CancelWrite resets the stream immediately and discards the queued data.
+func (s *sendStream) CancelWrite(code quic.StreamErrorCode) {
+	s.mu.Lock()
+	if s.resetCode != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.resetCode = &code
+	s.queue = nil
+	s.mu.Unlock()
+
+	s.SendStream.CancelWrite(code)
+}
+
+// SetWriteDeadline sets the deadline for waiting for the link.
+// Queued data is delivered regardless of the deadline.
+func (s *sendStream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = t
+
+	return nil
+}
+
+func (s *sendStream) Context() context.Context {
+	return s.SendStream.Context()
+}
+
+var _ quic.Stream = (*stream)(nil)
+
+// stream is a bidirectional stream whose send direction is impaired.
+type stream struct {
+	*sendStream
+	recv quic.Stream
+}
+
+func (s *stream) Read(b []byte) (int, error) {
+	return s.recv.Read(b)
+}
+
+func (s *stream) CancelRead(code quic.StreamErrorCode) {
+	s.recv.CancelRead(code)
+}
+
+func (s *stream) SetReadDeadline(t time.Time) error {
+	return s.recv.SetReadDeadline(t)
+}
+
+func (s *stream) SetDeadline(t time.Time) error {
+	_ = s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
@@ -0,0 +1,246 @@
+package quicmem
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// Pipe returns a connected pair of in-memory connections.
+// Both report nextProto as the negotiated application protocol, so the
+// server side can be passed to a server dispatching on ALPN.
+//
+// Streams are reliable and ordered and buffer up to 1 MiB of unread data
+// before writes block. Stream cancellation, deadlines and closing the
+// connection behave as they do over QUIC.
+func Pipe(nextProto string) (client, server quic.Connection) {
+	c := newConn(nextProto, Addr("client"), Addr("server"), true)
+	s := newConn(nextProto, Addr("server"), Addr("client"), false)
+	c.peer, s.peer = s, c
+
+	closeOnce := &sync.Once{}
+	c.closeOnce, s.closeOnce = closeOnce, closeOnce
+
+	return c, s
+}
+
+// Addr is the address of an in-memory connection.
+type Addr string
+
+func (a Addr) Network() string { return "memory" }
+
+func (a Addr) String() string { return string(a) }
+
+var _ quic.Connection = (*conn)(nil)
+
+type conn struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	peer      *conn
+	closeOnce *sync.Once
+
+	state  quic.ConnectionState
+	local  net.Addr
+	remote net.Addr
+
+	client bool
+
+	mu       sync.Mutex
+	nextBidi uint64
+	nextUni  uint64
+
+	bidi *queue[*stream]
+	uni  *queue[*receiveStream]
+}
+
+func newConn(nextProto string, local, remote net.Addr, client bool) *conn {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	return &conn{
+		ctx:    ctx,
+		cancel: cancel,
+		state: quic.ConnectionState{
+			TLS: tls.ConnectionState{
+				Version:            tls.VersionTLS13,
+				HandshakeComplete:  true,
+				NegotiatedProtocol: nextProto,
+				ServerName:         "localhost",
+			},
+			SupportsDatagrams: true,
+			Version:           quic.Version(1),
+		},
+		local:  local,
+		remote: remote,
+		client: client,
+		bidi:   newQueue[*stream](),
+		uni:    newQueue[*receiveStream](),
+	}
+}
+
+// streamID returns the ID of the n-th stream of a type opened by this side.
+func (c *conn) streamID(n uint64, uni bool) quic.StreamID {
+	id := n << 2
+	if !c.client {
+		id |= 0x01
+	}
+	if uni {
+		id |= 0x02
+	}
+	return quic.StreamID(id)
+}
+
+func (c *conn) OpenStream() (quic.Stream, error) {
+	if err := context.Cause(c.ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	id := c.streamID(c.nextBidi, false)
+	c.nextBidi++
+	c.mu.Unlock()
+
+	out := newPipe(id, c.ctx, c.peer.ctx)
+	in := newPipe(id, c.peer.ctx, c.ctx)
+
+	c.peer.bidi.push(&stream{
+		sendStream:    sendStream{p: in},
+		receiveStream: receiveStream{p: out},
+	})
+
+	return &stream{
+		sendStream:    sendStream{p: out},
+		receiveStream: receiveStream{p: in},
+	}, nil
+}
+
+func (c *conn) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.OpenStream()
+}
+
+func (c *conn) OpenUniStream() (quic.SendStream, error) {
+	if err := context.Cause(c.ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	id := c.streamID(c.nextUni, true)
+	c.nextUni++
+	c.mu.Unlock()
+
+	p := newPipe(id, c.ctx, c.peer.ctx)
+	c.peer.uni.push(&receiveStream{p: p})
+
+	return &sendStream{p: p}, nil
+}
+
+func (c *conn) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.OpenUniStream()
+}
+
+func (c *conn) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	str, err := c.bidi.pop(ctx, c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+func (c *conn) AcceptUniStream(ctx context.Context) (quic.ReceiveStream, error) {
+	str, err := c.uni.pop(ctx, c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+func (c *conn) CloseWithError(code quic.ApplicationErrorCode, msg string) error {
+	c.closeOnce.Do(func() {
+		c.cancel(&quic.ApplicationError{ErrorCode: code, ErrorMessage: msg, Remote: false})
+		c.peer.cancel(&quic.ApplicationError{ErrorCode: code, ErrorMessage: msg, Remote: true})
+	})
+	return nil
+}
+
+func (c *conn) ConnectionState() quic.ConnectionState {
+	return c.state
+}
+
+func (c *conn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// queue is an unbounded queue of streams waiting to be accepted.
+type queue[T any] struct {
+	mu     sync.Mutex
+	items  []T
+	signal chan struct{}
+}
+
+func newQueue[T any]() *queue[T] {
+	return &queue[T]{signal: make(chan struct{}, 1)}
+}
+
+func (q *queue[T]) push(v T) {
+	q.mu.Lock()
+	q.items = append(q.items, v)
+	q.mu.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue[T]) pop(ctx, connCtx context.Context) (T, error) {
+	var zero T
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0]
+			q.items[0] = zero
+			q.items = q.items[1:]
+			more := len(q.items) > 0
+			q.mu.Unlock()
+
+			if more {
+				// Let another waiting caller take the next one
+				select {
+				case q.signal <- struct{}{}:
+				default:
+				}
+			}
+			return v, nil
+		}
+		q.mu.Unlock()
+
+		if err := context.Cause(connCtx); err != nil {
+			return zero, err
+		}
+
+		select {
+		case <-q.signal:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-connCtx.Done():
+			return zero, context.Cause(connCtx)
+		}
+	}
+}
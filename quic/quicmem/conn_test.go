@@ -0,0 +1,273 @@
+package quicmem
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipe_ConnectionState(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	assert.Equal(t, "moq-00", client.ConnectionState().TLS.NegotiatedProtocol)
+	assert.Equal(t, "moq-00", server.ConnectionState().TLS.NegotiatedProtocol)
+	assert.Equal(t, client.LocalAddr(), server.RemoteAddr())
+	assert.Equal(t, client.RemoteAddr(), server.LocalAddr())
+}
+
+func TestPipe_Stream(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cs, err := client.OpenStreamSync(ctx)
+	require.NoError(t, err)
+
+	ss, err := server.AcceptStream(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, cs.StreamID(), ss.StreamID())
+
+	_, err = cs.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.NoError(t, cs.Close())
+
+	b, err := io.ReadAll(ss)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ping"), b)
+
+	_, err = ss.Write([]byte("pong"))
+	require.NoError(t, err)
+	require.NoError(t, ss.Close())
+
+	b, err = io.ReadAll(cs)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pong"), b)
+}
+
+func TestPipe_StreamIDs(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	ids := func(open func() (quic.StreamID, error)) []quic.StreamID {
+		var ids []quic.StreamID
+		for range 2 {
+			id, err := open()
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	bidi := func(c quic.Connection) func() (quic.StreamID, error) {
+		return func() (quic.StreamID, error) {
+			s, err := c.OpenStream()
+			if err != nil {
+				return 0, err
+			}
+			return s.StreamID(), nil
+		}
+	}
+	uni := func(c quic.Connection) func() (quic.StreamID, error) {
+		return func() (quic.StreamID, error) {
+			s, err := c.OpenUniStream()
+			if err != nil {
+				return 0, err
+			}
+			return s.StreamID(), nil
+		}
+	}
+
+	assert.Equal(t, []quic.StreamID{0, 4}, ids(bidi(client)))
+	assert.Equal(t, []quic.StreamID{1, 5}, ids(bidi(server)))
+	assert.Equal(t, []quic.StreamID{2, 6}, ids(uni(client)))
+	assert.Equal(t, []quic.StreamID{3, 7}, ids(uni(server)))
+}
+
+func TestPipe_UniStreamLargeWrite(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 3*streamWindow/16)
+
+	go func() {
+		s, err := client.OpenUniStream()
+		if err != nil {
+			return
+		}
+		_, _ = s.Write(data)
+		_ = s.Close()
+	}()
+
+	s, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(s)
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestPipe_CancelWrite(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	_, err = cs.Write([]byte("data"))
+	require.NoError(t, err)
+	cs.CancelWrite(7)
+
+	_, err = ss.Read(make([]byte, 4))
+	var serr *quic.StreamError
+	require.ErrorAs(t, err, &serr)
+	assert.Equal(t, quic.StreamErrorCode(7), serr.ErrorCode)
+	assert.True(t, serr.Remote)
+
+	_, err = cs.Write([]byte("more"))
+	require.ErrorAs(t, err, &serr)
+	assert.False(t, serr.Remote)
+
+	require.ErrorAs(t, context.Cause(cs.Context()), &serr)
+	assert.False(t, serr.Remote)
+}
+
+func TestPipe_CancelRead(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	ss.CancelRead(9)
+
+	<-cs.Context().Done()
+	var serr *quic.StreamError
+	require.ErrorAs(t, context.Cause(cs.Context()), &serr)
+	assert.Equal(t, quic.StreamErrorCode(9), serr.ErrorCode)
+	assert.True(t, serr.Remote)
+
+	_, err = cs.Write([]byte("data"))
+	require.ErrorAs(t, err, &serr)
+	assert.True(t, serr.Remote)
+}
+
+func TestPipe_CloseCancelsSendContext(t *testing.T) {
+	client, _ := Pipe("moq-00")
+
+	s, err := client.OpenUniStream()
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	assert.ErrorIs(t, s.Context().Err(), context.Canceled)
+
+	_, err = s.Write([]byte("data"))
+	assert.Error(t, err)
+
+	// Cancelling after all data was delivered has no effect
+	s.CancelWrite(1)
+}
+
+func TestPipe_ReadDeadline(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+	ss, err := server.AcceptStream(context.Background())
+	require.NoError(t, err)
+	_ = cs
+
+	require.NoError(t, ss.SetReadDeadline(time.Now().Add(20*time.Millisecond)))
+
+	start := time.Now()
+	_, err = ss.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+
+	// Extending the deadline lets a blocked read continue
+	require.NoError(t, ss.SetReadDeadline(time.Time{}))
+	done := make(chan error, 1)
+	go func() {
+		_, err := ss.Read(make([]byte, 1))
+		done <- err
+	}()
+	_, err = cs.Write([]byte("x"))
+	require.NoError(t, err)
+	assert.NoError(t, <-done)
+}
+
+func TestPipe_WriteDeadline(t *testing.T) {
+	client, _ := Pipe("moq-00")
+
+	s, err := client.OpenUniStream()
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetWriteDeadline(time.Now().Add(20*time.Millisecond)))
+
+	// Nobody reads, so the write blocks once the window is full
+	n, err := s.Write(make([]byte, 2*streamWindow))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Equal(t, streamWindow, n)
+}
+
+func TestPipe_AcceptContext(t *testing.T) {
+	_, server := Pipe("moq-00")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := server.AcceptStream(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = server.AcceptUniStream(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPipe_CloseWithError(t *testing.T) {
+	client, server := Pipe("moq-00")
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+	ss, err := server.AcceptStream(context.Background())
+	require.NoError(t, err)
+
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := ss.Read(make([]byte, 1))
+		readErr <- err
+	}()
+
+	require.NoError(t, client.CloseWithError(42, "bye"))
+
+	var appErr *quic.ApplicationError
+
+	err = <-readErr
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, quic.ApplicationErrorCode(42), appErr.ErrorCode)
+	assert.Equal(t, "bye", appErr.ErrorMessage)
+	assert.True(t, appErr.Remote)
+
+	require.ErrorAs(t, context.Cause(client.Context()), &appErr)
+	assert.False(t, appErr.Remote)
+
+	require.ErrorAs(t, context.Cause(cs.Context()), &appErr)
+
+	_, err = server.AcceptStream(context.Background())
+	require.ErrorAs(t, err, &appErr)
+	assert.True(t, appErr.Remote)
+
+	_, err = client.OpenStream()
+	assert.True(t, errors.As(err, &appErr))
+
+	// Closing again is a no-op
+	assert.NoError(t, server.CloseWithError(0, ""))
+	require.ErrorAs(t, context.Cause(server.Context()), &appErr)
+	assert.Equal(t, quic.ApplicationErrorCode(42), appErr.ErrorCode)
+}
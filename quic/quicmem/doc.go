@@ -0,0 +1,18 @@
+// Package quicmem provides in-memory quic.Connection pairs.
+//
+// Connections returned by Pipe never touch the network or TLS. They are meant
+// for running sessions inside a single process, for example to serve a local
+// TrackMux to a component that consumes a Session, and for tests.
+/*
+	client, server := quicmem.Pipe(moqt.NextProtoMOQ)
+
+	go srv.ServeQUICConn(server)
+
+	c := &moqt.Client{
+	    DialQUICFunc: func(context.Context, string, *tls.Config, *quic.Config) (quic.Connection, error) {
+	        return client, nil
+	    },
+	}
+	sess, err := c.DialQUIC(ctx, "memory", "/", nil)
+*/
+package quicmem
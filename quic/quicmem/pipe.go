@@ -0,0 +1,229 @@
+package quicmem
+
+import (
+	"context"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// streamWindow is the number of unread bytes a stream buffers before writes block.
+const streamWindow = 1 << 20
+
+// pipe carries the data of one direction of a stream.
+//
+// The writing side belongs to the connection wctx was derived from and the
+// reading side to the connection of rctx. Every state change is broadcast by
+// closing and replacing the signal channel.
+type pipe struct {
+	id quic.StreamID
+
+	wctx context.Context
+	rctx context.Context
+
+	// sendCtx is the context of the send stream,
+	// canceled when the write side is closed or reset.
+	sendCtx    context.Context
+	cancelSend context.CancelCauseFunc
+
+	mu     sync.Mutex
+	signal chan struct{}
+
+	buf []byte
+	fin bool
+
+	// writeErr is set when the writer canceled the stream and
+	// readErr when the reader did. Each is seen by the other side as a remote error.
+	writeErr *quic.StreamError
+	readErr  *quic.StreamError
+
+	closed bool
+
+	readDeadline  time.Time
+	writeDeadline time.Time
+}
+
+func newPipe(id quic.StreamID, wctx, rctx context.Context) *pipe {
+	p := &pipe{
+		id:     id,
+		wctx:   wctx,
+		rctx:   rctx,
+		signal: make(chan struct{}),
+	}
+	p.sendCtx, p.cancelSend = context.WithCancelCause(wctx)
+	return p
+}
+
+// broadcast wakes up the waiting readers and writers. The caller must hold p.mu.
+func (p *pipe) broadcast() {
+	close(p.signal)
+	p.signal = make(chan struct{})
+}
+
+// wait blocks until the state changes, the context is done or the deadline passes.
+// The caller must hold p.mu, which is released while waiting.
+func (p *pipe) wait(ctx context.Context, deadline time.Time) error {
+	signal := p.signal
+	p.mu.Unlock()
+	defer p.mu.Lock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-signal:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+func (p *pipe) read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for {
+		if p.readErr != nil {
+			return 0, &quic.StreamError{StreamID: p.id, ErrorCode: p.readErr.ErrorCode, Remote: false}
+		}
+		if p.writeErr != nil {
+			return 0, &quic.StreamError{StreamID: p.id, ErrorCode: p.writeErr.ErrorCode, Remote: true}
+		}
+		if len(p.buf) > 0 {
+			n := copy(b, p.buf)
+			p.buf = p.buf[n:]
+			p.broadcast()
+			return n, nil
+		}
+		if p.fin {
+			return 0, io.EOF
+		}
+		if err := context.Cause(p.rctx); err != nil {
+			return 0, err
+		}
+
+		if err := p.wait(p.rctx, p.readDeadline); err != nil {
+			return 0, err
+		}
+	}
+}
+
+func (p *pipe) write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var n int
+	for {
+		if p.writeErr != nil {
+			return n, &quic.StreamError{StreamID: p.id, ErrorCode: p.writeErr.ErrorCode, Remote: false}
+		}
+		if p.closed {
+			return n, errWriteOnClosedStream(p.id)
+		}
+		if p.readErr != nil {
+			return n, &quic.StreamError{StreamID: p.id, ErrorCode: p.readErr.ErrorCode, Remote: true}
+		}
+		if err := context.Cause(p.wctx); err != nil {
+			return n, err
+		}
+		if len(b) == 0 {
+			return n, nil
+		}
+
+		if space := streamWindow - len(p.buf); space > 0 {
+			m := min(space, len(b))
+			p.buf = append(p.buf, b[:m]...)
+			b = b[m:]
+			n += m
+			p.broadcast()
+			continue
+		}
+
+		if err := p.wait(p.wctx, p.writeDeadline); err != nil {
+			return n, err
+		}
+	}
+}
+
+func (p *pipe) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.writeErr != nil {
+		return errCloseCanceledStream(p.id)
+	}
+	if p.closed {
+		return nil
+	}
+
+	p.closed = true
+	p.fin = true
+	p.broadcast()
+	p.cancelSend(nil)
+
+	return nil
+}
+
+// delivered reports whether the reader has received all the data and the FIN.
+// The caller must hold p.mu.
+func (p *pipe) delivered() bool {
+	return p.fin && len(p.buf) == 0
+}
+
+func (p *pipe) cancelWrite(code quic.StreamErrorCode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.writeErr != nil || p.readErr != nil || p.delivered() {
+		return
+	}
+
+	p.writeErr = &quic.StreamError{StreamID: p.id, ErrorCode: code}
+	p.buf = nil
+	p.broadcast()
+	p.cancelSend(&quic.StreamError{StreamID: p.id, ErrorCode: code, Remote: false})
+}
+
+func (p *pipe) cancelRead(code quic.StreamErrorCode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.readErr != nil || p.writeErr != nil || p.delivered() {
+		return
+	}
+
+	p.readErr = &quic.StreamError{StreamID: p.id, ErrorCode: code}
+	p.buf = nil
+	p.broadcast()
+	// Like a STOP_SENDING frame, this resets the send side
+	p.cancelSend(&quic.StreamError{StreamID: p.id, ErrorCode: code, Remote: true})
+}
+
+func (p *pipe) setReadDeadline(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.readDeadline = t
+	p.broadcast()
+}
+
+func (p *pipe) setWriteDeadline(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.writeDeadline = t
+	p.broadcast()
+}
@@ -0,0 +1,88 @@
+package quicmem
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+func errWriteOnClosedStream(id quic.StreamID) error {
+	return fmt.Errorf("write on closed stream %d", id)
+}
+
+func errCloseCanceledStream(id quic.StreamID) error {
+	return fmt.Errorf("close called for canceled stream %d", id)
+}
+
+var _ quic.SendStream = (*sendStream)(nil)
+
+type sendStream struct {
+	p *pipe
+}
+
+func (s *sendStream) Write(b []byte) (int, error) {
+	return s.p.write(b)
+}
+
+func (s *sendStream) Close() error {
+	return s.p.close()
+}
+
+func (s *sendStream) StreamID() quic.StreamID {
+	return s.p.id
+}
+
+func (s *sendStream) CancelWrite(code quic.StreamErrorCode) {
+	s.p.cancelWrite(code)
+}
+
+func (s *sendStream) SetWriteDeadline(t time.Time) error {
+	s.p.setWriteDeadline(t)
+	return nil
+}
+
+func (s *sendStream) Context() context.Context {
+	return s.p.sendCtx
+}
+
+var _ quic.ReceiveStream = (*receiveStream)(nil)
+
+type receiveStream struct {
+	p *pipe
+}
+
+func (s *receiveStream) Read(b []byte) (int, error) {
+	return s.p.read(b)
+}
+
+func (s *receiveStream) StreamID() quic.StreamID {
+	return s.p.id
+}
+
+func (s *receiveStream) CancelRead(code quic.StreamErrorCode) {
+	s.p.cancelRead(code)
+}
+
+func (s *receiveStream) SetReadDeadline(t time.Time) error {
+	s.p.setReadDeadline(t)
+	return nil
+}
+
+var _ quic.Stream = (*stream)(nil)
+
+// stream is a bidirectional stream made of a pipe in each direction.
+type stream struct {
+	sendStream
+	receiveStream
+}
+
+func (s *stream) StreamID() quic.StreamID {
+	return s.sendStream.p.id
+}
+
+func (s *stream) SetDeadline(t time.Time) error {
+	_ = s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
@@ -0,0 +1,782 @@
+package quicmux
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// maxImplicitStreams bounds the number of streams a single frame opens.
+const maxImplicitStreams = 1 << 10
+
+// closeTimeout bounds the time spent sending a CONNECTION_CLOSE frame.
+const closeTimeout = time.Second
+
+// defaultWindow is the flow control window of a stream
+// if quic.Config.InitialStreamReceiveWindow is not set.
+const defaultWindow = 512 << 10
+
+// initialConnWindow is the flow control limit of the connection until the
+// receiver raises it, and defaultConnWindow its window if
+// quic.Config.InitialConnectionReceiveWindow is not set.
+const (
+	initialConnWindow = 1 << 20
+	defaultConnWindow = 4 << 20
+)
+
+// initialMaxStreams is the number of streams of each type a peer may open
+// until the receiver raises it. Both sides assume it, so it is never sent.
+const initialMaxStreams = 100
+
+var errTooManyOpenStreams = errors.New("quicmux: too many open streams")
+
+// Client returns a connection running over nc as the client.
+// nc must be reliable and ordered, such as a TCP or TLS connection.
+//
+// The TLS state reported by the connection is taken from nc if it has a
+// ConnectionState method, as *tls.Conn does. If nextProto is not empty, it
+// is reported as the negotiated application protocol instead of the one of nc.
+//
+// Only InitialStreamReceiveWindow, InitialConnectionReceiveWindow,
+// MaxIncomingStreams and MaxIncomingUniStreams are used from config, which
+// may be nil. The stream limits cannot be lowered below 100 streams.
+func Client(nc net.Conn, nextProto string, config *quic.Config) quic.Connection {
+	return newConn(nc, nextProto, config, true)
+}
+
+// Server returns a connection running over nc as the server.
+// See Client for the parameters.
+func Server(nc net.Conn, nextProto string, config *quic.Config) quic.Connection {
+	return newConn(nc, nextProto, config, false)
+}
+
+var _ quic.Connection = (*conn)(nil)
+
+type conn struct {
+	nc     net.Conn
+	client bool
+	state  quic.ConnectionState
+
+	// window and connWindow are how far the flow control limits of a stream
+	// and of the connection are raised past the data read.
+	window, connWindow uint64
+	// maxBidi and maxUni are how many streams of each type the peer may
+	// have open at once.
+	maxBidi, maxUni uint64
+
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	closing atomic.Bool
+
+	writeMu  sync.Mutex
+	writeBuf []byte
+
+	mu sync.Mutex
+	// nextBidi and nextUni count the streams opened by this side,
+	// peerBidi and peerUni the streams opened by the peer.
+	nextBidi, nextUni uint64
+	peerBidi, peerUni uint64
+
+	// sendMaxBidi and sendMaxUni are the limits set by the peer on the
+	// streams this side opens. streamsSignal is closed and replaced when
+	// they are raised.
+	sendMaxBidi, sendMaxUni uint64
+	streamsSignal           chan struct{}
+
+	// peerMaxBidi and peerMaxUni are the limits given to the peer, and
+	// peerDoneBidi and peerDoneUni count the streams of the peer that ended.
+	peerMaxBidi, peerMaxUni   uint64
+	peerDoneBidi, peerDoneUni uint64
+
+	sendStreams    map[quic.StreamID]*sendStream
+	receiveStreams map[quic.StreamID]*receiveStream
+
+	bidi *queue[*stream]
+	uni  *queue[*receiveStream]
+
+	// flowMu guards the flow control of the connection.
+	flowMu sync.Mutex
+	// sent is the amount of data sent on all streams and sendMaxData
+	// the limit set by the peer.
+	sent, sendMaxData uint64
+	// received is the amount of data received on all streams, consumed
+	// the amount read or discarded and maxData the limit given to the peer.
+	received, consumed, maxData uint64
+}
+
+func newConn(nc net.Conn, nextProto string, config *quic.Config, client bool) *conn {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	c := &conn{
+		nc:             nc,
+		client:         client,
+		window:         defaultWindow,
+		connWindow:     defaultConnWindow,
+		maxBidi:        initialMaxStreams,
+		maxUni:         initialMaxStreams,
+		ctx:            ctx,
+		cancel:         cancel,
+		sendStreams:    make(map[quic.StreamID]*sendStream),
+		receiveStreams: make(map[quic.StreamID]*receiveStream),
+		bidi:           newQueue[*stream](),
+		uni:            newQueue[*receiveStream](),
+		sendMaxBidi:    initialMaxStreams,
+		sendMaxUni:     initialMaxStreams,
+		streamsSignal:  make(chan struct{}),
+		sendMaxData:    initialConnWindow,
+		maxData:        initialConnWindow,
+	}
+
+	if config != nil {
+		if config.InitialStreamReceiveWindow > 0 {
+			c.window = config.InitialStreamReceiveWindow
+		}
+		if config.InitialConnectionReceiveWindow > 0 {
+			c.connWindow = config.InitialConnectionReceiveWindow
+		}
+		c.maxBidi = max(c.maxBidi, uint64(max(config.MaxIncomingStreams, 0)))
+		c.maxUni = max(c.maxUni, uint64(max(config.MaxIncomingUniStreams, 0)))
+	}
+	c.peerMaxBidi = c.maxBidi
+	c.peerMaxUni = c.maxUni
+
+	if tc, ok := nc.(interface{ ConnectionState() tls.ConnectionState }); ok {
+		c.state.TLS = tc.ConnectionState()
+	}
+	if nextProto != "" {
+		c.state.TLS.NegotiatedProtocol = nextProto
+	}
+
+	go c.run()
+
+	// Raise the stream limits the peer assumes
+	if c.maxBidi > initialMaxStreams {
+		c.sendMaxFrame(frameMaxStreamsBidi, c.maxBidi)
+	}
+	if c.maxUni > initialMaxStreams {
+		c.sendMaxFrame(frameMaxStreamsUni, c.maxUni)
+	}
+
+	return c
+}
+
+// sendMaxFrame writes a frame raising a limit of the peer by another
+// goroutine, so that reading never waits for writing.
+func (c *conn) sendMaxFrame(typ uint64, maximum uint64) {
+	go func() {
+		_ = c.writeFrame(func(buf []byte) []byte {
+			return appendMaxFrame(buf, typ, maximum)
+		})
+	}()
+}
+
+// streamID returns the ID of the n-th stream of a type opened by one side.
+func streamID(n uint64, client, uni bool) quic.StreamID {
+	id := n << 2
+	if !client {
+		id |= 0x01
+	}
+	if uni {
+		id |= 0x02
+	}
+	return quic.StreamID(id)
+}
+
+func (c *conn) run() {
+	r := bufio.NewReaderSize(c.nc, 2*maxPayload)
+	buf := make([]byte, maxPayload)
+
+	for {
+		f, err := readFrame(r, buf)
+		if err != nil {
+			if errors.Is(err, errFrameEncoding) {
+				c.closeWithError(&quic.TransportError{ErrorCode: quic.FrameEncodingError, ErrorMessage: err.Error()})
+				return
+			}
+			c.lost(err)
+			return
+		}
+
+		if err := c.handleFrame(f); err != nil {
+			var transportErr *quic.TransportError
+			if !errors.As(err, &transportErr) {
+				transportErr = &quic.TransportError{ErrorCode: quic.InternalError, ErrorMessage: err.Error()}
+			}
+			c.closeWithError(transportErr)
+			return
+		}
+	}
+}
+
+func (c *conn) handleFrame(f frame) error {
+	switch f.typ {
+	case frameConnectionClose:
+		c.closeByPeer(&quic.TransportError{
+			ErrorCode:    quic.TransportErrorCode(f.code),
+			ErrorMessage: f.reason,
+			Remote:       true,
+		})
+		return nil
+	case frameApplicationClose:
+		c.closeByPeer(&quic.ApplicationError{
+			ErrorCode:    quic.ApplicationErrorCode(f.code),
+			ErrorMessage: f.reason,
+			Remote:       true,
+		})
+		return nil
+	case frameMaxStreamsBidi, frameMaxStreamsUni:
+		c.handleMaxStreams(f.typ == frameMaxStreamsUni, f.maximum)
+		return nil
+	case frameMaxData:
+		c.handleMaxData(f.maximum)
+		return nil
+	case frameStream, frameFin, frameResetStream:
+		if c.isLocal(f.streamID) && isUni(f.streamID) {
+			return protocolViolation("receiving on send-only stream %d", f.streamID)
+		}
+		if err := c.openPeerStreams(f.streamID); err != nil {
+			return err
+		}
+		if f.typ == frameStream {
+			if err := c.receive(len(f.data)); err != nil {
+				return err
+			}
+		}
+
+		c.mu.Lock()
+		s, ok := c.receiveStreams[f.streamID]
+		c.mu.Unlock()
+		if !ok {
+			// The stream is already done
+			if f.typ == frameStream {
+				c.consumeAsync(len(f.data))
+			}
+			return nil
+		}
+
+		switch f.typ {
+		case frameStream:
+			return s.handleData(f.data)
+		case frameFin:
+			s.handleFin()
+		case frameResetStream:
+			s.handleReset(quic.StreamErrorCode(f.code))
+			c.removeReceiveStream(f.streamID)
+		}
+		return nil
+	case frameStopSending, frameMaxStreamData:
+		if !c.isLocal(f.streamID) && isUni(f.streamID) {
+			return protocolViolation("sending control frame for receive-only stream %d", f.streamID)
+		}
+		if err := c.openPeerStreams(f.streamID); err != nil {
+			return err
+		}
+
+		c.mu.Lock()
+		s, ok := c.sendStreams[f.streamID]
+		c.mu.Unlock()
+		if !ok {
+			return nil
+		}
+
+		if f.typ == frameMaxStreamData {
+			s.handleMaxStreamData(f.maximum)
+			return nil
+		}
+
+		if s.handleStopSending(quic.StreamErrorCode(f.code)) {
+			c.removeSendStream(f.streamID)
+			// The frame is written by another goroutine,
+			// so that reading never waits for writing
+			go func() {
+				_ = c.writeFrame(func(buf []byte) []byte {
+					return appendStreamControlFrame(buf, frameResetStream, f.streamID, f.code)
+				})
+			}()
+		}
+		return nil
+	default:
+		return protocolViolation("unexpected frame type 0x%x", f.typ)
+	}
+}
+
+func protocolViolation(format string, args ...any) error {
+	return &quic.TransportError{
+		ErrorCode:    quic.ProtocolViolation,
+		ErrorMessage: fmt.Sprintf(format, args...),
+	}
+}
+
+func isUni(id quic.StreamID) bool {
+	return id&0x02 != 0
+}
+
+// isLocal reports whether the stream was opened by this side.
+func (c *conn) isLocal(id quic.StreamID) bool {
+	return (id&0x01 == 0) == c.client
+}
+
+// openPeerStreams opens the stream of the peer with the ID and all the
+// streams of the same type it opened before, as QUIC does.
+func (c *conn) openPeerStreams(id quic.StreamID) error {
+	c.mu.Lock()
+
+	if c.isLocal(id) {
+		var next uint64
+		if isUni(id) {
+			next = c.nextUni
+		} else {
+			next = c.nextBidi
+		}
+		c.mu.Unlock()
+
+		if uint64(id)>>2 >= next {
+			return protocolViolation("frame for stream %d not opened yet", id)
+		}
+		return nil
+	}
+
+	uni := isUni(id)
+	n := uint64(id) >> 2
+
+	next, limit := c.peerBidi, c.peerMaxBidi
+	if uni {
+		next, limit = c.peerUni, c.peerMaxUni
+	}
+	if n >= limit {
+		c.mu.Unlock()
+		return &quic.TransportError{
+			ErrorCode:    quic.StreamLimitError,
+			ErrorMessage: fmt.Sprintf("stream %d exceeds the limit of %d streams", id, limit),
+		}
+	}
+	if n >= next+maxImplicitStreams {
+		c.mu.Unlock()
+		return &quic.TransportError{
+			ErrorCode:    quic.StreamLimitError,
+			ErrorMessage: fmt.Sprintf("stream %d opens too many streams at once", id),
+		}
+	}
+
+	var bidi []*stream
+	var uniStreams []*receiveStream
+	if uni {
+		for ; c.peerUni <= n; c.peerUni++ {
+			sid := streamID(c.peerUni, !c.client, true)
+			rs := newReceiveStream(c, sid)
+			c.receiveStreams[sid] = rs
+			uniStreams = append(uniStreams, rs)
+		}
+	} else {
+		for ; c.peerBidi <= n; c.peerBidi++ {
+			sid := streamID(c.peerBidi, !c.client, false)
+			str := &stream{
+				sendStream:    newSendStream(c, sid),
+				receiveStream: newReceiveStream(c, sid),
+			}
+			c.sendStreams[sid] = str.sendStream
+			c.receiveStreams[sid] = str.receiveStream
+			bidi = append(bidi, str)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, str := range bidi {
+		c.bidi.push(str)
+	}
+	for _, str := range uniStreams {
+		c.uni.push(str)
+	}
+
+	return nil
+}
+
+func (c *conn) removeSendStream(id quic.StreamID) {
+	c.mu.Lock()
+	_, ok := c.sendStreams[id]
+	delete(c.sendStreams, id)
+	if ok {
+		c.retire(id)
+	}
+	c.mu.Unlock()
+}
+
+func (c *conn) removeReceiveStream(id quic.StreamID) {
+	c.mu.Lock()
+	_, ok := c.receiveStreams[id]
+	delete(c.receiveStreams, id)
+	if ok {
+		c.retire(id)
+	}
+	c.mu.Unlock()
+}
+
+// retire lets the peer open another stream once a stream it opened is
+// done in both directions. The caller must hold c.mu.
+func (c *conn) retire(id quic.StreamID) {
+	if c.isLocal(id) {
+		return
+	}
+	if _, ok := c.sendStreams[id]; ok {
+		return
+	}
+	if _, ok := c.receiveStreams[id]; ok {
+		return
+	}
+
+	if isUni(id) {
+		c.peerDoneUni++
+		c.peerMaxUni = c.peerDoneUni + c.maxUni
+		c.sendMaxFrame(frameMaxStreamsUni, c.peerMaxUni)
+	} else {
+		c.peerDoneBidi++
+		c.peerMaxBidi = c.peerDoneBidi + c.maxBidi
+		c.sendMaxFrame(frameMaxStreamsBidi, c.peerMaxBidi)
+	}
+}
+
+func (c *conn) handleMaxStreams(uni bool, maximum uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limit := &c.sendMaxBidi
+	if uni {
+		limit = &c.sendMaxUni
+	}
+	if maximum > *limit {
+		*limit = maximum
+		close(c.streamsSignal)
+		c.streamsSignal = make(chan struct{})
+	}
+}
+
+// receive counts data received on any stream.
+// It fails if the peer exceeded the flow control limit of the connection.
+func (c *conn) receive(n int) error {
+	c.flowMu.Lock()
+	defer c.flowMu.Unlock()
+
+	if c.received+uint64(n) > c.maxData {
+		return &quic.TransportError{
+			ErrorCode:    quic.FlowControlError,
+			ErrorMessage: "connection exceeded its flow control limit",
+		}
+	}
+	c.received += uint64(n)
+
+	return nil
+}
+
+// consume counts data read or discarded and returns the new limit of the
+// connection to send, or zero. The limit is raised once half of the
+// window is consumed.
+func (c *conn) consume(n int) uint64 {
+	c.flowMu.Lock()
+	defer c.flowMu.Unlock()
+
+	c.consumed += uint64(n)
+	if c.maxData-c.consumed >= c.connWindow/2 {
+		return 0
+	}
+	c.maxData = c.consumed + c.connWindow
+
+	return c.maxData
+}
+
+// consumeAsync is consume for the reading goroutine.
+func (c *conn) consumeAsync(n int) {
+	if maximum := c.consume(n); maximum > 0 {
+		c.sendMaxFrame(frameMaxData, maximum)
+	}
+}
+
+// reserve takes up to n bytes of the flow control credit of the
+// connection for sending.
+func (c *conn) reserve(n uint64) uint64 {
+	c.flowMu.Lock()
+	defer c.flowMu.Unlock()
+
+	n = min(n, c.sendMaxData-c.sent)
+	c.sent += n
+
+	return n
+}
+
+func (c *conn) handleMaxData(maximum uint64) {
+	c.flowMu.Lock()
+	raised := maximum > c.sendMaxData
+	if raised {
+		c.sendMaxData = maximum
+	}
+	c.flowMu.Unlock()
+
+	if !raised {
+		return
+	}
+
+	// Wake up the writers waiting for credit
+	c.mu.Lock()
+	streams := make([]*sendStream, 0, len(c.sendStreams))
+	for _, s := range c.sendStreams {
+		streams = append(streams, s)
+	}
+	c.mu.Unlock()
+
+	for _, s := range streams {
+		s.mu.Lock()
+		s.broadcast()
+		s.mu.Unlock()
+	}
+}
+
+// writeFrame writes the frame appended by appendFrame.
+func (c *conn) writeFrame(appendFrame func([]byte) []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if err := context.Cause(c.ctx); err != nil {
+		return err
+	}
+
+	c.writeBuf = appendFrame(c.writeBuf[:0])
+	if _, err := c.nc.Write(c.writeBuf); err != nil {
+		c.lost(err)
+		return context.Cause(c.ctx)
+	}
+
+	return nil
+}
+
+func (c *conn) OpenStream() (quic.Stream, error) {
+	if err := context.Cause(c.ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.nextBidi >= c.sendMaxBidi {
+		return nil, errTooManyOpenStreams
+	}
+
+	id := streamID(c.nextBidi, c.client, false)
+	c.nextBidi++
+
+	str := &stream{
+		sendStream:    newSendStream(c, id),
+		receiveStream: newReceiveStream(c, id),
+	}
+	c.sendStreams[id] = str.sendStream
+	c.receiveStreams[id] = str.receiveStream
+
+	return str, nil
+}
+
+func (c *conn) OpenStreamSync(ctx context.Context) (quic.Stream, error) {
+	return openSync(c, ctx, c.OpenStream)
+}
+
+func (c *conn) OpenUniStream() (quic.SendStream, error) {
+	if err := context.Cause(c.ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.nextUni >= c.sendMaxUni {
+		return nil, errTooManyOpenStreams
+	}
+
+	id := streamID(c.nextUni, c.client, true)
+	c.nextUni++
+
+	s := newSendStream(c, id)
+	c.sendStreams[id] = s
+
+	return s, nil
+}
+
+func (c *conn) OpenUniStreamSync(ctx context.Context) (quic.SendStream, error) {
+	return openSync(c, ctx, c.OpenUniStream)
+}
+
+// openSync calls open until the peer allows another stream.
+func openSync[T any](c *conn, ctx context.Context, open func() (T, error)) (T, error) {
+	var zero T
+	for {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+
+		c.mu.Lock()
+		signal := c.streamsSignal
+		c.mu.Unlock()
+
+		str, err := open()
+		if !errors.Is(err, errTooManyOpenStreams) {
+			return str, err
+		}
+
+		select {
+		case <-signal:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-c.ctx.Done():
+			return zero, context.Cause(c.ctx)
+		}
+	}
+}
+
+func (c *conn) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	str, err := c.bidi.pop(ctx, c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+func (c *conn) AcceptUniStream(ctx context.Context) (quic.ReceiveStream, error) {
+	str, err := c.uni.pop(ctx, c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	return str, nil
+}
+
+// CloseWithError sends the error to the peer and closes the underlying connection.
+func (c *conn) CloseWithError(code quic.ApplicationErrorCode, msg string) error {
+	c.closeWithError(&quic.ApplicationError{ErrorCode: code, ErrorMessage: msg})
+	return nil
+}
+
+// closeWithError closes the connection with a local error, which is sent to the peer.
+func (c *conn) closeWithError(err error) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.cancel(err)
+
+	var typ, code uint64
+	var reason string
+	var appErr *quic.ApplicationError
+	var transportErr *quic.TransportError
+	switch {
+	case errors.As(err, &appErr):
+		typ, code, reason = frameApplicationClose, uint64(appErr.ErrorCode), appErr.ErrorMessage
+	case errors.As(err, &transportErr):
+		typ, code, reason = frameConnectionClose, uint64(transportErr.ErrorCode), transportErr.ErrorMessage
+	}
+
+	// Do not wait long for a write in progress or a peer not reading
+	_ = c.nc.SetWriteDeadline(time.Now().Add(closeTimeout))
+
+	c.writeMu.Lock()
+	_, _ = c.nc.Write(appendCloseFrame(nil, typ, code, reason))
+	c.writeMu.Unlock()
+
+	_ = c.nc.Close()
+}
+
+// closeByPeer closes the connection with the error the peer sent.
+func (c *conn) closeByPeer(err error) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.cancel(err)
+	_ = c.nc.Close()
+}
+
+// lost closes the connection after the underlying connection failed.
+func (c *conn) lost(err error) {
+	if !c.closing.CompareAndSwap(false, true) {
+		return
+	}
+
+	c.cancel(fmt.Errorf("quicmux: connection lost: %w", err))
+	_ = c.nc.Close()
+}
+
+func (c *conn) ConnectionState() quic.ConnectionState {
+	return c.state
+}
+
+func (c *conn) Context() context.Context {
+	return c.ctx
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.nc.LocalAddr()
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.nc.RemoteAddr()
+}
+
+// queue is an unbounded queue of streams waiting to be accepted.
+type queue[T any] struct {
+	mu     sync.Mutex
+	items  []T
+	signal chan struct{}
+}
+
+func newQueue[T any]() *queue[T] {
+	return &queue[T]{signal: make(chan struct{}, 1)}
+}
+
+func (q *queue[T]) push(v T) {
+	q.mu.Lock()
+	q.items = append(q.items, v)
+	q.mu.Unlock()
+
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (q *queue[T]) pop(ctx, connCtx context.Context) (T, error) {
+	var zero T
+	for {
+		q.mu.Lock()
+		if len(q.items) > 0 {
+			v := q.items[0]
+			q.items[0] = zero
+			q.items = q.items[1:]
+			more := len(q.items) > 0
+			q.mu.Unlock()
+
+			if more {
+				// Let another waiting caller take the next one
+				select {
+				case q.signal <- struct{}{}:
+				default:
+				}
+			}
+			return v, nil
+		}
+		q.mu.Unlock()
+
+		if err := context.Cause(connCtx); err != nil {
+			return zero, err
+		}
+
+		select {
+		case <-q.signal:
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-connCtx.Done():
+			return zero, context.Cause(connCtx)
+		}
+	}
+}
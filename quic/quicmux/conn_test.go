@@ -0,0 +1,466 @@
+package quicmux
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pipe(t *testing.T, config *quic.Config) (client, server quic.Connection) {
+	c, s := net.Pipe()
+
+	client = Client(c, "moq-00", config)
+	server = Server(s, "moq-00", config)
+	t.Cleanup(func() {
+		_ = client.CloseWithError(0, "")
+		_ = server.CloseWithError(0, "")
+	})
+
+	return client, server
+}
+
+func TestConn_ConnectionState(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	assert.Equal(t, "moq-00", client.ConnectionState().TLS.NegotiatedProtocol)
+	assert.Equal(t, "moq-00", server.ConnectionState().TLS.NegotiatedProtocol)
+	assert.NotNil(t, client.LocalAddr())
+	assert.NotNil(t, server.RemoteAddr())
+}
+
+func TestConn_Stream(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	cs, err := client.OpenStreamSync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, quic.StreamID(0), cs.StreamID())
+
+	_, err = cs.Write([]byte("ping"))
+	require.NoError(t, err)
+	require.NoError(t, cs.Close())
+
+	ss, err := server.AcceptStream(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, cs.StreamID(), ss.StreamID())
+
+	b, err := io.ReadAll(ss)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ping"), b)
+
+	_, err = ss.Write([]byte("pong"))
+	require.NoError(t, err)
+	require.NoError(t, ss.Close())
+
+	b, err = io.ReadAll(cs)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("pong"), b)
+
+	select {
+	case <-cs.Context().Done():
+	default:
+		t.Fatal("send stream context was not canceled by Close")
+	}
+	assert.Equal(t, context.Canceled, context.Cause(cs.Context()))
+}
+
+func TestConn_StreamsOpenInOrder(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	first, err := client.OpenUniStream()
+	require.NoError(t, err)
+	second, err := client.OpenUniStream()
+	require.NoError(t, err)
+	assert.Equal(t, quic.StreamID(2), first.StreamID())
+	assert.Equal(t, quic.StreamID(6), second.StreamID())
+
+	// Data on the second stream opens the first one as well
+	_, err = second.Write([]byte("b"))
+	require.NoError(t, err)
+
+	s, err := server.AcceptUniStream(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, first.StreamID(), s.StreamID())
+
+	s, err = server.AcceptUniStream(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, second.StreamID(), s.StreamID())
+
+	server2, err := server.OpenStream()
+	require.NoError(t, err)
+	assert.Equal(t, quic.StreamID(1), server2.StreamID())
+}
+
+func TestConn_FlowControl(t *testing.T) {
+	client, server := pipe(t, &quic.Config{InitialStreamReceiveWindow: 128 << 10})
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1<<16)
+
+	// Without a reader, writes stop at the initial window
+	require.NoError(t, cs.SetWriteDeadline(time.Now().Add(100*time.Millisecond)))
+	n, err := cs.Write(data)
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	assert.Equal(t, initialWindow, n)
+
+	require.NoError(t, cs.SetWriteDeadline(time.Time{}))
+	go func() {
+		_, _ = cs.Write(data[n:])
+		_ = cs.Close()
+	}()
+
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(ss)
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestConn_ConnectionFlowControl(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Without a reader, writes on all streams stop at the initial window of the connection
+	var written int
+	var streams []quic.SendStream
+	for range initialConnWindow/initialWindow + 1 {
+		cs, err := client.OpenUniStream()
+		require.NoError(t, err)
+		require.NoError(t, cs.SetWriteDeadline(time.Now().Add(100*time.Millisecond)))
+		n, _ := cs.Write(make([]byte, initialWindow))
+		written += n
+		streams = append(streams, cs)
+	}
+	assert.Equal(t, initialConnWindow, written)
+
+	// Reading the first stream lets the last one continue
+	last := streams[len(streams)-1]
+	require.NoError(t, last.SetWriteDeadline(time.Time{}))
+	done := make(chan error, 1)
+	go func() {
+		_, err := last.Write(make([]byte, initialWindow))
+		done <- err
+	}()
+
+	ss, err := server.AcceptUniStream(ctx)
+	require.NoError(t, err)
+	_, err = io.ReadFull(ss, make([]byte, initialWindow))
+	require.NoError(t, err)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("write did not continue")
+	}
+}
+
+func TestConn_StreamLimit(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var first quic.SendStream
+	for i := range initialMaxStreams {
+		cs, err := client.OpenUniStream()
+		require.NoError(t, err)
+		if i == 0 {
+			first = cs
+		}
+	}
+
+	_, err := client.OpenUniStream()
+	assert.ErrorIs(t, err, errTooManyOpenStreams)
+
+	opened := make(chan quic.SendStream, 1)
+	go func() {
+		cs, err := client.OpenUniStreamSync(ctx)
+		if err == nil {
+			opened <- cs
+		}
+	}()
+
+	// A stream of the peer that ended lets another one open
+	require.NoError(t, first.Close())
+	ss, err := server.AcceptUniStream(ctx)
+	require.NoError(t, err)
+	_, err = io.ReadAll(ss)
+	require.NoError(t, err)
+
+	select {
+	case cs := <-opened:
+		assert.Equal(t, streamID(initialMaxStreams, true, true), cs.StreamID())
+	case <-ctx.Done():
+		t.Fatal("stream was not opened")
+	}
+}
+
+func TestConn_ConcurrentStreams(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	const streams = 8
+	data := bytes.Repeat([]byte("x"), 300<<10)
+
+	for range streams {
+		go func() {
+			s, err := client.OpenUniStream()
+			if err != nil {
+				return
+			}
+			_, _ = s.Write(data)
+			_ = s.Close()
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for range streams {
+		s, err := server.AcceptUniStream(context.Background())
+		require.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b, err := io.ReadAll(s)
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), len(b))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConn_CancelWrite(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	cs, err := client.OpenUniStream()
+	require.NoError(t, err)
+	_, err = cs.Write([]byte("data"))
+	require.NoError(t, err)
+
+	ss, err := server.AcceptUniStream(context.Background())
+	require.NoError(t, err)
+
+	cs.CancelWrite(7)
+
+	var strErr *quic.StreamError
+	_, err = cs.Write([]byte("more"))
+	require.ErrorAs(t, err, &strErr)
+	assert.False(t, strErr.Remote)
+	assert.Equal(t, quic.StreamErrorCode(7), strErr.ErrorCode)
+
+	require.ErrorAs(t, context.Cause(cs.Context()), &strErr)
+	assert.False(t, strErr.Remote)
+
+	assert.Eventually(t, func() bool {
+		_, err := ss.Read(make([]byte, 16))
+		return errors.As(err, &strErr) && strErr.Remote && strErr.ErrorCode == 7
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Error(t, cs.Close())
+}
+
+func TestConn_CancelRead(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+	_, err = cs.Write([]byte("data"))
+	require.NoError(t, err)
+
+	ss, err := server.AcceptStream(context.Background())
+	require.NoError(t, err)
+
+	ss.CancelRead(9)
+
+	var strErr *quic.StreamError
+	_, err = ss.Read(make([]byte, 16))
+	require.ErrorAs(t, err, &strErr)
+	assert.False(t, strErr.Remote)
+
+	// The peer stops sending
+	select {
+	case <-cs.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("send stream context was not canceled by STOP_SENDING")
+	}
+	require.ErrorAs(t, context.Cause(cs.Context()), &strErr)
+	assert.True(t, strErr.Remote)
+	assert.Equal(t, quic.StreamErrorCode(9), strErr.ErrorCode)
+
+	_, err = cs.Write([]byte("more"))
+	require.ErrorAs(t, err, &strErr)
+	assert.True(t, strErr.Remote)
+
+	// The other direction is not affected
+	_, err = ss.Write([]byte("reply"))
+	require.NoError(t, err)
+	require.NoError(t, ss.Close())
+
+	b, err := io.ReadAll(cs)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("reply"), b)
+}
+
+func TestConn_ReadDeadline(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+
+	require.NoError(t, cs.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = cs.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+	require.NoError(t, cs.SetDeadline(time.Time{}))
+	_, err = cs.Write([]byte("x"))
+	require.NoError(t, err)
+
+	ss, err := server.AcceptStream(context.Background())
+	require.NoError(t, err)
+	_, err = ss.Write([]byte("y"))
+	require.NoError(t, err)
+
+	b := make([]byte, 1)
+	_, err = io.ReadFull(cs, b)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("y"), b)
+}
+
+func TestConn_CloseWithError(t *testing.T) {
+	client, server := pipe(t, nil)
+
+	cs, err := client.OpenStream()
+	require.NoError(t, err)
+
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := client.AcceptUniStream(context.Background())
+		accepted <- err
+	}()
+
+	require.NoError(t, server.CloseWithError(42, "bye"))
+
+	var appErr *quic.ApplicationError
+	require.ErrorAs(t, context.Cause(server.Context()), &appErr)
+	assert.False(t, appErr.Remote)
+
+	select {
+	case <-client.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("peer was not closed")
+	}
+	require.ErrorAs(t, context.Cause(client.Context()), &appErr)
+	assert.True(t, appErr.Remote)
+	assert.Equal(t, quic.ApplicationErrorCode(42), appErr.ErrorCode)
+	assert.Equal(t, "bye", appErr.ErrorMessage)
+
+	select {
+	case err := <-accepted:
+		assert.ErrorAs(t, err, &appErr)
+	case <-time.After(time.Second):
+		t.Fatal("accept was not unblocked")
+	}
+
+	_, err = cs.Read(make([]byte, 1))
+	assert.ErrorAs(t, err, &appErr)
+	_, err = client.OpenStream()
+	assert.ErrorAs(t, err, &appErr)
+}
+
+func TestConn_ConnectionLost(t *testing.T) {
+	c, s := net.Pipe()
+	client := Client(c, "", nil)
+
+	require.NoError(t, s.Close())
+
+	select {
+	case <-client.Context().Done():
+	case <-time.After(time.Second):
+		t.Fatal("connection was not closed")
+	}
+	assert.ErrorIs(t, context.Cause(client.Context()), io.EOF)
+}
+
+func TestConn_ProtocolViolation(t *testing.T) {
+	tests := map[string]struct {
+		frames []byte
+		code   quic.TransportErrorCode
+	}{
+		"unknown frame type": {
+			frames: []byte{0x3f},
+			code:   quic.FrameEncodingError,
+		},
+		"flow control": {
+			frames: func() []byte {
+				var b []byte
+				for range initialWindow/maxPayload + 1 {
+					b = appendStreamFrame(b, 2, make([]byte, maxPayload))
+				}
+				return b
+			}(),
+			code: quic.FlowControlError,
+		},
+		"stream limit": {
+			frames: appendStreamControlFrame(nil, frameFin, streamID(initialMaxStreams, true, true), 0),
+			code:   quic.StreamLimitError,
+		},
+		"connection flow control": {
+			frames: func() []byte {
+				// Every stream stays within its own limit
+				var b []byte
+				for i := range uint64(initialConnWindow/initialWindow + 1) {
+					for range initialWindow / maxPayload {
+						b = appendStreamFrame(b, streamID(i, true, true), make([]byte, maxPayload))
+					}
+				}
+				return b
+			}(),
+			code: quic.FlowControlError,
+		},
+		"stream not opened": {
+			frames: appendStreamControlFrame(nil, frameMaxStreamData, 1, 100),
+			code:   quic.ProtocolViolation,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, s := net.Pipe()
+			server := Server(s, "", nil)
+
+			go func() { _, _ = c.Write(tt.frames) }()
+
+			// The connection is closed with a CONNECTION_CLOSE frame
+			f, err := readFrame(bufio.NewReader(c), nil)
+			require.NoError(t, err)
+			assert.Equal(t, frameConnectionClose, f.typ)
+			assert.Equal(t, uint64(tt.code), f.code)
+
+			var transportErr *quic.TransportError
+			require.ErrorAs(t, context.Cause(server.Context()), &transportErr)
+			assert.Equal(t, tt.code, transportErr.ErrorCode)
+			assert.False(t, transportErr.Remote)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package quicmux multiplexes QUIC-like streams over a single reliable,
+// ordered byte stream such as TLS over TCP or a WebSocket.
+//
+// It implements quic.Connection for networks where UDP is blocked. Streams
+// have QUIC stream IDs, can be reset and stopped, and are flow controlled per
+// stream, so a slow reader does not stall the other streams. As in QUIC, the
+// peer limits the number of open streams and the data buffered on the whole
+// connection. As everything shares one byte stream, a lost packet still
+// delays all streams.
+//
+// DialAddr and ListenAddr connect over TLS over TCP and negotiate the
+// application protocol with ALPN. DialWebSocket and Upgrader connect over
+// WebSocket and negotiate it as the WebSocket subprotocol.
+/*
+	c := &moqt.Client{
+	    DialQUICFunc: quicmux.DialAddr,
+	}
+	sess, err := c.Dial(ctx, "moqt://example.com:4443/", nil)
+*/
+package quicmux
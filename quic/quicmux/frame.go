@@ -0,0 +1,177 @@
+package quicmux
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/quic-go/quic-go/quicvarint"
+)
+
+// Frame types. Every frame starts with its type, followed by the fields
+// listed for it, all of which are QUIC variable-length integers except for
+// the stream data and the close reason.
+const (
+	// frameStream carries stream data: stream ID, length, data.
+	frameStream uint64 = 0x00
+	// frameFin ends the data of a stream: stream ID.
+	frameFin uint64 = 0x01
+	// frameResetStream abandons sending on a stream: stream ID, error code.
+	frameResetStream uint64 = 0x02
+	// frameStopSending asks the peer to stop sending on a stream: stream ID, error code.
+	frameStopSending uint64 = 0x03
+	// frameMaxStreamData raises the flow control limit of a stream: stream ID, maximum offset.
+	frameMaxStreamData uint64 = 0x04
+	// frameConnectionClose closes the connection with a transport error:
+	// error code, reason length, reason.
+	frameConnectionClose uint64 = 0x05
+	// frameApplicationClose closes the connection with an application error,
+	// with the fields of frameConnectionClose.
+	frameApplicationClose uint64 = 0x06
+	// frameMaxStreamsBidi raises the number of bidirectional streams the
+	// peer may open: maximum number of streams.
+	frameMaxStreamsBidi uint64 = 0x07
+	// frameMaxStreamsUni raises the number of unidirectional streams the
+	// peer may open: maximum number of streams.
+	frameMaxStreamsUni uint64 = 0x08
+	// frameMaxData raises the flow control limit of the connection: maximum offset.
+	frameMaxData uint64 = 0x09
+)
+
+const (
+	// maxPayload is the largest amount of stream data sent in one frame,
+	// so that the streams sharing the connection are interleaved.
+	maxPayload = 16 << 10
+
+	// maxReasonLength bounds the reason of a CONNECTION_CLOSE frame.
+	maxReasonLength = 1 << 10
+)
+
+var errFrameEncoding = errors.New("quicmux: frame encoding error")
+
+// frame is a decoded frame. Only the fields of its type are set.
+type frame struct {
+	typ      uint64
+	streamID quic.StreamID
+	code     uint64
+	maximum  uint64
+	data     []byte
+	reason   string
+}
+
+func appendStreamFrame(b []byte, id quic.StreamID, data []byte) []byte {
+	b = quicvarint.Append(b, frameStream)
+	b = quicvarint.Append(b, uint64(id))
+	b = quicvarint.Append(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+func appendStreamControlFrame(b []byte, typ uint64, id quic.StreamID, value uint64) []byte {
+	b = quicvarint.Append(b, typ)
+	b = quicvarint.Append(b, uint64(id))
+	if typ != frameFin {
+		b = quicvarint.Append(b, value)
+	}
+	return b
+}
+
+func appendMaxFrame(b []byte, typ uint64, maximum uint64) []byte {
+	b = quicvarint.Append(b, typ)
+	return quicvarint.Append(b, maximum)
+}
+
+func appendCloseFrame(b []byte, typ uint64, code uint64, reason string) []byte {
+	if len(reason) > maxReasonLength {
+		reason = reason[:maxReasonLength]
+	}
+	b = quicvarint.Append(b, typ)
+	b = quicvarint.Append(b, code)
+	b = quicvarint.Append(b, uint64(len(reason)))
+	return append(b, reason...)
+}
+
+// readFrame reads the next frame. The data of a STREAM frame is read into
+// buf if it is large enough.
+func readFrame(r *bufio.Reader, buf []byte) (frame, error) {
+	var f frame
+
+	typ, err := quicvarint.Read(r)
+	if err != nil {
+		return f, err
+	}
+	f.typ = typ
+
+	if typ > frameMaxData {
+		return f, fmt.Errorf("%w: unknown frame type 0x%x", errFrameEncoding, typ)
+	}
+
+	if typ == frameConnectionClose || typ == frameApplicationClose {
+		if f.code, err = quicvarint.Read(r); err != nil {
+			return f, unexpectedEOF(err)
+		}
+		n, err := quicvarint.Read(r)
+		if err != nil {
+			return f, unexpectedEOF(err)
+		}
+		if n > maxReasonLength {
+			return f, fmt.Errorf("%w: reason of %d bytes", errFrameEncoding, n)
+		}
+		reason := make([]byte, n)
+		if _, err := io.ReadFull(r, reason); err != nil {
+			return f, unexpectedEOF(err)
+		}
+		f.reason = string(reason)
+		return f, nil
+	}
+
+	if typ == frameMaxStreamsBidi || typ == frameMaxStreamsUni || typ == frameMaxData {
+		if f.maximum, err = quicvarint.Read(r); err != nil {
+			return f, unexpectedEOF(err)
+		}
+		return f, nil
+	}
+
+	id, err := quicvarint.Read(r)
+	if err != nil {
+		return f, unexpectedEOF(err)
+	}
+	f.streamID = quic.StreamID(id)
+
+	switch typ {
+	case frameStream:
+		n, err := quicvarint.Read(r)
+		if err != nil {
+			return f, unexpectedEOF(err)
+		}
+		if n > maxPayload {
+			return f, fmt.Errorf("%w: stream frame of %d bytes", errFrameEncoding, n)
+		}
+		if uint64(cap(buf)) < n {
+			buf = make([]byte, n)
+		}
+		f.data = buf[:n]
+		if _, err := io.ReadFull(r, f.data); err != nil {
+			return f, unexpectedEOF(err)
+		}
+	case frameFin:
+	case frameResetStream, frameStopSending:
+		if f.code, err = quicvarint.Read(r); err != nil {
+			return f, unexpectedEOF(err)
+		}
+	case frameMaxStreamData:
+		if f.maximum, err = quicvarint.Read(r); err != nil {
+			return f, unexpectedEOF(err)
+		}
+	}
+
+	return f, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
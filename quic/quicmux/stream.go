@@ -0,0 +1,435 @@
+package quicmux
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// initialWindow is the flow control limit of every stream until the
+// receiver raises it. Both sides assume it, so it is never sent.
+const initialWindow = 64 << 10
+
+func errWriteOnClosedStream(id quic.StreamID) error {
+	return fmt.Errorf("write on closed stream %d", id)
+}
+
+func errCloseCanceledStream(id quic.StreamID) error {
+	return fmt.Errorf("close called for canceled stream %d", id)
+}
+
+// waitSignal blocks until signal is closed, the connection is closed or the deadline passes.
+// The caller must hold mu, which is released while waiting.
+func waitSignal(mu *sync.Mutex, signal chan struct{}, connCtx context.Context, deadline time.Time) error {
+	mu.Unlock()
+	defer mu.Lock()
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-signal:
+		return nil
+	case <-connCtx.Done():
+		return context.Cause(connCtx)
+	case <-timeout:
+		return os.ErrDeadlineExceeded
+	}
+}
+
+var _ quic.SendStream = (*sendStream)(nil)
+
+type sendStream struct {
+	conn *conn
+	id   quic.StreamID
+
+	// ctx is canceled when the stream is closed or reset.
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	mu     sync.Mutex
+	signal chan struct{}
+
+	// offset is the amount of data sent and maxData the limit set by the peer.
+	offset  uint64
+	maxData uint64
+
+	closed bool
+
+	// resetCode is set when the stream was canceled locally and
+	// stopCode when the peer asked to stop sending.
+	resetCode *quic.StreamErrorCode
+	stopCode  *quic.StreamErrorCode
+
+	deadline time.Time
+}
+
+func newSendStream(c *conn, id quic.StreamID) *sendStream {
+	s := &sendStream{
+		conn:    c,
+		id:      id,
+		signal:  make(chan struct{}),
+		maxData: initialWindow,
+	}
+	s.ctx, s.cancel = context.WithCancelCause(c.ctx)
+	return s
+}
+
+// broadcast wakes up the waiting writers. The caller must hold s.mu.
+func (s *sendStream) broadcast() {
+	close(s.signal)
+	s.signal = make(chan struct{})
+}
+
+// writeErr returns the error a write fails with. The caller must hold s.mu.
+func (s *sendStream) writeErr() error {
+	if s.resetCode != nil {
+		return &quic.StreamError{StreamID: s.id, ErrorCode: *s.resetCode, Remote: false}
+	}
+	if s.closed {
+		return errWriteOnClosedStream(s.id)
+	}
+	if s.stopCode != nil {
+		return &quic.StreamError{StreamID: s.id, ErrorCode: *s.stopCode, Remote: true}
+	}
+	return context.Cause(s.conn.ctx)
+}
+
+func (s *sendStream) Write(b []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int
+	for {
+		if err := s.writeErr(); err != nil {
+			return n, err
+		}
+		if len(b) == 0 {
+			return n, nil
+		}
+
+		var m uint64
+		if credit := s.maxData - s.offset; credit > 0 {
+			m = s.conn.reserve(min(credit, uint64(len(b)), maxPayload))
+		}
+		if m > 0 {
+			s.offset += m
+
+			s.mu.Unlock()
+			err := s.conn.writeFrame(func(buf []byte) []byte {
+				return appendStreamFrame(buf, s.id, b[:m])
+			})
+			s.mu.Lock()
+			if err != nil {
+				return n, err
+			}
+
+			b = b[m:]
+			n += int(m)
+			continue
+		}
+
+		if err := waitSignal(&s.mu, s.signal, s.conn.ctx, s.deadline); err != nil {
+			return n, err
+		}
+	}
+}
+
+func (s *sendStream) Close() error {
+	s.mu.Lock()
+	if s.resetCode != nil {
+		s.mu.Unlock()
+		return errCloseCanceledStream(s.id)
+	}
+	if s.closed || s.stopCode != nil {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.broadcast()
+	s.mu.Unlock()
+
+	err := s.conn.writeFrame(func(buf []byte) []byte {
+		return appendStreamControlFrame(buf, frameFin, s.id, 0)
+	})
+
+	s.cancel(nil)
+	s.conn.removeSendStream(s.id)
+
+	return err
+}
+
+func (s *sendStream) StreamID() quic.StreamID {
+	return s.id
+}
+
+func (s *sendStream) CancelWrite(code quic.StreamErrorCode) {
+	s.mu.Lock()
+	if s.resetCode != nil || s.stopCode != nil || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.resetCode = &code
+	s.broadcast()
+	s.mu.Unlock()
+
+	_ = s.conn.writeFrame(func(buf []byte) []byte {
+		return appendStreamControlFrame(buf, frameResetStream, s.id, uint64(code))
+	})
+
+	s.cancel(&quic.StreamError{StreamID: s.id, ErrorCode: code, Remote: false})
+	s.conn.removeSendStream(s.id)
+}
+
+func (s *sendStream) SetWriteDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = t
+	s.broadcast()
+
+	return nil
+}
+
+func (s *sendStream) Context() context.Context {
+	return s.ctx
+}
+
+// handleStopSending resets the stream at the request of the peer.
+// It reports whether a RESET_STREAM frame has to be sent.
+func (s *sendStream) handleStopSending(code quic.StreamErrorCode) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.resetCode != nil || s.stopCode != nil || s.closed {
+		return false
+	}
+	s.stopCode = &code
+	s.broadcast()
+	s.cancel(&quic.StreamError{StreamID: s.id, ErrorCode: code, Remote: true})
+
+	return true
+}
+
+func (s *sendStream) handleMaxStreamData(maximum uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if maximum > s.maxData {
+		s.maxData = maximum
+		s.broadcast()
+	}
+}
+
+var _ quic.ReceiveStream = (*receiveStream)(nil)
+
+type receiveStream struct {
+	conn *conn
+	id   quic.StreamID
+
+	mu     sync.Mutex
+	signal chan struct{}
+
+	buf []byte
+	fin bool
+
+	// readOffset is the amount of data read by the application,
+	// received the amount of data received and maxData the limit given to the peer.
+	readOffset uint64
+	received   uint64
+	maxData    uint64
+
+	// cancelCode is set when reading was canceled locally and
+	// resetCode when the peer reset the stream.
+	cancelCode *quic.StreamErrorCode
+	resetCode  *quic.StreamErrorCode
+
+	deadline time.Time
+}
+
+func newReceiveStream(c *conn, id quic.StreamID) *receiveStream {
+	return &receiveStream{
+		conn:    c,
+		id:      id,
+		signal:  make(chan struct{}),
+		maxData: initialWindow,
+	}
+}
+
+// broadcast wakes up the waiting readers. The caller must hold s.mu.
+func (s *receiveStream) broadcast() {
+	close(s.signal)
+	s.signal = make(chan struct{})
+}
+
+func (s *receiveStream) Read(b []byte) (int, error) {
+	s.mu.Lock()
+
+	for {
+		if s.cancelCode != nil {
+			s.mu.Unlock()
+			return 0, &quic.StreamError{StreamID: s.id, ErrorCode: *s.cancelCode, Remote: false}
+		}
+		if s.resetCode != nil {
+			s.mu.Unlock()
+			return 0, &quic.StreamError{StreamID: s.id, ErrorCode: *s.resetCode, Remote: true}
+		}
+		if len(s.buf) > 0 {
+			n := copy(b, s.buf)
+			s.buf = s.buf[n:]
+			s.readOffset += uint64(n)
+			connMaximum := s.conn.consume(n)
+
+			// Raise the limit once half of the window is consumed
+			var maximum uint64
+			if window := s.conn.window; !s.fin && s.maxData-s.readOffset < window/2 {
+				s.maxData = s.readOffset + window
+				maximum = s.maxData
+			}
+			s.mu.Unlock()
+
+			if maximum > 0 {
+				_ = s.conn.writeFrame(func(buf []byte) []byte {
+					return appendStreamControlFrame(buf, frameMaxStreamData, s.id, maximum)
+				})
+			}
+			if connMaximum > 0 {
+				_ = s.conn.writeFrame(func(buf []byte) []byte {
+					return appendMaxFrame(buf, frameMaxData, connMaximum)
+				})
+			}
+			return n, nil
+		}
+		if s.fin {
+			s.mu.Unlock()
+			s.conn.removeReceiveStream(s.id)
+			return 0, io.EOF
+		}
+
+		if err := waitSignal(&s.mu, s.signal, s.conn.ctx, s.deadline); err != nil {
+			s.mu.Unlock()
+			return 0, err
+		}
+	}
+}
+
+func (s *receiveStream) StreamID() quic.StreamID {
+	return s.id
+}
+
+func (s *receiveStream) CancelRead(code quic.StreamErrorCode) {
+	s.mu.Lock()
+	if s.cancelCode != nil || s.resetCode != nil || (s.fin && len(s.buf) == 0) {
+		s.mu.Unlock()
+		return
+	}
+	s.cancelCode = &code
+	discarded := len(s.buf)
+	s.buf = nil
+	s.broadcast()
+	s.mu.Unlock()
+
+	if maximum := s.conn.consume(discarded); maximum > 0 {
+		_ = s.conn.writeFrame(func(buf []byte) []byte {
+			return appendMaxFrame(buf, frameMaxData, maximum)
+		})
+	}
+
+	_ = s.conn.writeFrame(func(buf []byte) []byte {
+		return appendStreamControlFrame(buf, frameStopSending, s.id, uint64(code))
+	})
+
+	s.conn.removeReceiveStream(s.id)
+}
+
+func (s *receiveStream) SetReadDeadline(t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadline = t
+	s.broadcast()
+
+	return nil
+}
+
+// handleData buffers data received from the peer.
+// It fails if the peer exceeded the flow control limit.
+func (s *receiveStream) handleData(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.received+uint64(len(data)) > s.maxData {
+		return &quic.TransportError{
+			ErrorCode:    quic.FlowControlError,
+			ErrorMessage: fmt.Sprintf("stream %d exceeded its flow control limit", s.id),
+		}
+	}
+	if s.fin {
+		return &quic.TransportError{
+			ErrorCode:    quic.FinalSizeError,
+			ErrorMessage: fmt.Sprintf("data after the end of stream %d", s.id),
+		}
+	}
+	s.received += uint64(len(data))
+
+	if s.cancelCode != nil || s.resetCode != nil {
+		s.conn.consumeAsync(len(data))
+		return nil
+	}
+
+	s.buf = append(s.buf, data...)
+	s.broadcast()
+
+	return nil
+}
+
+func (s *receiveStream) handleFin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fin = true
+	s.broadcast()
+}
+
+func (s *receiveStream) handleReset(code quic.StreamErrorCode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancelCode != nil || s.resetCode != nil {
+		return
+	}
+	s.resetCode = &code
+	s.conn.consumeAsync(len(s.buf))
+	s.buf = nil
+	s.broadcast()
+}
+
+var _ quic.Stream = (*stream)(nil)
+
+// stream is a bidirectional stream made of a send and a receive stream with the same ID.
+type stream struct {
+	*sendStream
+	*receiveStream
+}
+
+func (s *stream) StreamID() quic.StreamID {
+	return s.sendStream.id
+}
+
+func (s *stream) SetDeadline(t time.Time) error {
+	_ = s.SetReadDeadline(t)
+	return s.SetWriteDeadline(t)
+}
@@ -0,0 +1,151 @@
+package quicmux
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// defaultHandshakeTimeout bounds the TLS handshake
+// if quic.Config.HandshakeIdleTimeout is not set.
+const defaultHandshakeTimeout = 5 * time.Second
+
+var errTLSConfigRequired = errors.New("quicmux: TLS configuration is required")
+
+func handshakeTimeout(config *quic.Config) time.Duration {
+	if config != nil && config.HandshakeIdleTimeout > 0 {
+		return config.HandshakeIdleTimeout
+	}
+	return defaultHandshakeTimeout
+}
+
+var _ quic.DialAddrFunc = DialAddr
+
+// DialAddr establishes a connection over TLS over TCP.
+// The application protocol is negotiated with ALPN from tlsConfig.NextProtos,
+// as it is over QUIC.
+func DialAddr(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+	if tlsConfig == nil {
+		return nil, errTLSConfigRequired
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout(quicConfig))
+	defer cancel()
+
+	d := &tls.Dialer{Config: tlsConfig}
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return Client(nc, "", quicConfig), nil
+}
+
+var _ quic.ListenAddrFunc = ListenAddr
+
+// ListenAddr listens for connections over TLS over TCP on addr.
+func ListenAddr(addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Listener, error) {
+	if tlsConfig == nil {
+		return nil, errTLSConfigRequired
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return Listen(ln, tlsConfig, quicConfig), nil
+}
+
+// Listen accepts connections over TLS on ln. ln is closed with the returned listener.
+func Listen(ln net.Listener, tlsConfig *tls.Config, quicConfig *quic.Config) quic.Listener {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	l := &listener{
+		ln:        ln,
+		tlsConfig: tlsConfig,
+		config:    quicConfig,
+		ctx:       ctx,
+		cancel:    cancel,
+		conns:     make(chan quic.Connection),
+	}
+
+	go l.run()
+
+	return l
+}
+
+var _ quic.Listener = (*listener)(nil)
+
+type listener struct {
+	ln        net.Listener
+	tlsConfig *tls.Config
+	config    *quic.Config
+
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+
+	conns chan quic.Connection
+
+	closeOnce sync.Once
+}
+
+func (l *listener) run() {
+	for {
+		nc, err := l.ln.Accept()
+		if err != nil {
+			l.cancel(err)
+			return
+		}
+
+		go l.handshake(nc)
+	}
+}
+
+func (l *listener) handshake(nc net.Conn) {
+	ctx, cancel := context.WithTimeout(l.ctx, handshakeTimeout(l.config))
+	defer cancel()
+
+	tc := tls.Server(nc, l.tlsConfig)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		_ = nc.Close()
+		return
+	}
+
+	conn := Server(tc, "", l.config)
+
+	select {
+	case l.conns <- conn:
+	case <-l.ctx.Done():
+		_ = conn.CloseWithError(0, "")
+	}
+}
+
+func (l *listener) Accept(ctx context.Context) (quic.Connection, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.ctx.Done():
+		return nil, context.Cause(l.ctx)
+	}
+}
+
+func (l *listener) Addr() net.Addr {
+	return l.ln.Addr()
+}
+
+func (l *listener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		l.cancel(net.ErrClosed)
+		err = l.ln.Close()
+	})
+	return err
+}
@@ -0,0 +1,114 @@
+package quicmux
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestDialAddr(t *testing.T) {
+	ln, err := ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{testCertificate(t)},
+		NextProtos:   []string{"moq-00"},
+	}, nil)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		conn, err := ln.Accept(ctx)
+		if err != nil {
+			return
+		}
+		str, err := conn.AcceptStream(ctx)
+		if err != nil {
+			return
+		}
+		_, _ = io.Copy(str, str)
+		_ = str.Close()
+	}()
+
+	conn, err := DialAddr(ctx, ln.Addr().String(), &tls.Config{
+		InsecureSkipVerify: true,
+		NextProtos:         []string{"moq-00"},
+	}, nil)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+
+	assert.Equal(t, "moq-00", conn.ConnectionState().TLS.NegotiatedProtocol)
+	assert.Equal(t, ln.Addr().String(), conn.RemoteAddr().String())
+
+	str, err := conn.OpenStreamSync(ctx)
+	require.NoError(t, err)
+	_, err = str.Write([]byte("echo"))
+	require.NoError(t, err)
+	require.NoError(t, str.Close())
+
+	b, err := io.ReadAll(str)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("echo"), b)
+}
+
+func TestListener_Close(t *testing.T) {
+	ln, err := ListenAddr("127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{testCertificate(t)},
+	}, nil)
+	require.NoError(t, err)
+
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := ln.Accept(context.Background())
+		accepted <- err
+	}()
+
+	require.NoError(t, ln.Close())
+
+	select {
+	case err := <-accepted:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("accept was not unblocked")
+	}
+}
+
+func TestDialAddr_NoTLSConfig(t *testing.T) {
+	_, err := DialAddr(context.Background(), "127.0.0.1:1", nil, nil)
+	assert.ErrorIs(t, err, errTLSConfigRequired)
+
+	_, err = ListenAddr("127.0.0.1:0", nil, nil)
+	assert.ErrorIs(t, err, errTLSConfigRequired)
+}
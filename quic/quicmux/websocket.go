@@ -0,0 +1,495 @@
+package quicmux
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+)
+
+// WebSocket opcodes
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// closeNormal is the WebSocket status code of a normal closure.
+const closeNormal = 1000
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errWebSocketProtocol = errors.New("quicmux: WebSocket protocol error")
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key))
+	h.Write([]byte(websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerTokens returns the comma-separated tokens of the header field.
+func headerTokens(h http.Header, name string) []string {
+	var tokens []string
+	for _, v := range h.Values(name) {
+		for t := range strings.SplitSeq(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+func hasToken(h http.Header, name, token string) bool {
+	return slices.ContainsFunc(headerTokens(h, name), func(t string) bool {
+		return strings.EqualFold(t, token)
+	})
+}
+
+// DialWebSocket establishes a connection over a WebSocket connection to a
+// ws or wss URL, for networks where QUIC cannot get through.
+//
+// The subprotocols offered in the Sec-WebSocket-Protocol field of header
+// are negotiated in place of ALPN: the one selected by the server is
+// reported as the negotiated application protocol. tlsConfig is used for
+// wss URLs and may be nil. The HTTP response is returned even if the
+// handshake fails with a status other than 101.
+func DialWebSocket(ctx context.Context, urlStr string, header http.Header, tlsConfig *tls.Config, quicConfig *quic.Config) (*http.Response, quic.Connection, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secure bool
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		secure = true
+	default:
+		return nil, nil, fmt.Errorf("quicmux: unsupported WebSocket scheme %q", u.Scheme)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		if secure {
+			addr = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			addr = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, handshakeTimeout(quicConfig))
+	defer cancel()
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Abort the handshake when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = nc.SetDeadline(time.Now())
+	})
+	fail := func(err error) error {
+		_ = nc.Close()
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+
+	if secure {
+		var config *tls.Config
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		} else {
+			config = &tls.Config{}
+		}
+		if config.ServerName == "" {
+			config.ServerName = u.Hostname()
+		}
+		// HTTP/2 cannot be upgraded to a WebSocket
+		config.NextProtos = []string{"http/1.1"}
+
+		tc := tls.Client(nc, config)
+		if err := tc.HandshakeContext(ctx); err != nil {
+			stop()
+			return nil, nil, fail(err)
+		}
+		nc = tc
+	}
+
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header, len(header)+4),
+	}
+	for k, v := range header {
+		k = http.CanonicalHeaderKey(k)
+		req.Header[k] = append(req.Header[k], v...)
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if err := req.Write(nc); err != nil {
+		stop()
+		return nil, nil, fail(err)
+	}
+
+	r := bufio.NewReader(nc)
+	rsp, err := http.ReadResponse(r, req)
+	if !stop() || err != nil {
+		return rsp, nil, fail(err)
+	}
+	_ = nc.SetDeadline(time.Time{})
+
+	if rsp.StatusCode != http.StatusSwitchingProtocols {
+		return rsp, nil, fail(fmt.Errorf("quicmux: WebSocket handshake failed with status %s", rsp.Status))
+	}
+	if !hasToken(rsp.Header, "Upgrade", "websocket") ||
+		!hasToken(rsp.Header, "Connection", "upgrade") ||
+		rsp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return rsp, nil, fail(fmt.Errorf("%w: invalid handshake response", errWebSocketProtocol))
+	}
+
+	protocol := rsp.Header.Get("Sec-WebSocket-Protocol")
+	if protocol != "" && !slices.Contains(headerTokens(req.Header, "Sec-WebSocket-Protocol"), protocol) {
+		return rsp, nil, fail(fmt.Errorf("%w: server selected subprotocol %q not offered", errWebSocketProtocol, protocol))
+	}
+
+	ws := &wsConn{nc: nc, r: r, client: true}
+
+	return rsp, Client(ws, protocol, quicConfig), nil
+}
+
+// Upgrader upgrades HTTP/1.1 requests to connections over WebSocket.
+type Upgrader struct {
+	// Protocols lists the supported subprotocols in order of preference.
+	// If it is not empty, requests offering none of them are rejected.
+	Protocols []string
+
+	// CheckOrigin validates the Origin header of requests.
+	// If nil, all origins are accepted.
+	CheckOrigin func(*http.Request) bool
+
+	// Config configures the connections and may be nil.
+	Config *quic.Config
+}
+
+// Upgrade completes the WebSocket handshake and returns a connection run
+// over the hijacked HTTP connection. On failure, an error response has
+// been written to w.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request) (quic.Connection, error) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return nil, fmt.Errorf("%w: method %s", errWebSocketProtocol, r.Method)
+	}
+	if !hasToken(r.Header, "Upgrade", "websocket") || !hasToken(r.Header, "Connection", "upgrade") {
+		http.Error(w, "not a WebSocket handshake", http.StatusBadRequest)
+		return nil, fmt.Errorf("%w: not a WebSocket handshake", errWebSocketProtocol)
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, fmt.Errorf("%w: unsupported version", errWebSocketProtocol)
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if nonce, err := base64.StdEncoding.DecodeString(key); err != nil || len(nonce) != 16 {
+		http.Error(w, "invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, fmt.Errorf("%w: invalid key", errWebSocketProtocol)
+	}
+	if u.CheckOrigin != nil && !u.CheckOrigin(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return nil, errors.New("quicmux: origin not allowed")
+	}
+
+	var protocol string
+	if len(u.Protocols) > 0 {
+		offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+		for _, p := range u.Protocols {
+			if slices.Contains(offered, p) {
+				protocol = p
+				break
+			}
+		}
+		if protocol == "" {
+			http.Error(w, "no supported subprotocol", http.StatusBadRequest)
+			return nil, fmt.Errorf("%w: no supported subprotocol in %v", errWebSocketProtocol, offered)
+		}
+	}
+
+	nc, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil, fmt.Errorf("quicmux: failed to hijack the connection: %w", err)
+	}
+	// Clear the deadlines set by the HTTP server
+	_ = nc.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	b.WriteString("Upgrade: websocket\r\n")
+	b.WriteString("Connection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n")
+	if protocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+	}
+	b.WriteString("\r\n")
+
+	if _, err := io.WriteString(nc, b.String()); err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+
+	ws := &wsConn{nc: nc, r: brw.Reader, client: false}
+
+	return Server(ws, protocol, u.Config), nil
+}
+
+var _ net.Conn = (*wsConn)(nil)
+
+// wsConn is a byte stream carried in binary WebSocket messages.
+// Message boundaries are not preserved.
+type wsConn struct {
+	nc     net.Conn
+	r      *bufio.Reader
+	client bool
+
+	// remaining is the payload length left in the current data frame.
+	remaining uint64
+	masked    bool
+	mask      [4]byte
+	maskPos   int
+
+	writeMu   sync.Mutex
+	writeBuf  []byte
+	closeSent bool
+}
+
+func (c *wsConn) Read(b []byte) (int, error) {
+	for c.remaining == 0 {
+		if err := c.nextFrame(); err != nil {
+			return 0, err
+		}
+	}
+
+	if uint64(len(b)) > c.remaining {
+		b = b[:c.remaining]
+	}
+	n, err := c.r.Read(b)
+	if c.masked {
+		for i := range n {
+			b[i] ^= c.mask[c.maskPos&3]
+			c.maskPos++
+		}
+	}
+	c.remaining -= uint64(n)
+
+	return n, unexpectedEOF(err)
+}
+
+// nextFrame reads the header of the next data frame, handling the control frames before it.
+func (c *wsConn) nextFrame() error {
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(c.r, header[:]); err != nil {
+			return err
+		}
+
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7F)
+
+		// Clients mask their frames and servers do not
+		if masked == c.client {
+			return fmt.Errorf("%w: unexpected masking", errWebSocketProtocol)
+		}
+
+		switch length {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return unexpectedEOF(err)
+			}
+			length = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+				return unexpectedEOF(err)
+			}
+			length = binary.BigEndian.Uint64(ext[:])
+		}
+
+		c.masked = masked
+		c.maskPos = 0
+		if masked {
+			if _, err := io.ReadFull(c.r, c.mask[:]); err != nil {
+				return unexpectedEOF(err)
+			}
+		}
+
+		switch opcode {
+		case opBinary, opContinuation:
+			c.remaining = length
+			return nil
+		case opClose, opPing, opPong:
+			if length > 125 {
+				return fmt.Errorf("%w: control frame of %d bytes", errWebSocketProtocol, length)
+			}
+			payload := make([]byte, length)
+			if _, err := io.ReadFull(c.r, payload); err != nil {
+				return unexpectedEOF(err)
+			}
+			if masked {
+				for i := range payload {
+					payload[i] ^= c.mask[i&3]
+				}
+			}
+
+			switch opcode {
+			case opPing:
+				if err := c.writeFrame(opPong, payload); err != nil {
+					return err
+				}
+			case opClose:
+				_ = c.sendClose()
+				return io.EOF
+			}
+		case opText:
+			return fmt.Errorf("%w: unexpected text message", errWebSocketProtocol)
+		default:
+			return fmt.Errorf("%w: unknown opcode 0x%x", errWebSocketProtocol, opcode)
+		}
+	}
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closeSent {
+		return net.ErrClosed
+	}
+
+	b := append(c.writeBuf[:0], 0x80|opcode)
+
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, maskBit|byte(n))
+	case n <= 0xFFFF:
+		b = append(b, maskBit|126)
+		b = binary.BigEndian.AppendUint16(b, uint16(n))
+	default:
+		b = append(b, maskBit|127)
+		b = binary.BigEndian.AppendUint64(b, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		b = append(b, mask[:]...)
+		start := len(b)
+		b = append(b, payload...)
+		for i := range payload {
+			b[start+i] ^= mask[i&3]
+		}
+	} else {
+		b = append(b, payload...)
+	}
+	c.writeBuf = b
+
+	_, err := c.nc.Write(b)
+	return err
+}
+
+func (c *wsConn) Write(b []byte) (int, error) {
+	if err := c.writeFrame(opBinary, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// sendClose sends a close frame unless one has been sent.
+func (c *wsConn) sendClose() error {
+	if err := c.writeFrame(opClose, binary.BigEndian.AppendUint16(nil, closeNormal)); err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	c.closeSent = true
+	c.writeMu.Unlock()
+
+	return nil
+}
+
+func (c *wsConn) Close() error {
+	_ = c.nc.SetWriteDeadline(time.Now().Add(closeTimeout))
+	_ = c.sendClose()
+	return c.nc.Close()
+}
+
+// ConnectionState returns the state of the TLS connection the WebSocket runs over, if any.
+// The protocol negotiated with ALPN is HTTP and not the one of the connection, so it is cleared.
+func (c *wsConn) ConnectionState() tls.ConnectionState {
+	var state tls.ConnectionState
+	if tc, ok := c.nc.(*tls.Conn); ok {
+		state = tc.ConnectionState()
+		state.NegotiatedProtocol = ""
+	}
+	return state
+}
+
+func (c *wsConn) LocalAddr() net.Addr {
+	return c.nc.LocalAddr()
+}
+
+func (c *wsConn) RemoteAddr() net.Addr {
+	return c.nc.RemoteAddr()
+}
+
+func (c *wsConn) SetDeadline(t time.Time) error {
+	return c.nc.SetDeadline(t)
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.nc.SetReadDeadline(t)
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	return c.nc.SetWriteDeadline(t)
+}
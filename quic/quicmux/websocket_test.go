@@ -0,0 +1,179 @@
+package quicmux
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/okdaichi/gomoqt/quic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// echoServer accepts streams on the connections upgraded by u and echoes them.
+func echoServer(t *testing.T, u *Upgrader, tls bool) *httptest.Server {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := u.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+
+		for {
+			str, err := conn.AcceptStream(context.Background())
+			if err != nil {
+				return
+			}
+			go func() {
+				_, _ = io.Copy(str, str)
+				_ = str.Close()
+			}()
+		}
+	})
+
+	var srv *httptest.Server
+	if tls {
+		srv = httptest.NewTLSServer(h)
+	} else {
+		srv = httptest.NewServer(h)
+	}
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func echo(t *testing.T, conn quic.Connection, data []byte) {
+	t.Helper()
+
+	str, err := conn.OpenStream()
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = str.Write(data)
+		_ = str.Close()
+	}()
+
+	b, err := io.ReadAll(str)
+	require.NoError(t, err)
+	assert.Equal(t, data, b)
+}
+
+func TestDialWebSocket(t *testing.T) {
+	srv := echoServer(t, &Upgrader{Protocols: []string{"moq-00"}}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	header := http.Header{"Sec-WebSocket-Protocol": {"other, moq-00"}}
+	rsp, conn, err := DialWebSocket(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/path", header, nil, nil)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+
+	assert.Equal(t, http.StatusSwitchingProtocols, rsp.StatusCode)
+	assert.Equal(t, "moq-00", conn.ConnectionState().TLS.NegotiatedProtocol)
+
+	echo(t, conn, []byte("hello"))
+	echo(t, conn, make([]byte, 1<<20))
+}
+
+func TestDialWebSocket_TLS(t *testing.T) {
+	srv := echoServer(t, &Upgrader{}, true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, conn, err := DialWebSocket(ctx, "wss"+strings.TrimPrefix(srv.URL, "https"), nil,
+		&tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}, nil)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+
+	assert.Equal(t, "", conn.ConnectionState().TLS.NegotiatedProtocol)
+	assert.True(t, conn.ConnectionState().TLS.HandshakeComplete)
+
+	echo(t, conn, []byte("secure"))
+}
+
+func TestDialWebSocket_Rejected(t *testing.T) {
+	srv := echoServer(t, &Upgrader{
+		Protocols: []string{"moq-00"},
+	}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	// No supported subprotocol
+	rsp, _, err := DialWebSocket(ctx, url, nil, nil, nil)
+	assert.Error(t, err)
+	require.NotNil(t, rsp)
+	assert.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+
+	// Not a WebSocket handshake
+	plain, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	plain.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+
+	_, _, err = DialWebSocket(ctx, srv.URL, nil, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestUpgrader_CheckOrigin(t *testing.T) {
+	srv := echoServer(t, &Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			return r.Header.Get("Origin") == "https://example.com"
+		},
+	}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	rsp, _, err := DialWebSocket(ctx, url, http.Header{"Origin": {"https://evil.example"}}, nil, nil)
+	assert.Error(t, err)
+	require.NotNil(t, rsp)
+	assert.Equal(t, http.StatusForbidden, rsp.StatusCode)
+
+	_, conn, err := DialWebSocket(ctx, url, http.Header{"Origin": {"https://example.com"}}, nil, nil)
+	require.NoError(t, err)
+	defer conn.CloseWithError(0, "")
+
+	echo(t, conn, []byte("allowed"))
+}
+
+func TestDialWebSocket_CloseWithError(t *testing.T) {
+	closed := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&Upgrader{}).Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		<-conn.Context().Done()
+		closed <- context.Cause(conn.Context())
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, conn, err := DialWebSocket(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, conn.CloseWithError(3, "done"))
+
+	select {
+	case err := <-closed:
+		var appErr *quic.ApplicationError
+		require.ErrorAs(t, err, &appErr)
+		assert.True(t, appErr.Remote)
+		assert.Equal(t, quic.ApplicationErrorCode(3), appErr.ErrorCode)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server connection was not closed")
+	}
+}